@@ -17,11 +17,12 @@ import (
 
 // MARK: 定数定義
 const (
-	CPU_CLOCK          = 1_789_772.5 // 1.78MHz
-	SAMPLE_RATE        = 44100       // 44.1kHz
-	APU_CYCLE_INTERVAL = 7457        // 分周器の間隔
-	BUFFER_SIZE        = 1024 * 4    // サンプルバッファサイズ
-	MAX_VOLUME         = 1.0         // 最大音量
+	CPU_CLOCK              = 1_789_772.5 // 1.78MHz
+	SAMPLE_RATE            = 44100       // 44.1kHz
+	APU_CYCLE_INTERVAL     = 7457        // 分周器の間隔
+	APU_CYCLE_INTERVAL_PAL = 8313        // 分周器の間隔 (PAL)
+	BUFFER_SIZE            = 1024 * 4    // サンプルバッファサイズ
+	MAX_VOLUME             = 1.0         // 最大音量
 )
 
 // MARK: 変数定義
@@ -34,13 +35,11 @@ var (
 	ch5Buffer [BUFFER_SIZE]float32
 )
 
-// CPUバスからデータを読み取るための関数型
-type CpuBusReader func(uint16) uint8
-
 // MARK: APUの定義
 type APU struct {
-	cycles uint
-	step   uint8
+	cycles        uint
+	step          uint8
+	frameInterval uint // フレームシーケンサの分周器の間隔
 
 	// チャンネル
 	channel1 SquareWaveChannel
@@ -53,7 +52,6 @@ type APU struct {
 	status       StatusRegister
 
 	sampleClock uint64
-	cpuRead     CpuBusReader
 
 	// 各チャンネルの前回レベルを保持
 	prevLevel1 float32
@@ -67,10 +65,9 @@ type APU struct {
 }
 
 // MARK: APUの初期化メソッド
-func (a *APU) Init(reader CpuBusReader, config config.Config) {
+func (a *APU) Init(config config.Config) {
 	a.cycles = 0
 	a.step = 0
-	a.cpuRead = reader
 	a.config = config
 
 	// 各チャンネルの初期化
@@ -78,7 +75,10 @@ func (a *APU) Init(reader CpuBusReader, config config.Config) {
 	a.channel2.Init(a.config.Apu.LOG_ENABLED)
 	a.channel3.Init(a.config.Apu.LOG_ENABLED)
 	a.channel4.Init(a.config.Apu.LOG_ENABLED)
-	a.channel5.Init(a.cpuRead, a.config.Apu.LOG_ENABLED)
+	a.channel5.Init(a.config.Apu.LOG_ENABLED)
+
+	// 地域ごとのタイミングの設定
+	a.applyRegion(a.config.System.REGION)
 
 	a.frameCounter.Init()
 	a.status.Init()
@@ -97,6 +97,28 @@ func (a *APU) Init(reader CpuBusReader, config config.Config) {
 	}
 }
 
+// MARK: 地域ごとのクロック・分周器・周期テーブルの設定
+func (a *APU) applyRegion(region config.Region) {
+	if region == config.REGION_PAL {
+		a.frameInterval = APU_CYCLE_INTERVAL_PAL
+		a.channel4.register.periodTable = &noiseFrequencyTablePAL
+		a.channel5.frequencyTable = &dmcFrequencyTablePAL
+	} else {
+		// DendyのAPUはNTSCと同じ分周器・テーブルを使う
+		a.frameInterval = APU_CYCLE_INTERVAL
+		a.channel4.register.periodTable = &noiseFrequencyTable
+		a.channel5.frequencyTable = &dmcFrequencyTable
+	}
+
+	// サンプルの生成レートをCPUクロックに合わせる
+	clock := region.CPUClock()
+	a.channel1.buffer.tickRate = clock
+	a.channel2.buffer.tickRate = clock
+	a.channel3.buffer.tickRate = clock
+	a.channel4.buffer.tickRate = clock
+	a.channel5.buffer.tickRate = clock
+}
+
 // MARK: オーディオデバイスの初期化メソッド
 func (a *APU) initAudioDevice() {
 	handle := cgo.NewHandle(a)
@@ -320,6 +342,16 @@ func (a *APU) FrameIRQ() bool {
 	return a.status.FrameIRQ()
 }
 
+// MARK: DMCのDMAの要求の取得 (サンプルを読み込むアドレスと要求の有無)
+func (a *APU) DMCRequest() (uint16, bool) {
+	return a.channel5.baseAddress, a.channel5.dmaRequest
+}
+
+// MARK: DMAで読み込んだサンプルをDMCに渡すメソッド
+func (a *APU) FillDMCSample(value uint8) {
+	a.channel5.fill(value)
+}
+
 // MARK: フレームシーケンサの書き込みメソッド
 func (a *APU) WriteFrameSequencer(data uint8) {
 	a.frameCounter.update(data)
@@ -584,7 +616,7 @@ func (a *APU) Write5ch(address uint16, data uint8) {
 				6   l    ループフラグ
 				3-0 f    周期インデックス
 		*/
-		a.channel5.timerReload = dmcFrequencyTable[a.channel5.register.frequencyIndex]
+		a.channel5.timerReload = a.channel5.frequencyTable[a.channel5.register.frequencyIndex]
 		a.channel5.timer = a.channel5.timerReload
 	case 0x4011:
 		/*
@@ -653,9 +685,9 @@ func (a *APU) clockLengthCounter() {
 
 // MARK: フレームシーケンサのクロック
 func (a *APU) clockFrameSequencer() {
-	if a.cycles >= APU_CYCLE_INTERVAL {
-		// フレームシーケンサは入力の1.789MHzを7457分周する
-		a.cycles %= APU_CYCLE_INTERVAL
+	if a.cycles >= a.frameInterval {
+		// フレームシーケンサは入力の1.789MHzを7457分周する (PALは1.662MHzを8313分周)
+		a.cycles %= a.frameInterval
 		a.step++
 		mode := a.frameCounter.Mode()
 
@@ -8,14 +8,20 @@ var (
 		0x0BE, 0x0A0, 0x08E, 0x080,
 		0x06A, 0x054, 0x048, 0x036,
 	}
+	dmcFrequencyTablePAL = [16]uint16{
+		0x18E, 0x162, 0x13C, 0x12A,
+		0x114, 0x0EC, 0x0D2, 0x0C6,
+		0x0B0, 0x094, 0x084, 0x076,
+		0x062, 0x04E, 0x042, 0x032,
+	}
 )
 
 // MARK: DMCの定義
 type DMCWaveChannel struct {
-	register DMCRegister
-	cpuRead  CpuBusReader
-	enabled  bool
-	irq      bool
+	register       DMCRegister
+	frequencyTable *[16]uint16 // 地域ごとの周期テーブル
+	enabled        bool
+	irq            bool
 
 	// DAC
 	deltaCounter uint8 // 7bit DAC (0-127)
@@ -27,20 +33,30 @@ type DMCWaveChannel struct {
 	// サンプル処理
 	byteCount   uint16
 	baseAddress uint16
-	sample      uint8
+	sample      uint8 // 出力ユニットのシフトレジスタ
 	bitsLeft    uint8
 	bytesLeft   uint16
+	silence     bool // サンプルバッファが空で出力サイクルを始めたとき
+
+	// サンプルバッファ (DMAでCPUを止めてバスから読み込む)
+	sampleBuffer uint8
+	bufferFull   bool
+	dmaRequest   bool // サンプルバッファへの読み込みを要求中
 
 	buffer BlipBuffer
 }
 
 // MARK: DMCの初期化メソッド
-func (dwc *DMCWaveChannel) Init(reader CpuBusReader, log bool) {
+func (dwc *DMCWaveChannel) Init(log bool) {
 	dwc.register = DMCRegister{}
 	dwc.register.Init()
-	dwc.cpuRead = reader
+	dwc.frequencyTable = &dmcFrequencyTable
 	dwc.baseAddress = 0xC000
 	dwc.byteCount = 1
+	dwc.bitsLeft = 8
+	dwc.silence = true
+	dwc.bufferFull = false
+	dwc.dmaRequest = false
 	dwc.buffer.Init(log)
 }
 
@@ -59,45 +75,64 @@ func (dwc *DMCWaveChannel) tick(cycles uint) {
 	}
 
 	dwc.timer = dwc.timerReload
-	if dwc.bitsLeft == 0 {
-		// 次のサンプルをフェッチ
-		if dwc.bytesLeft > 0 {
-			dwc.sample = dwc.cpuRead(dwc.baseAddress)
-			dwc.baseAddress++
-
-			// オーバーフロー
-			if dwc.baseAddress == 0 {
-				dwc.baseAddress = 0x8000
-			}
-			dwc.bytesLeft--
-			dwc.bitsLeft = 8
-
-			if dwc.bytesLeft == 0 {
-				// サンプル終了
-				if dwc.register.loop {
-					dwc.restart()
-				} else if dwc.register.irqEnabled {
-					dwc.irq = true
-				}
+
+	// 1ビット処理 (無音のときは出力レベルを変えない)
+	if !dwc.silence {
+		if (dwc.sample & 0x01) == 1 {
+			if dwc.deltaCounter < 127 {
+				dwc.deltaCounter += 2
 			}
 		} else {
-			// サンプルがない場合
-			return
+			if dwc.deltaCounter > 1 {
+				dwc.deltaCounter -= 2
+			}
 		}
 	}
+	dwc.sample >>= 1
+	dwc.bitsLeft--
 
-	// 1ビット処理
-	if (dwc.sample & 0x01) == 1 {
-		if dwc.deltaCounter < 127 {
-			dwc.deltaCounter += 2
+	if dwc.bitsLeft == 0 {
+		// 次の出力サイクルの開始 (サンプルバッファが空なら無音)
+		dwc.bitsLeft = 8
+		dwc.silence = !dwc.bufferFull
+		if dwc.bufferFull {
+			dwc.sample = dwc.sampleBuffer
+			dwc.bufferFull = false
 		}
-	} else {
-		if dwc.deltaCounter > 1 {
-			dwc.deltaCounter -= 2
+		dwc.requestSample()
+	}
+}
+
+// MARK: サンプルバッファが空ならDMAを要求するメソッド
+func (dwc *DMCWaveChannel) requestSample() {
+	dwc.dmaRequest = !dwc.bufferFull && dwc.bytesLeft > 0
+}
+
+// MARK: DMAで読み込んだサンプルをバッファに入れるメソッド
+func (dwc *DMCWaveChannel) fill(value uint8) {
+	dwc.dmaRequest = false
+	if dwc.bytesLeft == 0 {
+		// DMAの途中でチャンネルが無効化された
+		return
+	}
+	dwc.sampleBuffer = value
+	dwc.bufferFull = true
+	dwc.baseAddress++
+
+	// オーバーフロー
+	if dwc.baseAddress == 0 {
+		dwc.baseAddress = 0x8000
+	}
+	dwc.bytesLeft--
+
+	if dwc.bytesLeft == 0 {
+		// サンプル終了
+		if dwc.register.loop {
+			dwc.restart()
+		} else if dwc.register.irqEnabled {
+			dwc.irq = true
 		}
 	}
-	dwc.sample >>= 1
-	dwc.bitsLeft--
 }
 
 // MARK: DMCの出力メソッド
@@ -124,6 +159,7 @@ func (dwc *DMCWaveChannel) setEnabled(enabled bool) {
 			dwc.timer = dwc.timerReload
 		}
 	}
+	dwc.requestSample()
 }
 
 // MARK: デバッグ出力切り替え
@@ -23,6 +23,16 @@ const (
 	FRAME_COUNTER_MODE_POS = 7
 )
 
+// ノイズの周期テーブル
+var (
+	noiseFrequencyTable = [16]uint16{
+		4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068,
+	}
+	noiseFrequencyTablePAL = [16]uint16{
+		4, 8, 14, 30, 60, 88, 118, 148, 188, 236, 354, 472, 708, 944, 1890, 3778,
+	}
+)
+
 type NoiseShiftMode uint8
 
 // MARK: 矩形波レジスタ
@@ -211,10 +221,13 @@ type NoiseWaveRegister struct {
 
 	// 0x400F
 	keyOffCount uint8
+
+	periodTable *[16]uint16 // 地域ごとの周期テーブル
 }
 
 // MARK: ノイズレジスタの初期化メソッド
 func (nwr *NoiseWaveRegister) Init() {
+	nwr.periodTable = &noiseFrequencyTable
 	nwr.volume = 0x00
 	nwr.envelope = false
 	nwr.keyOffCounter = false
@@ -268,11 +281,7 @@ func (nwr *NoiseWaveRegister) Mode() NoiseShiftMode {
 
 // MARK: ノイズレジスタからノイズのピッチを取得するメソッド
 func (nwr *NoiseWaveRegister) Frequency() float32 {
-	noiseFrequencyTable := [16]uint16{
-		4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068,
-	}
-
-	return float32(noiseFrequencyTable[nwr.frequency])
+	return float32(nwr.periodTable[nwr.frequency])
 }
 
 // MARK: レジスタからボリュームを取得するメソッド
@@ -1,9 +1,13 @@
 package bus
 
 import (
+	"fmt"
+
 	"Famicom-emulator/apu"
 	"Famicom-emulator/cartridge"
+	"Famicom-emulator/cartridge/mappers"
 	"Famicom-emulator/config"
+	"Famicom-emulator/debugger"
 	"Famicom-emulator/joypad"
 	"Famicom-emulator/ppu"
 )
@@ -28,16 +32,23 @@ type Bus struct {
 	apu       *apu.APU                 // APU
 	joypad1   *joypad.JoyPad           // ポインタに変更
 	joypad2   *joypad.JoyPad           // コントローラ (2P)
+	vsSystem  *joypad.VsSystem         // Vs. システムのコイン・DIPスイッチ
 	cycles    uint                     // CPUサイクル
-	canvas    *ppu.Canvas
-	config    *config.Config
-}
+	openBus   uint8                    // CPUのデータバスに最後に乗った値 (オープンバス)
 
-// MARK: Busの初期化メソッド (カートリッジ無し，デバッグ・テスト用)
-func (b *Bus) InitForTest() {
-	for addr := range b.wram {
-		b.wram[addr] = 0x00
-	}
+	ppuClockRemainder uint // CPUサイクルをPPUドットに換算した際の端数 (PAL用)
+
+	trainerRam    [mappers.TRAINER_SIZE]uint8 // プログラムRAMを持たないマッパー用のトレーナー領域
+	trainerMapped bool                        // トレーナー領域をバスに割り当てるかどうか
+
+	oamDma     bool  // OAM DMA の要求中 (次のCPUの読み取りサイクルで開始する)
+	oamDmaPage uint8 // OAM DMA で転送するページ ($XX00-$XXFF)
+
+	canvas   *ppu.Canvas
+	config   *config.Config
+	debugger *debugger.Debugger       // デバッガ (nilなら無効で，フックのコストも掛からない)
+	cdl      *debugger.CodeDataLogger // Code/Data Logger (nilなら無効)
+	trace    *debugger.TraceLogger    // 実行ログ (nilなら無効)
 }
 
 // MARK: Busの初期化メソッド (ConnectComponents後に呼ばれる)
@@ -61,6 +72,7 @@ func (b *Bus) ConnectComponents(
 	cartridge *cartridge.Cartridge,
 	joypad1 *joypad.JoyPad,
 	joypad2 *joypad.JoyPad,
+	vsSystem *joypad.VsSystem,
 	config *config.Config,
 ) {
 	// 設定を反映
@@ -72,12 +84,24 @@ func (b *Bus) ConnectComponents(
 	b.cartridge = cartridge
 	b.joypad1 = joypad1
 	b.joypad2 = joypad2
+	b.vsSystem = vsSystem
+	b.ppuClockRemainder = 0
+
+	// プログラムRAMを持たないマッパーではトレーナーをバス上の小さなRAMに配置
+	b.trainerMapped = false
+	if trainer := b.cartridge.Trainer(); trainer != nil && b.cartridge.Mapper().ProgramRam() == nil {
+		copy(b.trainerRam[:], trainer)
+		b.trainerMapped = true
+	}
 
 	// 各コンポーネントを初期化
 	b.ppu.Init(b.cartridge.Mapper(), *b.config)
-	b.apu.Init(b.ReadByteFrom, *b.config)
+	b.apu.Init(*b.config)
+	b.oamDma = false
+	b.openBus = 0x00
 	b.joypad1.Init()
 	b.joypad2.Init()
+	b.vsSystem.Init(b.config.System.VS_DIP_SWITCHES)
 }
 
 // MARK: NMIを取得
@@ -103,7 +127,9 @@ func (b *Bus) Reset() {
 
 // MARK: 終了処理
 func (b *Bus) Shutdown() {
-	b.cartridge.Mapper().Save()
+	if err := b.cartridge.Save(); err != nil {
+		fmt.Printf("Error saving game data: %v\n", err)
+	}
 }
 
 // MARK: サイクルを進める
@@ -114,8 +140,8 @@ func (b *Bus) Tick(cycles uint) {
 
 	frameEnd := false
 
-	// PPUはCPUの3倍のクロック周波数
-	for range cycles * 3 {
+	// PPUはCPUの3倍 (PALは3.2倍) のクロック周波数
+	for range b.ppuDots(cycles) {
 		if b.ppu.Tick(b.canvas, 1) {
 			frameEnd = true
 
@@ -133,8 +159,102 @@ func (b *Bus) Tick(cycles uint) {
 	}
 }
 
+// MARK: CPUサイクル数を進めるべきPPUドット数に換算
+func (b *Bus) ppuDots(cycles uint) uint {
+	numerator, denominator := b.config.System.REGION.PPUClockRatio()
+	b.ppuClockRemainder += cycles * numerator
+	dots := b.ppuClockRemainder / denominator
+	b.ppuClockRemainder %= denominator
+	return dots
+}
+
+// MARK: デバッガの接続 (nilで切り離し)
+func (b *Bus) AttachDebugger(d *debugger.Debugger) {
+	b.debugger = d
+}
+
+// MARK: デバッガの取得
+func (b *Bus) Debugger() *debugger.Debugger {
+	return b.debugger
+}
+
+// MARK: Code/Data Logger の接続 (nilで切り離し)
+func (b *Bus) AttachCodeDataLogger(l *debugger.CodeDataLogger) {
+	b.cdl = l
+}
+
+// MARK: Code/Data Logger の取得
+func (b *Bus) CodeDataLogger() *debugger.CodeDataLogger {
+	return b.cdl
+}
+
+// MARK: 実行ログの接続 (nilで切り離し)
+func (b *Bus) AttachTraceLogger(t *debugger.TraceLogger) {
+	b.trace = t
+}
+
+// MARK: 実行ログの取得
+func (b *Bus) TraceLogger() *debugger.TraceLogger {
+	return b.trace
+}
+
+// MARK: PPUのフレーム数の取得 (デバッガ用)
+func (b *Bus) PPUFrame() uint {
+	return b.ppu.Frame()
+}
+
+// MARK: PPUのスキャンラインとドットの取得 (デバッガ用)
+func (b *Bus) PPUPosition() (uint16, uint) {
+	return b.ppu.Scanline(), b.ppu.Dot()
+}
+
+// MARK: 副作用なしでメモリを覗き見る (デバッガ用)
+func (b *Bus) PeekByteFrom(address uint16) uint8 {
+	// 読み取りでレジスタの状態が変わるI/O領域は覗かない
+	if PPU_REG_START <= address && address <= 0x401F {
+		return 0x00
+	}
+	return b.readByteFrom(address)
+}
+
 // MARK: メモリの読み取り (1byte)
 func (b *Bus) ReadByteFrom(address uint16) uint8 {
+	value := b.readByteFrom(address)
+	// $4015 は2A03の内部で読まれ，外部のデータバスには出てこない
+	if address != 0x4015 {
+		b.openBus = value
+	}
+	if b.debugger != nil {
+		b.debugger.CPURead(address, value)
+	}
+	if b.cdl != nil {
+		b.cdl.LogData(address)
+	}
+	return value
+}
+
+// MARK: ダミー読み取り (値を捨てる読み取りサイクル)
+func (b *Bus) DummyReadFrom(address uint16) {
+	/*
+		レジスタの副作用 ($2002 のフラグのクリアなど) とオープンバスは本物の読み取りと同じだが，
+		CPUは値を使わないので Code/Data Logger には記録せず，ウォッチポイントにも掛けない
+	*/
+	value := b.readByteFrom(address)
+	if address != 0x4015 {
+		b.openBus = value
+	}
+}
+
+// MARK: DMCのサンプルの読み取り
+func (b *Bus) readSample(address uint16) uint8 {
+	if b.cdl != nil {
+		b.cdl.LogSample(address)
+	}
+	return b.ReadByteFrom(address)
+}
+
+// MARK: メモリの読み取りの本体
+func (b *Bus) readByteFrom(address uint16) uint8 {
 	/*
 		CPUメモリマップ
 
@@ -174,26 +294,43 @@ func (b *Bus) ReadByteFrom(address uint16) uint8 {
 	case address == 0x2006: // PPU_ADDR
 		return b.ppu.ReadOpenBus()
 	case address == 0x2007: // PPU_DATA
+		if b.debugger != nil {
+			ppuAddress := b.ppu.VRAMAddress()
+			value := b.ppu.ReadVRAM()
+			b.debugger.PPURead(ppuAddress, value)
+			return value
+		}
 		return b.ppu.ReadVRAM()
 	case 0x2008 <= address && address <= PPU_REG_END: // PPUレジスタのミラーリング
 		// $2000 ~ $2007 (8bytesを繰り返すようにマスク)
 		ptr := 0x2000 | (address & 0x07)
-		return b.ReadByteFrom(ptr)
-	case address == 0x4014: // OAM_DATA (DMA)
-		// @NOTE 本来はCPU側のOpenBusを返すべき
-		return 0x00
-	case address == 0x4015: // APU
-		return b.apu.ReadStatus()
+		return b.readByteFrom(ptr)
+	case address == 0x4015: // APU (bit5は駆動されないのでオープンバス)
+		return b.apu.ReadStatus() | b.openBus&0x20
 	case address == 0x4016: // JOYPAD (1P)
-		return b.joypad1.Read()
+		if b.cartridge.IsVsSystem() {
+			// Vs. システムはコントローラの配線が逆で，2P側がコイン・DIPスイッチと共に読める
+			return b.vsSystem.Read4016(b.joypad2.Read())
+		}
+		// 上位3bitは駆動されないのでオープンバス (多くの場合アドレスの上位バイトの$40)
+		return b.joypad1.Read() | b.openBus&0xE0
 	case address == 0x4017: // JOYPAD (2P)
-		return b.joypad2.Read()
+		if b.cartridge.IsVsSystem() {
+			return b.vsSystem.Read4017(b.joypad1.Read())
+		}
+		return b.joypad2.Read() | b.openBus&0xE0
+	case b.trainerMapped && mappers.TRAINER_START <= address && address <= mappers.TRAINER_END: // トレーナー
+		return b.trainerRam[address-mappers.TRAINER_START]
 	case 0x6000 <= address && address <= 0x7FFF: // プログラムRAM
-		return b.cartridge.Mapper().ReadProgramRam(address)
+		if value, driven := b.cartridge.Mapper().ReadProgramRam(address); driven {
+			return value
+		}
+		return b.openBus
 	case PRG_ROM_START <= address && address <= PRG_ROM_END: // プログラムROM
 		return b.cartridge.Mapper().ReadProgramRom(address)
 	default:
-		return 0x00
+		// 書き込み専用のレジスタ ($4000-$4014) や未割り当ての領域はオープンバス
+		return b.openBus
 	}
 }
 
@@ -207,6 +344,15 @@ func (b *Bus) ReadWordFrom(address uint16) uint16 {
 
 // MARK: メモリの書き込み (1byte)
 func (b *Bus) WriteByteAt(address uint16, data uint8) {
+	if b.debugger != nil {
+		b.debugger.CPUWrite(address, data)
+	}
+	b.openBus = data
+	b.writeByteAt(address, data)
+}
+
+// MARK: メモリの書き込みの本体
+func (b *Bus) writeByteAt(address uint16, data uint8) {
 	/*
 		CPU メモリマップ
 
@@ -239,10 +385,18 @@ func (b *Bus) WriteByteAt(address uint16, data uint8) {
 	case CPU_WRAM_START <= address && address <= CPU_WRAM_END: // WRAM
 		ptr := address & 0b00000111_11111111 // 11bitにマスク
 		b.wram[ptr] = data
-	case address == 0x2000: // PPU_CTRL
-		b.ppu.WriteToPPUControlRegister(data)
-	case address == 0x2001: // PPU_MASK
-		b.ppu.WriteToPPUMaskRegister(data)
+	case address == 0x2000: // PPU_CTRL (RC2C05は$2001と入れ替わる)
+		if b.ppu.SwapsControlAndMask() {
+			b.ppu.WriteToPPUMaskRegister(data)
+		} else {
+			b.ppu.WriteToPPUControlRegister(data)
+		}
+	case address == 0x2001: // PPU_MASK (RC2C05は$2000と入れ替わる)
+		if b.ppu.SwapsControlAndMask() {
+			b.ppu.WriteToPPUControlRegister(data)
+		} else {
+			b.ppu.WriteToPPUMaskRegister(data)
+		}
 	case address == 0x2002: // PPU_STATUS
 		b.ppu.WriteToPPUStatusRegister(data)
 	case address == 0x2003: // OAM_ADDR
@@ -254,11 +408,14 @@ func (b *Bus) WriteByteAt(address uint16, data uint8) {
 	case address == 0x2006: // PPU_ADDR
 		b.ppu.WriteToPPUInternalRegister(address, data)
 	case address == 0x2007: // PPU_DATA
+		if b.debugger != nil {
+			b.debugger.PPUWrite(b.ppu.VRAMAddress(), data)
+		}
 		b.ppu.WriteVRAM(data)
 	case 0x2008 <= address && address <= PPU_REG_END: // PPUレジスタのミラーリング
 		// $2008 ~ $3FFF は $2000 ~ $2007 (8bytesを繰り返すようにマスク) へミラーリング
 		ptr := 0x2000 | (address & 0x07)
-		b.WriteByteAt(ptr, data)
+		b.writeByteAt(ptr, data)
 	case 0x4000 <= address && address <= 0x4003: // APU 1ch
 		b.apu.Write1ch(address, data)
 	case 0x4004 <= address && address <= 0x4007: // APU 2ch
@@ -278,46 +435,33 @@ func (b *Bus) WriteByteAt(address uint16, data uint8) {
 	case 0x4010 <= address && address <= 0x4013: // APU 5ch
 		b.apu.Write5ch(address, data)
 		// fmt.Printf("W 5ch: %04X -> %02X\n", address, data)
-	case address == 0x4014: // DMA転送
-		var buffer [256]uint8
-		upper := uint16(data) << 8
-		for i := range 256 {
-			buffer[i] = b.ReadByteFrom(upper + uint16(i))
-		}
-
-		// OAM DMA は 513 / 514 CPU サイクル を消費する
-		var dmaCpuCycles uint
-		if b.cycles%2 == 0 {
-			dmaCpuCycles = 513
-		} else {
-			dmaCpuCycles = 514
-		}
-		b.cycles += dmaCpuCycles
-
-		// PPU のサイクルを進める
-		for range dmaCpuCycles * 3 {
-			b.ppu.Tick(b.canvas, 1)
-		}
-
-		// APU のサイクルを進める
-		for range dmaCpuCycles {
-			b.apu.Tick(1)
-		}
-
-		// 用意されたデータを転送
-		b.ppu.DMATransfer(&buffer)
+	case address == 0x4014: // DMA転送 (CPUの次の読み取りサイクルで停止させて転送する)
+		b.oamDmaPage = data
+		b.oamDma = true
 	case address == 0x4015: // APU
 		b.apu.WriteStatus(data)
 	case address == 0x4016: // コントローラ (1P/2P)
 		// @FIXME 2PのB・A同時押しの読み取りミスが多い
 		b.joypad1.Write(data)
 		b.joypad2.Write(data)
+
+		// Vs. システムはbit2でカートリッジのバンクを切り替える
+		if vs, ok := b.cartridge.Mapper().(mappers.VsControlWriter); ok {
+			vs.WriteVsControl(data)
+		}
 	case address == 0x4017: // APU フレームカウンタ
 		b.apu.WriteFrameSequencer(data)
+	case b.trainerMapped && mappers.TRAINER_START <= address && address <= mappers.TRAINER_END: // トレーナー
+		b.trainerRam[address-mappers.TRAINER_START] = data
 	case 0x6000 <= address && address <= 0x7FFF: // プログラムRAM
 		b.cartridge.Mapper().WriteToProgramRam(address, data)
 	case PRG_ROM_START <= address && address <= PRG_ROM_END: // プログラムROM
-		b.cartridge.Mapper().Write(address, data)
+		// 書き込んだサイクルを必要とするマッパー (MMC1) にはサイクルも渡す
+		if m, ok := b.cartridge.Mapper().(mappers.CycleWriter); ok {
+			m.WriteAtCycle(address, data, b.cycles)
+		} else {
+			b.cartridge.Mapper().Write(address, data)
+		}
 	default:
 	}
 }
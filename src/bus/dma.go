@@ -0,0 +1,80 @@
+package bus
+
+// MARK: 定数定義
+const (
+	OAM_DMA_CYCLES = 512 // OAM DMA の読み取り・書き込みのサイクル数 (256byte × 2)
+)
+
+// MARK: DMAの要求があるかどうか (CPUの読み取りサイクルの前に確認する)
+func (b *Bus) DMAPending() bool {
+	_, dmc := b.apu.DMCRequest()
+	return b.oamDma || dmc
+}
+
+/*
+	DMAによるCPUの停止 (CPUは読み取りサイクルでしか止まらない)
+
+	停止サイクル   : CPUが読もうとしていたアドレスをダミーで読む
+	整列サイクル   : get / put の周期を合わせるためのダミー読み取り
+	DMC            : 停止とダミーのサイクルの後，get サイクルでサンプルを1byte読む (計3~4サイクル)
+	OAM DMA        : get サイクルで読み，put サイクルで $2004 に書く (計513~514サイクル)
+
+	OAM DMA の途中にDMCの要求が来たときは，OAM DMA のサイクルがDMCの停止・ダミーのサイクルを兼ねる
+	CPUの書き込みサイクル中に要求が来たときは次の読み取りサイクルまで待つので，盗まれるサイクルが減る
+
+	$4016/$4017 は連続して読んでもシフトしないため整列サイクルでは読まないが，
+	停止サイクルとCPU自身の読み取りで2回シフトし，コントローラのビットが1つ失われる
+*/
+// MARK: DMAの実行 (address はCPUが読もうとしていたアドレス)
+func (b *Bus) RunDMA(address uint16) {
+	skipDummyReads := address == 0x4016 || address == 0x4017
+
+	// 停止サイクル
+	b.Tick(1)
+	b.DummyReadFrom(address)
+
+	// DMCは停止サイクルの後にダミーのサイクルが1つ必要
+	_, dmcRunning := b.apu.DMCRequest()
+	dmcWait := 1
+	oamCycles := 0
+	var oamValue uint8
+
+	for {
+		// 途中で来たDMCの要求には停止とダミーのサイクルが必要 (無効化されたら取り消す)
+		dmcAddress, dmcRequested := b.apu.DMCRequest()
+		if dmcRequested && !dmcRunning {
+			dmcWait = 2
+		}
+		dmcRunning = dmcRequested
+		if !dmcRunning && !b.oamDma {
+			return
+		}
+
+		// OAM DMA のサイクルもDMCの停止・ダミーのサイクルとして数える
+		getCycle := b.cycles%2 == 0
+		dmcReady := dmcRunning && dmcWait == 0
+		if dmcWait > 0 {
+			dmcWait--
+		}
+		b.Tick(1)
+
+		switch {
+		case getCycle && dmcReady:
+			b.apu.FillDMCSample(b.readSample(dmcAddress))
+		case getCycle && b.oamDma:
+			oamValue = b.ReadByteFrom(uint16(b.oamDmaPage)<<8 | uint16(oamCycles/2))
+			oamCycles++
+		case !getCycle && b.oamDma && oamCycles%2 == 1:
+			b.ppu.WriteToOAMDataRegister(oamValue)
+			oamCycles++
+			if oamCycles == OAM_DMA_CYCLES {
+				b.oamDma = false
+			}
+		default:
+			// 整列・ダミーのサイクル
+			if !skipDummyReads {
+				b.DummyReadFrom(address)
+			}
+		}
+	}
+}
@@ -13,8 +13,23 @@ import (
 )
 
 type Cartridge struct {
-	ROM    string // ROMファイルのパス
-	mapper mappers.Mapper
+	ROM         string // ROMファイルのパス
+	SaveDir     string // セーブデータの保存先ディレクトリ
+	SaveBackups int    // セーブデータのバックアップ世代数
+	Database    string // ゲームデータベースのパス (空なら使用しない)
+
+	name      string
+	battery   bool    // バッテリーバックアップの有無
+	trainer   []uint8 // トレーナー (512バイト, 無ければnil)
+	underlay  []uint8 // セーブデータのトレーナーの範囲の内容 (トレーナーはセーブしない)
+	lastSaved []uint8 // 最後に書き出したプログラムRAMの内容
+	backedUp  bool    // 起動後にバックアップのローテーションを済ませたかどうか
+	isNES2    bool    // NES 2.0 ヘッダかどうか
+	crc       uint32  // ROM本体のCRC32
+	timing    TimingMode
+	vsSystem  bool      // Vs. システムのカートリッジかどうか
+	vsPPU     VsPPUType // Vs. システムのPPU
+	mapper    mappers.Mapper
 }
 
 type Mirroring uint8
@@ -23,7 +38,8 @@ const (
 	PRG_ROM_PAGE_SIZE uint = 16 * 1024 // 16kB
 	CHR_ROM_PAGE_SIZE uint = 8 * 1024  // 8kB
 
-	SAVE_DATA_DIR = "../rom/saves/"
+	DEFAULT_SAVE_DATA_DIR = "../rom/saves/"
+	SAVE_DATA_EXT         = ".save"
 )
 
 // カートリッジ先頭のiNESタグ
@@ -33,6 +49,10 @@ var NES_TAG = []uint8{0x4E, 0x45, 0x53, 0x1A}
 func (c *Cartridge) Load() error {
 	ext := filepath.Ext(c.ROM)
 	name := strings.TrimSuffix(filepath.Base(c.ROM), ext)
+	c.name = name
+	if c.SaveDir == "" {
+		c.SaveDir = DEFAULT_SAVE_DATA_DIR
+	}
 
 	// ゲームROMの読み込み
 	gamefile, err := os.ReadFile(c.ROM)
@@ -40,17 +60,6 @@ func (c *Cartridge) Load() error {
 		return fmt.Errorf("couldn't read file %s", c.ROM)
 	}
 
-	// savesディレクトリがなければ作成
-	if _, err := os.Stat(SAVE_DATA_DIR); os.IsNotExist(err) {
-		os.Mkdir(SAVE_DATA_DIR, 0755)
-	}
-
-	// セーブデータの読み込み
-	savefile, err := os.ReadFile(SAVE_DATA_DIR + name + ".save")
-	if err != nil {
-		savefile = []byte{}
-	}
-
 	// NESタグの検証
 	if !reflect.DeepEqual(gamefile[0:4], NES_TAG) {
 		log.Fatalf("Error: invalid cartridge header '%v'", gamefile[0:4])
@@ -60,20 +69,114 @@ func (c *Cartridge) Load() error {
 	// iNESヘッダとマッパーの検証
 	mapperNo := (gamefile[7] & 0xF0) | (gamefile[6] >> 4)
 	iNESVer := (gamefile[7] >> 2) & 0b11
-	if iNESVer != 0 {
-		log.Fatalf("NES2.0 format is not supported")
+	c.isNES2 = iNESVer == 0b10
+	if iNESVer != 0 && !c.isNES2 {
+		log.Fatalf("Unknown iNES header version")
 		return errors.New("Unsupported iNES version")
 	}
+	if c.isNES2 && gamefile[8]&0x0F != 0 {
+		fmt.Printf("[Warning] Cartridge: mapper %d is not supported\n", uint16(gamefile[8]&0x0F)<<8|uint16(mapperNo))
+	}
+	if c.isNES2 && gamefile[9] != 0 {
+		return fmt.Errorf("NES 2.0 ROM sizes above 4 MB are not supported (byte 9 = $%02X)", gamefile[9])
+	}
+
+	// 本体のタイミングの判定 (NES 2.0 ヘッダ > ゲームデータベース, 設定での指定はさらに優先)
+	c.crc = romCRC32(gamefile)
+	c.timing = c.detectTimingMode(gamefile)
+
+	// Vs. システムの判定
+	c.vsSystem = (gamefile[7] & 0b1) != 0
+	c.vsPPU = c.detectVsPPUType(gamefile)
+
+	// セーブデータの読み込み (バッテリーバックアップ付きのカートリッジのみ)
+	c.battery = (gamefile[6] & 0b10) != 0
+	savefile := []byte{}
+	if c.battery {
+		if data, err := os.ReadFile(c.savePath()); err == nil {
+			savefile = data
+		}
+	}
+
+	// トレーナーの読み込み
+	if c.trainer, err = mappers.Trainer(gamefile); err != nil {
+		return err
+	}
 
-	// マッパーオブジェクトを生成・設定
+	// マッパーオブジェクトを生成・設定 (トレーナーはセーブデータの上に配置される)
 	rom := c.selectMapper(mapperNo)
 	rom.Init(name, gamefile, savefile)
 	c.mapper = rom
+	if c.isNES2 {
+		if err := c.checkRAMSizes(gamefile); err != nil {
+			return err
+		}
+	}
+	c.underlay = c.trainerUnderlay(savefile)
+	c.lastSaved = append([]uint8(nil), c.saveImage(rom.ProgramRam())...)
+	c.backedUp = false
 	c.DumpInfo(savefile)
 
 	return nil
 }
 
+// MARK: 本体のタイミングの判定
+func (c *Cartridge) detectTimingMode(gamefile []uint8) TimingMode {
+	if c.isNES2 {
+		/*
+			NES 2.0 ヘッダ Byte 12
+			76543210
+			------VV
+			      ++- 0: NTSC, 1: PAL, 2: マルチリージョン, 3: Dendy
+		*/
+		return TimingMode(gamefile[12] & 0b11)
+	}
+	if entry, ok := lookupDatabase(c.Database, c.crc); ok {
+		return entry.timing
+	}
+	return TIMING_UNKNOWN
+}
+
+/*
+	NES 2.0 ヘッダ Byte 10, 11 (RAMのサイズ)
+
+	Byte 10: 上位4bit = バッテリーバックアップ付きのPRG-RAM, 下位4bit = PRG-RAM
+	Byte 11: 上位4bit = バッテリーバックアップ付きのCHR-RAM, 下位4bit = CHR-RAM
+	サイズは 64 << シフト量 バイト (0なら無し)
+
+	マッパーが持つRAMより大きいサイズは扱えないので読み込まない
+*/
+// MARK: NES 2.0 ヘッダのRAMのサイズの検証
+func (c *Cartridge) checkRAMSizes(gamefile []uint8) error {
+	programRAM := nes2RAMSize(gamefile[10]&0x0F) + nes2RAMSize(gamefile[10]>>4)
+	if programRAM > len(c.mapper.ProgramRam()) {
+		return fmt.Errorf("NES 2.0 PRG-RAM of %d bytes is not supported by %s", programRAM, c.mapper.MapperInfo())
+	}
+
+	characterRAM := nes2RAMSize(gamefile[11]&0x0F) + nes2RAMSize(gamefile[11]>>4)
+	available := 0
+	if c.mapper.IsCharacterRam() {
+		available = len(c.mapper.CharacterRom())
+	}
+	if characterRAM > available {
+		return fmt.Errorf("NES 2.0 CHR-RAM of %d bytes is not supported by %s", characterRAM, c.mapper.MapperInfo())
+	}
+	return nil
+}
+
+// MARK: NES 2.0 ヘッダのRAMのシフト量からサイズを取得
+func nes2RAMSize(shift uint8) int {
+	if shift == 0 {
+		return 0
+	}
+	return 64 << shift
+}
+
+// MARK: 本体のタイミングの取得
+func (c *Cartridge) TimingMode() TimingMode {
+	return c.timing
+}
+
 // MARK: マッパーオブジェクトの選択
 func (c *Cartridge) selectMapper(mapperNo uint8) mappers.Mapper {
 	switch mapperNo {
@@ -87,6 +190,8 @@ func (c *Cartridge) selectMapper(mapperNo uint8) mappers.Mapper {
 		return &mappers.CNROM{}
 	case 0x04:
 		return &mappers.TxROM{}
+	case 0x63:
+		return &mappers.VsUniSystem{}
 	default:
 		return &mappers.NROM{}
 	}
@@ -97,6 +202,11 @@ func (c *Cartridge) Mapper() mappers.Mapper {
 	return c.mapper
 }
 
+// MARK: トレーナーの取得 (無ければnil)
+func (c *Cartridge) Trainer() []uint8 {
+	return c.trainer
+}
+
 // MARK: カートリッジの情報を出力
 func (c *Cartridge) DumpInfo(savefile []uint8) {
 	fmt.Printf("Cartridge loaded:\n")
@@ -112,11 +222,23 @@ func (c *Cartridge) DumpInfo(savefile []uint8) {
 		mirroringStr = "Horizontal"
 	case mappers.MIRRORING_FOUR_SCREEN:
 		mirroringStr = "Four Screen"
+	case mappers.MIRRORING_SINGLE_SCREEN_LOWER, mappers.MIRRORING_SINGLE_SCREEN_UPPER:
+		mirroringStr = "Single Screen"
 	default:
 		mirroringStr = "Unknown"
 	}
 	fmt.Printf("  Mirroring: %s\n", mirroringStr)
+	fmt.Printf("  Timing: %s\n", c.timing)
+	fmt.Printf("  CRC32: %08X\n", c.crc)
+	if c.vsSystem {
+		fmt.Printf("  Vs. System: %s\n", c.vsPPU)
+	}
+	fmt.Printf("  Battery: %v\n", c.battery)
+	fmt.Printf("  Trainer: %v\n", c.trainer != nil)
 
+	if !c.battery {
+		return
+	}
 	if len(savefile) != 0 {
 		fmt.Println("Save data loaded")
 	} else {
@@ -0,0 +1,116 @@
+package cartridge
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MARK: 本体のタイミングの定義 (NES 2.0 の CPU/PPU Timing と同じ並び)
+type TimingMode uint8
+
+const (
+	TIMING_NTSC TimingMode = iota
+	TIMING_PAL
+	TIMING_MULTI
+	TIMING_DENDY
+	TIMING_UNKNOWN
+)
+
+// MARK: タイミング名からタイミングを取得
+func parseTimingMode(name string) (TimingMode, bool) {
+	switch strings.ToLower(name) {
+	case "ntsc":
+		return TIMING_NTSC, true
+	case "pal":
+		return TIMING_PAL, true
+	case "multi", "multiple":
+		return TIMING_MULTI, true
+	case "dendy":
+		return TIMING_DENDY, true
+	default:
+		return TIMING_UNKNOWN, false
+	}
+}
+
+// MARK: タイミング名の取得
+func (t TimingMode) String() string {
+	switch t {
+	case TIMING_NTSC:
+		return "NTSC"
+	case TIMING_PAL:
+		return "PAL"
+	case TIMING_MULTI:
+		return "Multi-region"
+	case TIMING_DENDY:
+		return "Dendy"
+	default:
+		return "Unknown"
+	}
+}
+
+// MARK: ROM本体 (ヘッダを除く) のCRC32を計算
+func romCRC32(gamefile []uint8) uint32 {
+	return crc32.ChecksumIEEE(gamefile[16:])
+}
+
+// MARK: ゲームデータベースの1タイトルの定義
+type databaseEntry struct {
+	timing TimingMode
+	vsPPU  VsPPUType // Vs. システムのPPU (書かれていなければ VS_PPU_NONE)
+}
+
+// MARK: ゲームデータベースの検索
+func lookupDatabase(path string, crc uint32) (databaseEntry, bool) {
+	/*
+		データベースは1行1タイトルのテキストファイル
+
+		# コメント
+		<CRC32 (16進数)> <ntsc|pal|dendy|multi> [Vs. システムのPPU (RP2C04-0004 など)]
+	*/
+	if path == "" {
+		return databaseEntry{}, false
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return databaseEntry{}, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			fmt.Printf("[Warning] Database: invalid line %d in %s\n", lineNo, path)
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "0x"), 16, 32)
+		if err != nil || uint32(value) != crc {
+			continue
+		}
+		timing, ok := parseTimingMode(fields[1])
+		if !ok {
+			continue
+		}
+		entry := databaseEntry{timing: timing}
+		if len(fields) >= 3 {
+			if vsPPU, ok := ParseVsPPUType(fields[2]); ok {
+				entry.vsPPU = vsPPU
+			} else {
+				fmt.Printf("[Warning] Database: unknown Vs. PPU '%s' on line %d in %s\n", fields[2], lineNo, path)
+			}
+		}
+		return entry, true
+	}
+
+	return databaseEntry{}, false
+}
@@ -9,6 +9,7 @@ type CNROM struct {
 	mirroring      Mirroring
 	programRom     []uint8
 	characterRom   []uint8
+	vram           []uint8 // 4画面ミラーリング用のカートリッジVRAM
 }
 
 // MARK: マッパーの初期化
@@ -19,6 +20,7 @@ func (c *CNROM) Init(name string, rom []uint8, save []uint8) {
 	programRom, characterRom := roms(rom)
 	c.isCharacterRam = characterRomSize(rom) == 0
 	c.mirroring = simpleMirroring(rom)
+	c.vram = fourScreenVram(c.mirroring)
 	c.programRom = programRom
 	c.characterRom = characterRom
 }
@@ -30,13 +32,23 @@ func (c *CNROM) Write(address uint16, data uint8) {
 
 // MARK: プログラムROMの読み取り
 func (c *CNROM) ReadProgramRom(address uint16) uint8 {
-	return c.programRom[uint(address)-uint(PRG_ROM_START)]
+	return c.programRom[c.ProgramRomOffset(address)]
+}
+
+// MARK: CPUのアドレスに対応するプログラムROMのオフセットの取得
+func (c *CNROM) ProgramRomOffset(address uint16) uint {
+	return uint(address) - uint(PRG_ROM_START)
 }
 
 // MARK: キャラクタROMの読み取り
 func (c *CNROM) ReadCharacterRom(address uint16) uint8 {
+	return c.characterRom[c.CharacterRomOffset(address)]
+}
+
+// MARK: PPUのアドレスに対応するキャラクタROMのオフセットの取得
+func (c *CNROM) CharacterRomOffset(address uint16) uint {
 	// CNROMはバンクセレクトの下位2ビットのみを使う
-	return c.characterRom[uint(address)+BANK_SIZE*uint(c.bank&0x03)]
+	return uint(address) + BANK_SIZE*uint(c.bank&0x03)
 }
 
 // MARK: キャラクタROMへの書き込み
@@ -45,18 +57,18 @@ func (c *CNROM) WriteToCharacterRom(address uint16, data uint8) {
 }
 
 // MARK: プログラムRAMの読み取り
-func (c *CNROM) ReadProgramRam(address uint16) uint8 {
-	panic("Error: unsupported read program RAM on CNROM")
+func (c *CNROM) ReadProgramRam(address uint16) (uint8, bool) {
+	// CNROM にはプログラムRAMがないためオープンバス
+	return 0x00, false
 }
 
 // MARK: プログラムRAMへの書き込み
 func (c *CNROM) WriteToProgramRam(address uint16, data uint8) {}
 
-// MARK: セーブデータの書き出し
-func (c *CNROM) Save() {}
-
-// MARK: スキャンラインによってIRQを発生させる
-func (c *CNROM) GenerateScanlineIRQ(scanline uint16, backgroundEnable bool) {}
+// MARK: プログラムRAMの取得 (バッテリーバックアップの対象)
+func (c *CNROM) ProgramRam() []uint8 {
+	return nil
+}
 
 // MARK: IRQ状態の取得
 func (c *CNROM) IRQ() bool { return false }
@@ -66,6 +78,21 @@ func (c *CNROM) Mirroring() Mirroring {
 	return c.mirroring
 }
 
+// MARK: ネームテーブルの割り当ての取得
+func (c *CNROM) NameTable(slot uint8) NameTableBank {
+	return mirroringNameTable(c.mirroring, slot)
+}
+
+// MARK: カートリッジ側のネームテーブルの読み取り
+func (c *CNROM) ReadNameTable(bank NameTableBank, offset uint16) uint8 {
+	return readCartridgeNameTable(c.vram, c.characterRom, bank, offset)
+}
+
+// MARK: カートリッジ側のネームテーブルへの書き込み
+func (c *CNROM) WriteToNameTable(bank NameTableBank, offset uint16, data uint8) {
+	writeCartridgeNameTable(c.vram, bank, offset, data)
+}
+
 // MARK: キャラクタRAMを使用するかどうかを取得
 func (c *CNROM) IsCharacterRam() bool {
 	return c.isCharacterRam
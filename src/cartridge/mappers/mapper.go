@@ -1,14 +1,19 @@
 package mappers
 
+import "fmt"
+
 const (
 	BANK_SIZE         uint = 16 * 1024 // 16kB
 	PRG_ROM_PAGE_SIZE uint = 16 * 1024 // 16kB
 	CHR_ROM_PAGE_SIZE uint = 8 * 1024  // 8kB
 	PRG_RAM_SIZE      uint = 8 * 1024  // 8kB
+	TRAINER_SIZE      uint = 512
 
 	PRG_ROM_START uint16 = 0x8000
 	PRG_ROM_END   uint16 = 0xFFFF
 	PRG_RAM_START uint16 = 0x6000
+	TRAINER_START uint16 = 0x7000
+	TRAINER_END   uint16 = 0x71FF
 )
 
 type Mirroring uint8
@@ -17,8 +22,8 @@ const (
 	MIRRORING_VERTICAL Mirroring = iota
 	MIRRORING_HORIZONTAL
 	MIRRORING_FOUR_SCREEN
-
-	SAVE_DATA_DIR = "../rom/saves/"
+	MIRRORING_SINGLE_SCREEN_LOWER
+	MIRRORING_SINGLE_SCREEN_UPPER
 )
 
 // MARK: マッパーのインターフェース
@@ -26,24 +31,43 @@ type Mapper interface {
 	Init(string, []uint8, []uint8)
 	ReadProgramRom(uint16) uint8
 	ReadCharacterRom(uint16) uint8
-	ReadProgramRam(uint16) uint8
+	ProgramRomOffset(uint16) uint        // CPUのアドレスからプログラムROM内のオフセットへの変換
+	CharacterRomOffset(uint16) uint      // PPUのアドレスからキャラクタROM内のオフセットへの変換
+	ReadProgramRam(uint16) (uint8, bool) // RAMがバスを駆動しなければ false (オープンバス)
 	WriteToCharacterRom(uint16, uint8)
 	WriteToProgramRam(uint16, uint8)
 	Write(uint16, uint8)
-	Save()
+	ProgramRam() []uint8
 
-	GenerateScanlineIRQ(uint16, bool)
 	IRQ() bool
 
 	MapperInfo() string
 	IsCharacterRam() bool
 	Mirroring() Mirroring
+	NameTable(uint8) NameTableBank
+	ReadNameTable(NameTableBank, uint16) uint8
+	WriteToNameTable(NameTableBank, uint16, uint8)
 	ProgramRom() []uint8
 	CharacterRom() []uint8
 
 	Clone() Mapper
 }
 
+// MARK: 書き込んだCPUサイクルを受け取るマッパーのインターフェース
+type CycleWriter interface {
+	WriteAtCycle(uint16, uint8, uint)
+}
+
+// MARK: PPUアドレスバスを監視するマッパーのインターフェース
+type PPUBusObserver interface {
+	ObservePPUAddress(uint16, uint)
+}
+
+// MARK: $4016への書き込みを受け取るマッパーのインターフェース (Vs. システム)
+type VsControlWriter interface {
+	WriteVsControl(uint8)
+}
+
 // MARK: カートリッジのバイナリからプログラムROMとキャラクタROMを取得
 func roms(rom []uint8) ([]uint8, []uint8) {
 	// それぞれのROMのアドレスとサイズを計算
@@ -65,6 +89,36 @@ func roms(rom []uint8) ([]uint8, []uint8) {
 	return programROM, characterROM
 }
 
+// MARK: カートリッジのバイナリからトレーナーを取得 (無ければnil)
+func Trainer(rom []uint8) ([]uint8, error) {
+	if (rom[6] & 0b100) == 0 {
+		return nil, nil
+	}
+	if uint(len(rom)) < 16+TRAINER_SIZE {
+		return nil, fmt.Errorf("trainer is truncated (%d of %d bytes)", max(len(rom)-16, 0), TRAINER_SIZE)
+	}
+	return rom[16 : 16+TRAINER_SIZE], nil
+}
+
+// MARK: プログラムRAM内のトレーナーの位置を取得 ($7000, 2kBのRAMのようにミラーされるときは折り返す)
+func TrainerOffset(ramSize int) int {
+	return int(TRAINER_START-PRG_RAM_START) % ramSize
+}
+
+/*
+	トレーナーはセーブデータを読み込んだ後に配置する
+
+	コピー機は電源を入れるたびに$7000へトレーナーを転送するので，
+	$7000~$71FF にセーブされていた内容は起動時に必ずトレーナーで上書きされる
+	(カートリッジの側ではこの範囲をセーブデータに書き出さない)
+*/
+// MARK: プログラムRAMへトレーナーを配置 ($7000~$71FF)
+func loadTrainer(programRam []uint8, rom []uint8) {
+	if data, err := Trainer(rom); err == nil && data != nil {
+		copy(programRam[TrainerOffset(len(programRam)):], data)
+	}
+}
+
 // MARK: シンプルなミラーリングの取得
 func simpleMirroring(rom []uint8) Mirroring {
 	isFourScreen := (rom[6] & 0b1000) != 0
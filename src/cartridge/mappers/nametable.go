@@ -0,0 +1,98 @@
+package mappers
+
+const (
+	NAMETABLE_SIZE        uint16 = 1024     // 1kB
+	FOUR_SCREEN_VRAM_SIZE uint   = 2 * 1024 // 2kB
+)
+
+// MARK: ネームテーブルの実体の種類
+type NameTableSource uint8
+
+const (
+	NAMETABLE_CIRAM_A        NameTableSource = iota // PPU内部VRAMの前半1kB
+	NAMETABLE_CIRAM_B                               // PPU内部VRAMの後半1kB
+	NAMETABLE_CARTRIDGE_VRAM                        // カートリッジ上の追加VRAM
+	NAMETABLE_CHARACTER_ROM                         // キャラクタROM
+)
+
+// MARK: ネームテーブル1枠分 (1kB) の割り当ての定義
+type NameTableBank struct {
+	Source NameTableSource
+	Page   uint // カートリッジVRAM / キャラクタROM 上の1kB単位のページ番号
+}
+
+// MARK: ミラーリングの種類からネームテーブルの割り当てを取得
+func mirroringNameTable(mirroring Mirroring, slot uint8) NameTableBank {
+	/*
+		slot: $2000 / $2400 / $2800 / $2C00 のどの枠か (0 ~ 3)
+
+		Vertical     Horizontal   Four Screen
+		[ A ][ B ]   [ A ][ A ]   [ A ][ B ]
+		[ A ][ B ]   [ B ][ B ]   [ 0 ][ 1 ] (0, 1: カートリッジVRAMのページ)
+	*/
+	switch mirroring {
+	case MIRRORING_VERTICAL:
+		if slot&0x01 == 0 {
+			return NameTableBank{Source: NAMETABLE_CIRAM_A}
+		}
+		return NameTableBank{Source: NAMETABLE_CIRAM_B}
+	case MIRRORING_HORIZONTAL:
+		if slot&0x02 == 0 {
+			return NameTableBank{Source: NAMETABLE_CIRAM_A}
+		}
+		return NameTableBank{Source: NAMETABLE_CIRAM_B}
+	case MIRRORING_SINGLE_SCREEN_LOWER:
+		return NameTableBank{Source: NAMETABLE_CIRAM_A}
+	case MIRRORING_SINGLE_SCREEN_UPPER:
+		return NameTableBank{Source: NAMETABLE_CIRAM_B}
+	case MIRRORING_FOUR_SCREEN:
+		switch slot & 0x03 {
+		case 0:
+			return NameTableBank{Source: NAMETABLE_CIRAM_A}
+		case 1:
+			return NameTableBank{Source: NAMETABLE_CIRAM_B}
+		default:
+			return NameTableBank{Source: NAMETABLE_CARTRIDGE_VRAM, Page: uint(slot&0x03) - 2}
+		}
+	default:
+		return NameTableBank{Source: NAMETABLE_CIRAM_A}
+	}
+}
+
+// MARK: 4画面ミラーリング用のカートリッジVRAMを確保 (不要ならnil)
+func fourScreenVram(mirroring Mirroring) []uint8 {
+	if mirroring != MIRRORING_FOUR_SCREEN {
+		return nil
+	}
+	return make([]uint8, FOUR_SCREEN_VRAM_SIZE)
+}
+
+// MARK: カートリッジ側のネームテーブルの読み取り
+func readCartridgeNameTable(vram []uint8, characterRom []uint8, bank NameTableBank, offset uint16) uint8 {
+	address := bank.Page*uint(NAMETABLE_SIZE) + uint(offset&(NAMETABLE_SIZE-1))
+
+	switch bank.Source {
+	case NAMETABLE_CARTRIDGE_VRAM:
+		if address < uint(len(vram)) {
+			return vram[address]
+		}
+	case NAMETABLE_CHARACTER_ROM:
+		if len(characterRom) != 0 {
+			return characterRom[address%uint(len(characterRom))]
+		}
+	}
+	return 0x00
+}
+
+// MARK: カートリッジ側のネームテーブルへの書き込み
+func writeCartridgeNameTable(vram []uint8, bank NameTableBank, offset uint16, data uint8) {
+	// キャラクタROMのネームテーブルには書き込めない
+	if bank.Source != NAMETABLE_CARTRIDGE_VRAM {
+		return
+	}
+
+	address := bank.Page*uint(NAMETABLE_SIZE) + uint(offset&(NAMETABLE_SIZE-1))
+	if address < uint(len(vram)) {
+		vram[address] = data
+	}
+}
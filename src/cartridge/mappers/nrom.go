@@ -7,6 +7,7 @@ type NROM struct {
 	mirroring      Mirroring
 	programRom     []uint8
 	characterRom   []uint8
+	vram           []uint8 // 4画面ミラーリング用のカートリッジVRAM
 }
 
 // MARK: マッパーの初期化
@@ -16,6 +17,7 @@ func (n *NROM) Init(name string, rom []uint8, save []uint8) {
 	n.name = name
 	n.isCharacterRam = characterRomSize(rom) == 0
 	n.mirroring = simpleMirroring(rom)
+	n.vram = fourScreenVram(n.mirroring)
 	n.programRom = programRom
 	n.characterRom = characterRom
 }
@@ -25,6 +27,11 @@ func (n *NROM) Write(address uint16, data uint8) {}
 
 // MARK: プログラムROMの読み取り
 func (n *NROM) ReadProgramRom(address uint16) uint8 {
+	return n.programRom[n.ProgramRomOffset(address)]
+}
+
+// MARK: CPUのアドレスに対応するプログラムROMのオフセットの取得
+func (n *NROM) ProgramRomOffset(address uint16) uint {
 	// カートリッジは$8000-$FFFFにマッピングされるためオフセット分引く
 	romAddress := address - 0x8000
 
@@ -32,12 +39,17 @@ func (n *NROM) ReadProgramRom(address uint16) uint8 {
 	if len(n.programRom) == 0x4000 && romAddress >= 0x4000 {
 		romAddress %= 0x4000
 	}
-	return n.programRom[romAddress]
+	return uint(romAddress)
 }
 
 // MARK: キャラクタROMの読み取り
 func (n *NROM) ReadCharacterRom(address uint16) uint8 {
-	return n.characterRom[address]
+	return n.characterRom[n.CharacterRomOffset(address)]
+}
+
+// MARK: PPUのアドレスに対応するキャラクタROMのオフセットの取得
+func (n *NROM) CharacterRomOffset(address uint16) uint {
+	return uint(address)
 }
 
 // MARK: キャラクタROMへの書き込み
@@ -53,19 +65,18 @@ func (n *NROM) WriteToCharacterRom(address uint16, data uint8) {
 }
 
 // MARK: プログラムRAMの読み取り
-func (n *NROM) ReadProgramRam(address uint16) uint8 {
-	// NROM にはプログラムRAMがないため，Open Busの挙動としてアドレス上位のバイトを返す
-	return uint8(address >> 8)
+func (n *NROM) ReadProgramRam(address uint16) (uint8, bool) {
+	// NROM にはプログラムRAMがないためオープンバス
+	return 0x00, false
 }
 
 // MARK: プログラムRAMへの書き込み
 func (n *NROM) WriteToProgramRam(address uint16, data uint8) {}
 
-// MARK: セーブデータの書き出し
-func (n *NROM) Save() {}
-
-// MARK: スキャンラインによってIRQを発生させる
-func (n *NROM) GenerateScanlineIRQ(scanline uint16, backgroundEnable bool) {}
+// MARK: プログラムRAMの取得 (バッテリーバックアップの対象)
+func (n *NROM) ProgramRam() []uint8 {
+	return nil
+}
 
 // MARK: IRQ状態の取得
 func (n *NROM) IRQ() bool { return false }
@@ -75,6 +86,21 @@ func (n *NROM) Mirroring() Mirroring {
 	return n.mirroring
 }
 
+// MARK: ネームテーブルの割り当ての取得
+func (n *NROM) NameTable(slot uint8) NameTableBank {
+	return mirroringNameTable(n.mirroring, slot)
+}
+
+// MARK: カートリッジ側のネームテーブルの読み取り
+func (n *NROM) ReadNameTable(bank NameTableBank, offset uint16) uint8 {
+	return readCartridgeNameTable(n.vram, n.characterRom, bank, offset)
+}
+
+// MARK: カートリッジ側のネームテーブルへの書き込み
+func (n *NROM) WriteToNameTable(bank NameTableBank, offset uint16, data uint8) {
+	writeCartridgeNameTable(n.vram, bank, offset, data)
+}
+
 // MARK: キャラクタRAMを使用するかどうかを取得
 func (n *NROM) IsCharacterRam() bool {
 	return n.isCharacterRam
@@ -1,16 +1,12 @@
 package mappers
 
-import (
-	"fmt"
-	"os"
-)
-
 // MARK: MMC1 SxROM (マッパー1) の定義
 type SxROM struct {
 	name string
 
-	shiftRegister uint8
-	shiftCount    uint8
+	shiftRegister  uint8
+	shiftCount     uint8
+	lastWriteCycle uint // 直前に書き込まれたCPUサイクル
 
 	control  uint8
 	chrBank0 uint8
@@ -20,6 +16,7 @@ type SxROM struct {
 	isCharacterRam bool
 	programRom     []uint8
 	characterRom   []uint8
+	vram           []uint8 // 4画面ミラーリング用のカートリッジVRAM
 	programRam     [PRG_RAM_SIZE]uint8
 }
 
@@ -29,6 +26,7 @@ func (s *SxROM) Init(name string, rom []uint8, save []uint8) {
 
 	s.shiftRegister = 0x10
 	s.shiftCount = 0
+	s.lastWriteCycle = 0
 
 	s.control = 0x0C
 	s.chrBank0 = 0
@@ -39,6 +37,7 @@ func (s *SxROM) Init(name string, rom []uint8, save []uint8) {
 	s.isCharacterRam = characterRomSize(rom) == 0
 	s.programRom = programRom
 	s.characterRom = characterRom
+	s.vram = fourScreenVram(simpleMirroring(rom))
 
 	// プログラムRAMの初期化
 	for i := range s.programRam {
@@ -49,6 +48,9 @@ func (s *SxROM) Init(name string, rom []uint8, save []uint8) {
 	if len(save) != 0 {
 		copy(s.programRam[:], save)
 	}
+
+	// トレーナーの配置
+	loadTrainer(s.programRam[:], rom)
 }
 
 // MARK: ROMスペースへの書き込み
@@ -79,8 +81,24 @@ func (s *SxROM) Write(address uint16, data uint8) {
 	}
 }
 
+// MARK: サイクル付きのROMスペースへの書き込み
+func (s *SxROM) WriteAtCycle(address uint16, data uint8, cycle uint) {
+	// MMC1は連続したサイクルの書き込みを無視する (INCなどのRMW命令の2回目の書き込み)
+	consecutive := cycle == s.lastWriteCycle+1
+	s.lastWriteCycle = cycle
+	if consecutive {
+		return
+	}
+	s.Write(address, data)
+}
+
 // MARK: プログラムROMの読み取り
 func (s *SxROM) ReadProgramRom(address uint16) uint8 {
+	return s.programRom[s.ProgramRomOffset(address)]
+}
+
+// MARK: CPUのアドレスに対応するプログラムROMのオフセットの取得
+func (s *SxROM) ProgramRomOffset(address uint16) uint {
 	/*
 		4bit0
 		-----
@@ -104,16 +122,16 @@ func (s *SxROM) ReadProgramRom(address uint16) uint8 {
 	case 0, 1:
 		// バンク番号の下位ビットを無視，32KBを$8000~に割り当て
 		bank := s.prgBank & 0x1E
-		return s.programRom[romBaseAddress+(BANK_SIZE*uint(bank))]
+		return romBaseAddress + (BANK_SIZE * uint(bank))
 	case 2:
 		// 最初のバンクを$8000~に固定，16KBバンクを$C000~に割り当て
 		bank := s.prgBank & 0x1F
 
 		switch {
 		case PRG_ROM_START <= address && address <= 0xBFFF:
-			return s.programRom[romBaseAddress]
+			return romBaseAddress
 		case 0xC000 <= address && address <= PRG_ROM_END:
-			return s.programRom[uint(address-0xC000)+(BANK_SIZE*uint(bank))]
+			return uint(address-0xC000) + (BANK_SIZE * uint(bank))
 		default:
 			panic("Error: unexpected program rom bank mode")
 		}
@@ -123,9 +141,9 @@ func (s *SxROM) ReadProgramRom(address uint16) uint8 {
 
 		switch {
 		case PRG_ROM_START <= address && address <= 0xBFFF:
-			return s.programRom[romBaseAddress+(BANK_SIZE*uint(bank))]
+			return romBaseAddress + (BANK_SIZE * uint(bank))
 		case 0xC000 <= address && address <= PRG_ROM_END:
-			return s.programRom[uint(address-0xC000)+(BANK_SIZE*(bankMax-1))]
+			return uint(address-0xC000) + (BANK_SIZE * (bankMax - 1))
 		default:
 			panic("Error: unexpected program rom bank mode")
 		}
@@ -174,7 +192,12 @@ func (s *SxROM) calcCharacterRomAddress(address uint16) uint16 {
 
 // MARK: キャラクタROMの読み取り
 func (s *SxROM) ReadCharacterRom(address uint16) uint8 {
-	return s.characterRom[s.calcCharacterRomAddress(address)]
+	return s.characterRom[s.CharacterRomOffset(address)]
+}
+
+// MARK: PPUのアドレスに対応するキャラクタROMのオフセットの取得
+func (s *SxROM) CharacterRomOffset(address uint16) uint {
+	return uint(s.calcCharacterRomAddress(address))
 }
 
 // MARK: キャラクタROMへの書き込み
@@ -183,26 +206,18 @@ func (s *SxROM) WriteToCharacterRom(address uint16, data uint8) {
 }
 
 // MARK: プログラムRAMの読み取り
-func (s *SxROM) ReadProgramRam(address uint16) uint8 {
-	return s.programRam[address-PRG_RAM_START]
+func (s *SxROM) ReadProgramRam(address uint16) (uint8, bool) {
+	return s.programRam[address-PRG_RAM_START], true
 }
 
 // MARK: プログラムRAMへの書き込み
 func (s *SxROM) WriteToProgramRam(address uint16, data uint8) {
 	s.programRam[address-PRG_RAM_START] = data
-
-	// セーブデータの書き出し
-	os.WriteFile(SAVE_DATA_DIR+s.name+".save", s.programRam[:], 0644)
 }
 
-// MARK: セーブデータの書き出し
-func (s *SxROM) Save() {
-	err := os.WriteFile(SAVE_DATA_DIR+s.name+".save", s.programRam[:], 0644)
-	if err != nil {
-		fmt.Printf("Error saving game data: %v\n", err)
-	} else {
-		fmt.Printf("Game saved to: %s\n", SAVE_DATA_DIR+s.name+".save")
-	}
+// MARK: プログラムRAMの取得 (バッテリーバックアップの対象)
+func (s *SxROM) ProgramRam() []uint8 {
+	return s.programRam[:]
 }
 
 // MARK: シフトレジスタのリセット
@@ -211,24 +226,42 @@ func (s *SxROM) resetShiftRegister() {
 	s.shiftCount = 0
 }
 
-// MARK: スキャンラインによってIRQを発生させる
-func (s *SxROM) GenerateScanlineIRQ(scanline uint16, backgroundEnable bool) {}
-
 // MARK: IRQ状態の取得
 func (s *SxROM) IRQ() bool { return false }
 
 // MARK: ミラーリングの取得
 func (s *SxROM) Mirroring() Mirroring {
+	if s.vram != nil {
+		return MIRRORING_FOUR_SCREEN
+	}
+
 	switch s.control & 0x03 {
+	case 0:
+		return MIRRORING_SINGLE_SCREEN_LOWER
+	case 1:
+		return MIRRORING_SINGLE_SCREEN_UPPER
 	case 2:
 		return MIRRORING_VERTICAL
-	case 3:
-		return MIRRORING_HORIZONTAL
 	default:
-		panic("Error: unsupported mirroring mode")
+		return MIRRORING_HORIZONTAL
 	}
 }
 
+// MARK: ネームテーブルの割り当ての取得
+func (s *SxROM) NameTable(slot uint8) NameTableBank {
+	return mirroringNameTable(s.Mirroring(), slot)
+}
+
+// MARK: カートリッジ側のネームテーブルの読み取り
+func (s *SxROM) ReadNameTable(bank NameTableBank, offset uint16) uint8 {
+	return readCartridgeNameTable(s.vram, s.characterRom, bank, offset)
+}
+
+// MARK: カートリッジ側のネームテーブルへの書き込み
+func (s *SxROM) WriteToNameTable(bank NameTableBank, offset uint16, data uint8) {
+	writeCartridgeNameTable(s.vram, bank, offset, data)
+}
+
 // MARK: キャラクタRAMを使用するかどうかを取得
 func (s *SxROM) IsCharacterRam() bool {
 	return s.isCharacterRam
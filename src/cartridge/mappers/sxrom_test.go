@@ -0,0 +1,64 @@
+package mappers
+
+import "testing"
+
+// テストヘルパー関数：32KBのプログラムROMと8KBのキャラクタROMを持つSxROMを初期化する
+func setupSxROM() *SxROM {
+	rom := make([]uint8, 16+2*BANK_SIZE+0x2000)
+	copy(rom, []uint8{'N', 'E', 'S', 0x1A, 2, 1})
+	s := &SxROM{}
+	s.Init("test", rom, nil)
+	return s
+}
+
+// TestSxROMWriteAtCycle は連続したサイクルの書き込みが無視されることをテストします
+func TestSxROMWriteAtCycle(t *testing.T) {
+	type write struct {
+		data  uint8
+		cycle uint
+	}
+	tests := []struct {
+		name      string
+		writes    []write
+		wantCount uint8
+		wantBank  uint8
+	}{
+		{
+			name:      "連続したサイクルの2回目の書き込みは無視する",
+			writes:    []write{{0x01, 10}, {0x01, 11}},
+			wantCount: 1,
+		},
+		{
+			name:      "1サイクル空いた書き込みは受け付ける",
+			writes:    []write{{0x01, 10}, {0x01, 12}},
+			wantCount: 2,
+		},
+		{
+			name: "RMW命令の2回目の書き込みを除いた5回でレジスタに入る",
+			writes: []write{
+				{0x01, 10}, {0x00, 11},
+				{0x00, 20}, {0x01, 21},
+				{0x01, 30}, {0x00, 31},
+				{0x00, 40}, {0x01, 41},
+				{0x00, 50}, {0x01, 51},
+			},
+			wantCount: 0,
+			wantBank:  0x05,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := setupSxROM()
+			for _, w := range tt.writes {
+				s.WriteAtCycle(0xE000, w.data, w.cycle)
+			}
+			if s.shiftCount != tt.wantCount {
+				t.Errorf("shiftCount = %d, want %d", s.shiftCount, tt.wantCount)
+			}
+			if s.prgBank != tt.wantBank {
+				t.Errorf("prgBank = %#02x, want %#02x", s.prgBank, tt.wantBank)
+			}
+		})
+	}
+}
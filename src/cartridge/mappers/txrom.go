@@ -1,13 +1,11 @@
 package mappers
 
-import (
-	"fmt"
-	"os"
-)
+import "fmt"
 
 const (
-	TXROM_PRG_BANK_SIZE = 8 * 1024 // 8kB
-	SCANLINE_POSTRENDER = 240
+	TXROM_PRG_BANK_SIZE    = 8 * 1024 // 8kB
+	TXROM_A12_FILTER_DOTS  = 9        // A12 の立ち上がりを無視する Low の期間 (M2 3サイクル分)
+	TXROM_A12_ADDRESS_MASK = 0x1000
 )
 
 // MARK: MMC3 TxROM (マッパー4) の定義
@@ -23,10 +21,14 @@ type TxROM struct {
 	irqCounter uint8
 	irq        bool
 
+	a12         bool // 直前に観測したPPUアドレスの A12
+	a12LowSince uint // A12 が Low になったPPUサイクル
+
 	isCharacterRam bool
 	mirroring      Mirroring
 	programRom     []uint8
 	characterRom   []uint8
+	vram           []uint8 // 4画面ミラーリング用のカートリッジVRAM
 	programRam     [PRG_RAM_SIZE]uint8
 }
 
@@ -39,6 +41,10 @@ func (t *TxROM) Init(name string, rom []uint8, save []uint8) {
 	t.irqLatch = 0x00
 	t.irqReload = false
 	t.irqEnable = false
+	t.irqCounter = 0
+	t.irq = false
+	t.a12 = false
+	t.a12LowSince = 0
 
 	for i := range t.bankData {
 		t.bankData[i] = 0x00
@@ -46,6 +52,7 @@ func (t *TxROM) Init(name string, rom []uint8, save []uint8) {
 
 	t.isCharacterRam = characterRomSize(rom) == 0
 	t.mirroring = simpleMirroring(rom)
+	t.vram = fourScreenVram(t.mirroring)
 	t.programRom = programRom
 	t.characterRom = characterROM
 
@@ -59,6 +66,9 @@ func (t *TxROM) Init(name string, rom []uint8, save []uint8) {
 		copy(t.programRam[:], save)
 		t.ramProtect = 0x80
 	}
+
+	// トレーナーの配置
+	loadTrainer(t.programRam[:], rom)
 }
 
 // MARK: ROMスペースへの書き込み
@@ -75,6 +85,10 @@ func (t *TxROM) Write(address uint16, data uint8) {
 	case 0xA000 <= address && address <= 0xBFFF:
 		if address&0x01 == 0 {
 			// ミラーリング ($A000~$BFFE, 偶数)
+			// 4画面ミラーリングのカートリッジではミラーリングは固定
+			if t.vram != nil {
+				break
+			}
 			if data&0x01 == 0 {
 				t.mirroring = MIRRORING_VERTICAL
 			} else {
@@ -113,6 +127,11 @@ func (t *TxROM) Write(address uint16, data uint8) {
 
 // MARK: プログラムROMの読み取り
 func (t *TxROM) ReadProgramRom(address uint16) uint8 {
+	return t.programRom[t.ProgramRomOffset(address)]
+}
+
+// MARK: CPUのアドレスに対応するプログラムROMのオフセットの取得
+func (t *TxROM) ProgramRomOffset(address uint16) uint {
 	/*
 		mode         0      1
 		$8000~$9FFF: R6    (-2)
@@ -141,26 +160,26 @@ func (t *TxROM) ReadProgramRom(address uint16) uint8 {
 	case 0:
 		switch {
 		case PRG_ROM_START <= address && address <= 0x9FFF:
-			return t.programRom[uint(address-PRG_ROM_START)+r6Bank*TXROM_PRG_BANK_SIZE]
+			return uint(address-PRG_ROM_START) + r6Bank*TXROM_PRG_BANK_SIZE
 		case 0xA000 <= address && address <= 0xBFFF:
-			return t.programRom[uint(address-0xA000)+r7Bank*TXROM_PRG_BANK_SIZE]
+			return uint(address-0xA000) + r7Bank*TXROM_PRG_BANK_SIZE
 		case 0xC000 <= address && address <= 0xDFFF:
-			return t.programRom[uint(address-0xC000)+lastBank2*TXROM_PRG_BANK_SIZE]
+			return uint(address-0xC000) + lastBank2*TXROM_PRG_BANK_SIZE
 		case 0xE000 <= address && address <= PRG_ROM_END:
-			return t.programRom[uint(address-0xE000)+lastBank1*TXROM_PRG_BANK_SIZE]
+			return uint(address-0xE000) + lastBank1*TXROM_PRG_BANK_SIZE
 		default:
 			panic(fmt.Sprintf("Error: unexpected program rom read: $%04X", address))
 		}
 	default:
 		switch {
 		case PRG_ROM_START <= address && address <= 0x9FFF:
-			return t.programRom[uint(address-PRG_ROM_START)+lastBank2*TXROM_PRG_BANK_SIZE]
+			return uint(address-PRG_ROM_START) + lastBank2*TXROM_PRG_BANK_SIZE
 		case 0xA000 <= address && address <= 0xBFFF:
-			return t.programRom[uint(address-0xA000)+r7Bank*TXROM_PRG_BANK_SIZE]
+			return uint(address-0xA000) + r7Bank*TXROM_PRG_BANK_SIZE
 		case 0xC000 <= address && address <= 0xDFFF:
-			return t.programRom[uint(address-0xC000)+r6Bank*TXROM_PRG_BANK_SIZE]
+			return uint(address-0xC000) + r6Bank*TXROM_PRG_BANK_SIZE
 		case 0xE000 <= address && address <= PRG_ROM_END:
-			return t.programRom[uint(address-0xE000)+lastBank1*TXROM_PRG_BANK_SIZE]
+			return uint(address-0xE000) + lastBank1*TXROM_PRG_BANK_SIZE
 		default:
 			panic(fmt.Sprintf("Error: unexpected program rom read: $%04X", address))
 		}
@@ -233,7 +252,12 @@ func (t *TxROM) calcCharacterRomAddress(address uint16) uint {
 
 // MARK: キャラクタROMの読み取り
 func (t *TxROM) ReadCharacterRom(address uint16) uint8 {
-	return t.characterRom[t.calcCharacterRomAddress(address)]
+	return t.characterRom[t.CharacterRomOffset(address)]
+}
+
+// MARK: PPUのアドレスに対応するキャラクタROMのオフセットの取得
+func (t *TxROM) CharacterRomOffset(address uint16) uint {
+	return t.calcCharacterRomAddress(address)
 }
 
 // MARK: キャラクタROMへの書き込み
@@ -242,12 +266,12 @@ func (t *TxROM) WriteToCharacterRom(address uint16, data uint8) {
 }
 
 // MARK: プログラムRAMの読み取り
-func (t *TxROM) ReadProgramRam(address uint16) uint8 {
-	// RAM有効ビットが立っている場合のみRAMから読み取り、それ以外は0xFF
+func (t *TxROM) ReadProgramRam(address uint16) (uint8, bool) {
+	// RAM有効ビットが立っている場合のみRAMから読み取り、それ以外はオープンバス
 	if t.ramProtect&0x80 != 0 {
-		return t.programRam[address-PRG_RAM_START]
+		return t.programRam[address-PRG_RAM_START], true
 	}
-	return 0xFF // 無効なRAMアクセスの場合は0xFFを返す
+	return 0x00, false
 }
 
 // MARK: プログラムRAMへの書き込み
@@ -258,43 +282,53 @@ func (t *TxROM) WriteToProgramRam(address uint16, data uint8) {
 	}
 }
 
-// MARK: セーブデータの書き出し
-func (t *TxROM) Save() {
-	// RAM書き込みが有効な場合のみセーブ
-	if t.ramProtect&0x80 != 0 {
-		err := os.WriteFile(SAVE_DATA_DIR+t.name+".save", t.programRam[:], 0644)
-		if err != nil {
-			fmt.Printf("Error saving game data: %v\n", err)
-		} else {
-			fmt.Printf("Game saved to: %s\n", SAVE_DATA_DIR+t.name+".save")
+// MARK: プログラムRAMの取得 (バッテリーバックアップの対象)
+func (t *TxROM) ProgramRam() []uint8 {
+	return t.programRam[:]
+}
+
+// MARK: PPUアドレスバスの観測 (A12 の立ち上がりでIRQカウンタを進める)
+func (t *TxROM) ObservePPUAddress(address uint16, cycle uint) {
+	/*
+		@NOTE
+		> The MMC3 scanline counter is based entirely on PPU A12, triggered on a rising edge after the line has remained low for three falling edges of M2.
+		https://www.nesdev.org/wiki/MMC3
+
+		スプライトのフェッチ中の短い Low (ネームテーブルのダミーフェッチ) では
+		カウンタが進まないように，Low の期間が短い立ち上がりは無視する
+	*/
+	a12 := address&TXROM_A12_ADDRESS_MASK != 0
+	switch {
+	case a12 && !t.a12:
+		if cycle-t.a12LowSince >= TXROM_A12_FILTER_DOTS {
+			t.clockIRQCounter()
 		}
+	case !a12 && t.a12:
+		t.a12LowSince = cycle
 	}
+	t.a12 = a12
 }
 
-// MARK: スキャンラインによってIRQを発生させる
-func (t *TxROM) GenerateScanlineIRQ(scanline uint16, renderEnable bool) {
-	if scanline <= SCANLINE_POSTRENDER && renderEnable {
-		// リロードフラグが立っているか、カウンタが0なら、カウンタをラッチ値でリロード
-		if t.irqReload || t.irqCounter == 0 {
-			t.irqCounter = t.irqLatch
-			t.irqReload = false
-		} else {
-			// そうでなければカウンタをデクリメント
-			t.irqCounter--
-		}
+// MARK: IRQカウンタを進める
+func (t *TxROM) clockIRQCounter() {
+	// リロードフラグが立っているか、カウンタが0なら、カウンタをラッチ値でリロード
+	if t.irqReload || t.irqCounter == 0 {
+		t.irqCounter = t.irqLatch
+		t.irqReload = false
+	} else {
+		// そうでなければカウンタをデクリメント
+		t.irqCounter--
+	}
 
-		// カウンタが0になり、かつIRQが有効ならIRQを発生
-		if t.irqCounter == 0 && t.irqEnable {
-			t.irq = true
-		}
+	// カウンタが0になり、かつIRQが有効ならIRQを発生
+	if t.irqCounter == 0 && t.irqEnable {
+		t.irq = true
 	}
 }
 
-// MARK: IRQ状態の取得
+// MARK: IRQ状態の取得 (レベルトリガ: $E000 に書き込んで確認応答するまで保持する)
 func (t *TxROM) IRQ() bool {
-	value := t.irq
-	t.irq = false
-	return value
+	return t.irq
 }
 
 // MARK: ミラーリングの取得
@@ -302,6 +336,21 @@ func (t *TxROM) Mirroring() Mirroring {
 	return t.mirroring
 }
 
+// MARK: ネームテーブルの割り当ての取得
+func (t *TxROM) NameTable(slot uint8) NameTableBank {
+	return mirroringNameTable(t.mirroring, slot)
+}
+
+// MARK: カートリッジ側のネームテーブルの読み取り
+func (t *TxROM) ReadNameTable(bank NameTableBank, offset uint16) uint8 {
+	return readCartridgeNameTable(t.vram, t.characterRom, bank, offset)
+}
+
+// MARK: カートリッジ側のネームテーブルへの書き込み
+func (t *TxROM) WriteToNameTable(bank NameTableBank, offset uint16, data uint8) {
+	writeCartridgeNameTable(t.vram, bank, offset, data)
+}
+
 // MARK: キャラクタRAMを使用するかどうかを取得
 func (t *TxROM) IsCharacterRam() bool {
 	return t.isCharacterRam
@@ -11,6 +11,7 @@ type UxROM struct {
 	mirroring      Mirroring
 	programRom     []uint8
 	characterRom   []uint8
+	vram           []uint8 // 4画面ミラーリング用のカートリッジVRAM
 }
 
 // MARK: マッパーの初期化
@@ -21,6 +22,7 @@ func (u *UxROM) Init(name string, rom []uint8, save []uint8) {
 	programRom, characterRom := roms(rom)
 	u.isCharacterRam = characterRomSize(rom) == 0
 	u.mirroring = simpleMirroring(rom)
+	u.vram = fourScreenVram(u.mirroring)
 	u.programRom = programRom
 	u.characterRom = characterRom
 }
@@ -32,6 +34,11 @@ func (u *UxROM) Write(address uint16, data uint8) {
 
 // MARK: プログラムROMの読み取り
 func (u *UxROM) ReadProgramRom(address uint16) uint8 {
+	return u.programRom[u.ProgramRomOffset(address)]
+}
+
+// MARK: CPUのアドレスに対応するプログラムROMのオフセットの取得
+func (u *UxROM) ProgramRomOffset(address uint16) uint {
 	// 最後のバンク番号
 	bankMax := uint(len(u.programRom)) / BANK_SIZE
 
@@ -39,10 +46,10 @@ func (u *UxROM) ReadProgramRom(address uint16) uint8 {
 	case PRG_ROM_START <= address && address <= 0xBFFF:
 		// 前半部分はバンク選択
 		bank := uint(u.bank & 0x0F)
-		return u.programRom[uint(address)-0x8000+BANK_SIZE*bank]
+		return uint(address) - 0x8000 + BANK_SIZE*bank
 	case 0xC000 <= address && address <= PRG_ROM_END:
 		// 後半部分は固定
-		return u.programRom[uint(address)-0xC000+BANK_SIZE*(bankMax-1)]
+		return uint(address) - 0xC000 + BANK_SIZE*(bankMax-1)
 	default:
 		panic(fmt.Sprintf("Erorr: unexpected PRG ROM space: %04X", address))
 	}
@@ -50,7 +57,12 @@ func (u *UxROM) ReadProgramRom(address uint16) uint8 {
 
 // MARK: キャラクタROMの読み取り
 func (u *UxROM) ReadCharacterRom(address uint16) uint8 {
-	return u.characterRom[address]
+	return u.characterRom[u.CharacterRomOffset(address)]
+}
+
+// MARK: PPUのアドレスに対応するキャラクタROMのオフセットの取得
+func (u *UxROM) CharacterRomOffset(address uint16) uint {
+	return uint(address)
 }
 
 // MARK: キャラクタROMへの書き込み
@@ -59,18 +71,18 @@ func (u *UxROM) WriteToCharacterRom(address uint16, data uint8) {
 }
 
 // MARK: プログラムRAMの読み取り
-func (u *UxROM) ReadProgramRam(address uint16) uint8 {
-	panic("Error: unsupported read program RAM on UxROM")
+func (u *UxROM) ReadProgramRam(address uint16) (uint8, bool) {
+	// UxROM にはプログラムRAMがないためオープンバス
+	return 0x00, false
 }
 
 // MARK: プログラムRAMへの書き込み
 func (u *UxROM) WriteToProgramRam(address uint16, data uint8) {}
 
-// MARK: セーブデータの書き出し
-func (u *UxROM) Save() {}
-
-// MARK: スキャンラインによってIRQを発生させる
-func (u *UxROM) GenerateScanlineIRQ(scanline uint16, backgroundEnable bool) {}
+// MARK: プログラムRAMの取得 (バッテリーバックアップの対象)
+func (u *UxROM) ProgramRam() []uint8 {
+	return nil
+}
 
 // MARK: IRQ状態の取得
 func (u *UxROM) IRQ() bool { return false }
@@ -80,6 +92,21 @@ func (u *UxROM) Mirroring() Mirroring {
 	return u.mirroring
 }
 
+// MARK: ネームテーブルの割り当ての取得
+func (u *UxROM) NameTable(slot uint8) NameTableBank {
+	return mirroringNameTable(u.mirroring, slot)
+}
+
+// MARK: カートリッジ側のネームテーブルの読み取り
+func (u *UxROM) ReadNameTable(bank NameTableBank, offset uint16) uint8 {
+	return readCartridgeNameTable(u.vram, u.characterRom, bank, offset)
+}
+
+// MARK: カートリッジ側のネームテーブルへの書き込み
+func (u *UxROM) WriteToNameTable(bank NameTableBank, offset uint16, data uint8) {
+	writeCartridgeNameTable(u.vram, bank, offset, data)
+}
+
 // MARK: キャラクタRAMを使用するかどうかを取得
 func (u *UxROM) IsCharacterRam() bool {
 	return u.isCharacterRam
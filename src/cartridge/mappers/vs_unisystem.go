@@ -0,0 +1,144 @@
+package mappers
+
+const (
+	VS_PRG_RAM_SIZE    uint = 2 * 1024 // 2kB (メインとサブで共有)
+	VS_PRG_BANK_SIZE   uint = 8 * 1024 // 8kB
+	VS_BANK_SELECT_POS      = 2        // $4016のバンク選択ビット
+)
+
+// MARK: Vs. UniSystem (マッパー99) の定義
+type VsUniSystem struct {
+	name string
+	bank uint8 // $4016 bit2 で選択されるバンク
+
+	isCharacterRam bool
+	mirroring      Mirroring
+	programRom     []uint8
+	characterRom   []uint8
+	programRam     [VS_PRG_RAM_SIZE]uint8
+	vram           []uint8 // 4画面ミラーリング用のカートリッジVRAM
+}
+
+// MARK: マッパーの初期化
+func (v *VsUniSystem) Init(name string, rom []uint8, save []uint8) {
+	v.name = name
+	v.bank = 0
+
+	programRom, characterRom := roms(rom)
+	v.isCharacterRam = characterRomSize(rom) == 0
+	v.mirroring = simpleMirroring(rom)
+	v.vram = fourScreenVram(v.mirroring)
+	v.programRom = programRom
+	v.characterRom = characterRom
+	copy(v.programRam[:], save)
+
+	// トレーナーの配置 (2kBのRAMは$6000-$7FFFでミラーされるので，$7000は先頭に当たる)
+	loadTrainer(v.programRam[:], rom)
+}
+
+// MARK: $4016への書き込み (bit2でCHRバンクと40kBのPRGの先頭バンクを切り替え)
+func (v *VsUniSystem) WriteVsControl(data uint8) {
+	v.bank = (data >> VS_BANK_SELECT_POS) & 0b1
+}
+
+// MARK: ROMスペースへの書き込み
+func (v *VsUniSystem) Write(address uint16, data uint8) {}
+
+// MARK: プログラムROMの読み取り
+func (v *VsUniSystem) ReadProgramRom(address uint16) uint8 {
+	return v.programRom[v.ProgramRomOffset(address)]
+}
+
+// MARK: CPUのアドレスに対応するプログラムROMのオフセットの取得
+func (v *VsUniSystem) ProgramRomOffset(address uint16) uint {
+	romAddress := uint(address - PRG_ROM_START)
+
+	// 32kBを超えるROM (Vs. Gumshoe) は$8000-$9FFFだけがバンク0/4で切り替わる
+	if uint(len(v.programRom)) > 2*PRG_ROM_PAGE_SIZE && romAddress < VS_PRG_BANK_SIZE {
+		romAddress += uint(v.bank) * 4 * VS_PRG_BANK_SIZE
+	}
+	return romAddress % uint(len(v.programRom))
+}
+
+// MARK: キャラクタROMの読み取り
+func (v *VsUniSystem) ReadCharacterRom(address uint16) uint8 {
+	return v.characterRom[v.CharacterRomOffset(address)]
+}
+
+// MARK: PPUのアドレスに対応するキャラクタROMのオフセットの取得
+func (v *VsUniSystem) CharacterRomOffset(address uint16) uint {
+	offset := uint(address) + uint(v.bank)*CHR_ROM_PAGE_SIZE
+	return offset % uint(len(v.characterRom))
+}
+
+// MARK: キャラクタROMへの書き込み
+func (v *VsUniSystem) WriteToCharacterRom(address uint16, data uint8) {
+	if !v.isCharacterRam {
+		return
+	}
+	v.characterRom[uint(address)%uint(len(v.characterRom))] = data
+}
+
+// MARK: プログラムRAMの読み取り ($6000-$7FFFに2kBがミラーリングされる)
+func (v *VsUniSystem) ReadProgramRam(address uint16) (uint8, bool) {
+	return v.programRam[uint(address-PRG_RAM_START)%VS_PRG_RAM_SIZE], true
+}
+
+// MARK: プログラムRAMへの書き込み
+func (v *VsUniSystem) WriteToProgramRam(address uint16, data uint8) {
+	v.programRam[uint(address-PRG_RAM_START)%VS_PRG_RAM_SIZE] = data
+}
+
+// MARK: プログラムRAMの取得 (バッテリーバックアップの対象)
+func (v *VsUniSystem) ProgramRam() []uint8 {
+	return v.programRam[:]
+}
+
+// MARK: IRQ状態の取得
+func (v *VsUniSystem) IRQ() bool { return false }
+
+// MARK: ミラーリングの取得
+func (v *VsUniSystem) Mirroring() Mirroring {
+	return v.mirroring
+}
+
+// MARK: ネームテーブルの割り当ての取得
+func (v *VsUniSystem) NameTable(slot uint8) NameTableBank {
+	return mirroringNameTable(v.mirroring, slot)
+}
+
+// MARK: カートリッジ側のネームテーブルの読み取り
+func (v *VsUniSystem) ReadNameTable(bank NameTableBank, offset uint16) uint8 {
+	return readCartridgeNameTable(v.vram, v.characterRom, bank, offset)
+}
+
+// MARK: カートリッジ側のネームテーブルへの書き込み
+func (v *VsUniSystem) WriteToNameTable(bank NameTableBank, offset uint16, data uint8) {
+	writeCartridgeNameTable(v.vram, bank, offset, data)
+}
+
+// MARK: キャラクタRAMを使用するかどうかを取得
+func (v *VsUniSystem) IsCharacterRam() bool {
+	return v.isCharacterRam
+}
+
+// MARK: プログラムROMの取得
+func (v *VsUniSystem) ProgramRom() []uint8 {
+	return v.programRom
+}
+
+// MARK: キャラクタROMの取得
+func (v *VsUniSystem) CharacterRom() []uint8 {
+	return v.characterRom
+}
+
+// MARK: マッパー名の取得
+func (v *VsUniSystem) MapperInfo() string {
+	return "Vs. UniSystem (Mapper 99)"
+}
+
+// MARK: マッパーのシャローコピーの取得
+func (v *VsUniSystem) Clone() Mapper {
+	copy := *v
+	return &copy
+}
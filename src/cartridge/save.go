@@ -0,0 +1,144 @@
+package cartridge
+
+import (
+	"Famicom-emulator/cartridge/mappers"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MARK: バッテリーバックアップの有無を取得
+func (c *Cartridge) HasBattery() bool {
+	return c.battery
+}
+
+// MARK: セーブデータのパスを取得
+func (c *Cartridge) savePath() string {
+	return filepath.Join(c.SaveDir, c.name+SAVE_DATA_EXT)
+}
+
+// MARK: バックアップのパスを取得 (世代番号は1から)
+func (c *Cartridge) backupPath(generation int) string {
+	return fmt.Sprintf("%s.%d", c.savePath(), generation)
+}
+
+// MARK: セーブデータの書き出し
+func (c *Cartridge) Save() error {
+	if !c.battery || c.mapper == nil {
+		return nil
+	}
+
+	// 前回の書き出しから変化がなければ何もしない
+	ram := c.saveImage(c.mapper.ProgramRam())
+	if len(ram) == 0 || bytes.Equal(ram, c.lastSaved) {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.SaveDir, 0755); err != nil {
+		return err
+	}
+
+	// 起動後最初の書き出しの前に，前回のセッションのセーブデータをバックアップへ回す
+	if !c.backedUp {
+		if err := c.rotateBackups(); err != nil {
+			return err
+		}
+		c.backedUp = true
+	}
+
+	if err := WriteFileAtomic(c.savePath(), ram); err != nil {
+		return err
+	}
+	c.lastSaved = append(c.lastSaved[:0], ram...)
+	fmt.Printf("Game saved to: %s\n", c.savePath())
+
+	return nil
+}
+
+/*
+	トレーナーの範囲 ($7000~$71FF) はセーブデータに書き出さない
+
+	トレーナーは起動のたびにセーブデータの上へ配置されるので，この範囲には
+	セーブデータを読み込んだときの内容 (新しいセーブデータなら0) をそのまま書き戻す
+*/
+// MARK: セーブデータに書き出すプログラムRAMの内容の取得
+func (c *Cartridge) saveImage(ram []uint8) []uint8 {
+	if c.trainer == nil || len(ram) == 0 {
+		return ram
+	}
+	image := append([]uint8(nil), ram...)
+	copy(image[mappers.TrainerOffset(len(image)):], c.underlay)
+	return image
+}
+
+// MARK: セーブデータのトレーナーの範囲の内容の取得
+func (c *Cartridge) trainerUnderlay(savefile []uint8) []uint8 {
+	ram := c.mapper.ProgramRam()
+	if c.trainer == nil || len(ram) == 0 {
+		return nil
+	}
+	underlay := make([]uint8, mappers.TRAINER_SIZE)
+	if offset := mappers.TrainerOffset(len(ram)); offset < len(savefile) {
+		copy(underlay, savefile[offset:])
+	}
+	return underlay
+}
+
+// MARK: バックアップのローテーション
+func (c *Cartridge) rotateBackups() error {
+	if c.SaveBackups <= 0 {
+		return nil
+	}
+
+	current, err := os.ReadFile(c.savePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// 古い世代から順にひとつずつずらす (最も古い世代は上書きされる)
+	for generation := c.SaveBackups - 1; generation >= 1; generation-- {
+		from := c.backupPath(generation)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, c.backupPath(generation+1)); err != nil {
+			return err
+		}
+	}
+
+	return WriteFileAtomic(c.backupPath(1), current)
+}
+
+// MARK: ファイルのアトミックな書き出し
+func WriteFileAtomic(path string, data []uint8) error {
+	/*
+		一時ファイルに書き出してから rename で置き換えることで，
+		書き出し中にクラッシュしても既存のファイルが壊れないようにする
+	*/
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,115 @@
+package cartridge
+
+import "strings"
+
+// MARK: Vs. システムのPPUの定義 (NES 2.0 の Vs. PPU Type + 1 の並び)
+type VsPPUType uint8
+
+const (
+	VS_PPU_NONE VsPPUType = iota // Vs. システムではない (通常の2C02)
+	VS_PPU_RP2C03B
+	VS_PPU_RP2C03G
+	VS_PPU_RP2C04_0001
+	VS_PPU_RP2C04_0002
+	VS_PPU_RP2C04_0003
+	VS_PPU_RP2C04_0004
+	VS_PPU_RC2C03B
+	VS_PPU_RC2C03C
+	VS_PPU_RC2C05_01
+	VS_PPU_RC2C05_02
+	VS_PPU_RC2C05_03
+	VS_PPU_RC2C05_04
+	VS_PPU_RC2C05_05
+)
+
+// PPU名の一覧 (VsPPUTypeの並び順)
+var vsPPUNames = [...]string{
+	"2C02",
+	"RP2C03B", "RP2C03G",
+	"RP2C04-0001", "RP2C04-0002", "RP2C04-0003", "RP2C04-0004",
+	"RC2C03B", "RC2C03C",
+	"RC2C05-01", "RC2C05-02", "RC2C05-03", "RC2C05-04", "RC2C05-05",
+}
+
+// MARK: PPU名からVs. システムのPPUを取得 ("2C04-0001" のように接頭辞は省略可)
+func ParseVsPPUType(name string) (VsPPUType, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for i, n := range vsPPUNames[VS_PPU_RP2C03B:] {
+		if name == n || name == n[2:] {
+			return VS_PPU_RP2C03B + VsPPUType(i), true
+		}
+	}
+	return VS_PPU_NONE, false
+}
+
+// MARK: PPU名の取得
+func (t VsPPUType) String() string {
+	if int(t) < len(vsPPUNames) {
+		return vsPPUNames[t]
+	}
+	return "Unknown"
+}
+
+// MARK: 並び替えられたパレットを持つ2C04かどうか
+func (t VsPPUType) IsScrambledPalette() bool {
+	return VS_PPU_RP2C04_0001 <= t && t <= VS_PPU_RP2C04_0004
+}
+
+// MARK: RGBパレットを持つPPUかどうか
+func (t VsPPUType) IsRGB() bool {
+	return t != VS_PPU_NONE
+}
+
+// MARK: $2000と$2001が入れ替わっているかどうか (RC2C05)
+func (t VsPPUType) SwapsControlAndMask() bool {
+	return VS_PPU_RC2C05_01 <= t && t <= VS_PPU_RC2C05_05
+}
+
+// MARK: $2002の下位5bitに返すPPUのID (RC2C05, 無ければ0)
+func (t VsPPUType) StatusID() uint8 {
+	switch t {
+	case VS_PPU_RC2C05_01, VS_PPU_RC2C05_04:
+		return 0x1B
+	case VS_PPU_RC2C05_02:
+		return 0x3D
+	case VS_PPU_RC2C05_03:
+		return 0x1C
+	default:
+		return 0x00
+	}
+}
+
+// MARK: Vs. システムのカートリッジかどうか
+func (c *Cartridge) IsVsSystem() bool {
+	return c.vsSystem
+}
+
+// MARK: ヘッダに記録されたVs. システムのPPUの取得
+func (c *Cartridge) VsPPUType() VsPPUType {
+	return c.vsPPU
+}
+
+// MARK: Vs. システムのPPUの判定
+func (c *Cartridge) detectVsPPUType(gamefile []uint8) VsPPUType {
+	if !c.vsSystem {
+		return VS_PPU_NONE
+	}
+	if c.isNES2 {
+		/*
+			NES 2.0 ヘッダ Byte 13 (Vs. システム)
+			76543210
+			HHHHPPPP
+			||||++++- Vs. PPU Type
+			++++----- Vs. Hardware Type
+		*/
+		if t := VsPPUType(gamefile[13]&0x0F) + 1; t <= VS_PPU_RC2C05_05 {
+			return t
+		}
+	}
+	// iNES 1.0 ではゲームデータベースでPPUを調べる
+	if entry, ok := lookupDatabase(c.Database, c.crc); ok && entry.vsPPU != VS_PPU_NONE {
+		return entry.vsPPU
+	}
+	// 分からなければ素直なRGBパレットの2C03とみなす
+	return VS_PPU_RP2C03B
+}
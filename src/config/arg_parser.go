@@ -23,9 +23,22 @@ func ParseArguments() (cartridge.Cartridge, *Config) {
 		fmt.Println("Load ROM file:", rom)
 	}
 
+	return config.NewCartridge(filepath.Join("..", "rom", rom)), config
+}
+
+// MARK: 設定を反映したカートリッジを生成
+func (c *Config) NewCartridge(path string) cartridge.Cartridge {
+	saveDir := c.Rom.SAVE_DIR
+	if saveDir == "" {
+		saveDir = cartridge.DEFAULT_SAVE_DATA_DIR
+	}
+
 	return cartridge.Cartridge{
-		ROM: filepath.Join("..", "rom", rom),
-	}, config
+		ROM:         path,
+		SaveDir:     saveDir,
+		SaveBackups: c.Rom.SAVE_BACKUPS,
+		Database:    c.System.GAME_DATABASE,
+	}
 }
 
 // MARK: デフォルトのROMファイル名を取得
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"Famicom-emulator/cartridge"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -36,6 +37,11 @@ var DefaultControl = ControlConfig{
 		BUTTON_START:  sdl.K_KP_ENTER,
 		BUTTON_SELECT: sdl.K_BACKSPACE,
 	},
+	KEY_VS: SDLVsKeyConfig{
+		COIN_1:  sdl.K_c,
+		COIN_2:  sdl.K_x,
+		SERVICE: sdl.K_z,
+	},
 	GamepadAxisThreshold: 8000,
 }
 
@@ -46,6 +52,7 @@ type Config struct {
 	Apu     ApuConfig     `json:"apu"`
 	Render  RenderConfig  `json:"render"`
 	Rom     RomConfig     `json:"rom"`
+	System  SystemConfig  `json:"system"`
 	Control ControlConfig `json:"control"`
 }
 
@@ -62,33 +69,96 @@ type ApuConfig struct {
 
 // MARK: CpuConfigの定義
 type CpuConfig struct {
-	LOG_ENABLED bool `json:"log"`
+	LOG_ENABLED      bool        `json:"log"`
+	DEBUGGER_ENABLED bool        `json:"debugger"` // 起動時にデバッガを有効化する
+	CDL_ENABLED      bool        `json:"cdl"`      // ROMと同じ場所の .cdl にコードとデータのアクセスを記録する
+	TRACE            TraceConfig `json:"trace"`    // 実行ログの書き出し (logがtrueなら起動時から)
+}
+
+// MARK: TraceConfigの定義
+type TraceConfig struct {
+	FILE         string   `json:"file"`       // 書き出し先 (空ならROMと同じ場所の .trace.log)
+	START_PC     string   `json:"startPc"`    // このPCに到達したら書き出しを開始 ("$C000" や ラベル名)
+	STOP_PC      string   `json:"stopPc"`     // このPCに到達したら書き出しを停止
+	START_FRAME  uint     `json:"startFrame"` // このフレームから書き出しを開始 (0で無効)
+	STOP_FRAME   uint     `json:"stopFrame"`  // このフレームで書き出しを停止 (0で無効)
+	RANGES       []string `json:"ranges"`     // 書き出すPCの範囲 ("$C000-$C7FF", 空なら全て)
+	BANKS        []uint   `json:"banks"`      // 書き出す16KBのPRGバンク (空なら全て)
+	PPU_COLUMN   bool     `json:"ppu"`        // nestest.log と同じ PPU:scanline,dot の列を付ける
+	CYCLE_COLUMN bool     `json:"cycles"`     // nestest.log と同じ CYC:cycles の列を付ける
 }
 
 // MARK: PpuConfigの定義
 type PpuConfig struct {
-	BACKGROUND_ENABLED bool `json:"background"`
-	SPRITE_ENABLED     bool `json:"sprite"`
+	BACKGROUND_ENABLED bool          `json:"background"`
+	SPRITE_ENABLED     bool          `json:"sprite"`
+	UNLIMITED_SPRITES  bool          `json:"unlimitedSprites"` // 1ラインに9個以上のスプライトを表示する (オーバーフローフラグは実機通り)
+	PALETTE            PaletteConfig `json:"palette"`
+	OPEN_BUS_DECAY     int           `json:"openBusDecay"` // OpenBusの各ビットが0に戻るまでの時間 [ms] (0なら600, 負なら減衰しない)
+	OAM_DECAY          bool          `json:"oamDecay"`     // レンダリングが長く無効だとOAMの値が崩れる (テスト用)
+}
+
+// MARK: PaletteConfigの定義
+type PaletteConfig struct {
+	FILE       string  `json:"file"`       // .palファイル (64色 or 512色) のパス (空なら内蔵のパレット)
+	GENERATE   bool    `json:"generate"`   // NTSCの信号からパレットを生成する
+	HUE        float64 `json:"hue"`        // 色相のずれ [度]
+	SATURATION float64 `json:"saturation"` // 彩度 (0なら1.0)
+	CONTRAST   float64 `json:"contrast"`   // コントラスト (0なら1.0)
+	BRIGHTNESS float64 `json:"brightness"` // 明るさのオフセット
+	GAMMA      float64 `json:"gamma"`      // ガンマ (0なら2.2)
 }
 
 // MARK: RenderConfigの定義
 type RenderConfig struct {
-	SCALE_FACTOR             int  `json:"scale"`
-	DOUBLE_BUFFERING_ENABLED bool `json:"doubleBuffering"`
-	FULLSCREEN               bool `json:"fullscreen"`
+	SCALE_FACTOR             int            `json:"scale"`
+	DOUBLE_BUFFERING_ENABLED bool           `json:"doubleBuffering"`
+	FULLSCREEN               bool           `json:"fullscreen"`
+	NTSC_FILTER              string         `json:"ntscFilter"`       // none / composite / svideo / rgb
+	UPSCALER                 string         `json:"upscaler"`         // nearest / scale2x / scale3x / scale4x / hq2x / hq3x / hq4x / xbr2x / xbr3x / xbr4x
+	ASPECT_CORRECTION        bool           `json:"aspectCorrection"` // 8:7のピクセル比で横に伸ばして表示する
+	INTEGER_SCALING          bool           `json:"integerScaling"`   // 整数倍だけで拡大し，余りは黒帯にする
+	OVERSCAN                 OverscanConfig `json:"overscan"`         // 画面の端を切り取る
+	CRT_MASK                 string         `json:"crtMask"`          // none / scanlines / shadowmask
+	CRT_MASK_STRENGTH        float64        `json:"crtMaskStrength"`  // マスクの濃さ 0 ~ 1 (0なら0.3)
+}
+
+// MARK: OverscanConfigの定義
+type OverscanConfig struct {
+	TOP    int `json:"top"`    // 上から切り取るライン数
+	BOTTOM int `json:"bottom"` // 下から切り取るライン数
+	LEFT   int `json:"left"`   // 左から切り取るピクセル数
+	RIGHT  int `json:"right"`  // 右から切り取るピクセル数
 }
 
 // MARK: RomConfigの定義
 type RomConfig struct {
-	AUTO_LOADING bool `json:"autoLoading"`
+	AUTO_LOADING  bool   `json:"autoLoading"`
+	SAVE_DIR      string `json:"saveDir"`      // セーブデータの保存先 (空ならデフォルト)
+	SAVE_INTERVAL int    `json:"saveInterval"` // セーブデータを書き出す間隔 [秒] (0以下で無効)
+	SAVE_BACKUPS  int    `json:"saveBackups"`  // セーブデータのバックアップ世代数
+}
+
+// MARK: SystemConfigの定義
+type SystemConfig struct {
+	RAW_REGION    string `json:"region"`       // auto / ntsc / pal / dendy
+	GAME_DATABASE string `json:"gameDatabase"` // CRC32と地域の対応表のパス
+	REGION        Region `json:"-"`            // カートリッジ読み込み後に決定される地域
+
+	RAW_VS_PPU      string              `json:"vsPpu"`         // auto / 2C03B / 2C04-0001 など
+	VS_PALETTE      string              `json:"vsPalette"`     // 2C04用のパレットファイル (.pal) のパス
+	VS_DIP_SWITCHES uint8               `json:"vsDipSwitches"` // Vs. システムのDIPスイッチ (bit0 = スイッチ1)
+	VS_PPU          cartridge.VsPPUType `json:"-"`             // カートリッジ読み込み後に決定されるVs. PPU
 }
 
 // MARK: ControllerConfigの定義
 type ControlConfig struct {
-	RAW_KEY_1P           KeyConfig `json:"key1p"`
-	RAW_KEY_2P           KeyConfig `json:"key2p"`
+	RAW_KEY_1P           KeyConfig   `json:"key1p"`
+	RAW_KEY_2P           KeyConfig   `json:"key2p"`
+	RAW_KEY_VS           VsKeyConfig `json:"keyVs"`
 	KEY_1P               SDLKeyConfig
 	KEY_2P               SDLKeyConfig
+	KEY_VS               SDLVsKeyConfig
 	GamepadAxisThreshold int16 `json:"gamepadAxisThreshold"`
 }
 
@@ -116,6 +186,20 @@ type KeyConfig struct {
 	BUTTON_SELECT string `json:"buttonSelect"`
 }
 
+// MARK: Vs. システムのKeyConfigの定義
+type SDLVsKeyConfig struct {
+	COIN_1  sdl.Keycode
+	COIN_2  sdl.Keycode
+	SERVICE sdl.Keycode
+}
+
+// MARK: Vs. システムのKeyConfigの定義
+type VsKeyConfig struct {
+	COIN_1  string `json:"coin1"`
+	COIN_2  string `json:"coin2"`
+	SERVICE string `json:"service"`
+}
+
 // MARK: コンフィグファイルの読み込み
 func LoadFromFile() *Config {
 	// コンフィグファイルの読み込み
@@ -151,6 +235,11 @@ func ParseFromJson(file []byte) (Config, error) {
 	// キーコンフィグをstringからsdl.KeyCodeに変換して登録
 	config.Control.KEY_1P = MapKeyConfig(config.Control.RAW_KEY_1P)
 	config.Control.KEY_2P = MapKeyConfig(config.Control.RAW_KEY_2P)
+	config.Control.KEY_VS = MapVsKeyConfig(config.Control.RAW_KEY_VS)
+	if config.Control.KEY_VS == (SDLVsKeyConfig{}) {
+		// 古いコンフィグファイルにはVs. システムのキーが無いためデフォルトを使う
+		config.Control.KEY_VS = DefaultControl.KEY_VS
+	}
 
 	return config, err
 }
@@ -158,9 +247,19 @@ func ParseFromJson(file []byte) (Config, error) {
 // MARK: KeyConfigをSDLKeyConfigに変換
 func MapKeyConfig(raw KeyConfig) SDLKeyConfig {
 	var out SDLKeyConfig
-	rvOut := reflect.ValueOf(&out).Elem()
-	rvIn := reflect.ValueOf(raw)
+	mapKeyNames(reflect.ValueOf(raw), reflect.ValueOf(&out).Elem())
+	return out
+}
 
+// MARK: VsKeyConfigをSDLVsKeyConfigに変換
+func MapVsKeyConfig(raw VsKeyConfig) SDLVsKeyConfig {
+	var out SDLVsKeyConfig
+	mapKeyNames(reflect.ValueOf(raw), reflect.ValueOf(&out).Elem())
+	return out
+}
+
+// MARK: キー名のフィールドを同名のsdl.Keycodeのフィールドへ変換
+func mapKeyNames(rvIn reflect.Value, rvOut reflect.Value) {
 	for i := 0; i < rvIn.NumField(); i++ {
 		fieldName := rvIn.Type().Field(i).Name
 		rawValue := rvIn.Field(i).String()
@@ -170,6 +269,4 @@ func MapKeyConfig(raw KeyConfig) SDLKeyConfig {
 			outField.SetInt(int64(sdl.GetKeyFromName(rawValue)))
 		}
 	}
-
-	return out
 }
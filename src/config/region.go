@@ -0,0 +1,122 @@
+package config
+
+import (
+	"Famicom-emulator/cartridge"
+	"fmt"
+	"strings"
+)
+
+// MARK: 地域 (本体のタイミング) の定義
+type Region uint8
+
+const (
+	REGION_NTSC Region = iota
+	REGION_PAL
+	REGION_DENDY
+)
+
+const (
+	NTSC_MASTER_CLOCK = 21_477_272.0 // NTSCのマスタークロック [Hz]
+	PAL_MASTER_CLOCK  = 26_601_712.0 // PAL/Dendyのマスタークロック [Hz]
+)
+
+// MARK: 文字列から地域を取得 ("auto" や空文字の場合は false)
+func ParseRegion(name string) (Region, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "ntsc":
+		return REGION_NTSC, true
+	case "pal":
+		return REGION_PAL, true
+	case "dendy":
+		return REGION_DENDY, true
+	default:
+		return REGION_NTSC, false
+	}
+}
+
+// MARK: カートリッジと設定から地域を決定
+func (c *Config) ResolveRegion(cart *cartridge.Cartridge) Region {
+	// 1. コンフィグで明示されていればそれを優先
+	if region, ok := ParseRegion(c.System.RAW_REGION); ok {
+		return region
+	}
+	if c.System.RAW_REGION != "" && !strings.EqualFold(c.System.RAW_REGION, "auto") {
+		fmt.Printf("[Warning] System: unknown region '%s', detecting from cartridge.\n", c.System.RAW_REGION)
+	}
+
+	// 2. NES 2.0 ヘッダ / ゲームデータベースの判定結果
+	switch cart.TimingMode() {
+	case cartridge.TIMING_PAL:
+		return REGION_PAL
+	case cartridge.TIMING_DENDY:
+		return REGION_DENDY
+	default:
+		// 不明・マルチリージョンの場合はNTSC
+		return REGION_NTSC
+	}
+}
+
+// MARK: 地域名の取得
+func (r Region) String() string {
+	switch r {
+	case REGION_PAL:
+		return "PAL"
+	case REGION_DENDY:
+		return "Dendy"
+	default:
+		return "NTSC"
+	}
+}
+
+// MARK: CPUのクロック周波数 [Hz] の取得
+func (r Region) CPUClock() float64 {
+	/*
+		NTSC : マスタークロック / 12
+		PAL  : マスタークロック / 16
+		Dendy: マスタークロック / 15
+	*/
+	switch r {
+	case REGION_PAL:
+		return PAL_MASTER_CLOCK / 16
+	case REGION_DENDY:
+		return PAL_MASTER_CLOCK / 15
+	default:
+		return NTSC_MASTER_CLOCK / 12
+	}
+}
+
+// MARK: CPU 1サイクルあたりのPPUドット数の取得 (分子, 分母)
+func (r Region) PPUClockRatio() (uint, uint) {
+	// PALのみPPUはCPUの3.2倍のクロック，それ以外は3倍
+	if r == REGION_PAL {
+		return 16, 5
+	}
+	return 3, 1
+}
+
+// MARK: 1フレームあたりのスキャンライン数の取得
+func (r Region) Scanlines() uint16 {
+	if r == REGION_NTSC {
+		return 262
+	}
+	return 312
+}
+
+// MARK: VBlankが始まるスキャンラインの取得
+func (r Region) VBlankScanline() uint16 {
+	// Dendyはポストレンダーの後に51ライン待ってからVBlank (NMI) に入る
+	if r == REGION_DENDY {
+		return 291
+	}
+	return 241
+}
+
+// MARK: $2001の赤と緑の色強調ビットが入れ替わっているかどうか (2C07 / UA6538)
+func (r Region) SwapsRedGreenEmphasis() bool {
+	return r != REGION_NTSC
+}
+
+// MARK: 奇数フレームでプリレンダーラインのドットを1つ飛ばすかどうか
+func (r Region) SkipsOddFrameDot() bool {
+	return r == REGION_NTSC
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"Famicom-emulator/cartridge"
+	"fmt"
+	"strings"
+)
+
+// MARK: カートリッジと設定からVs. システムのPPUを決定
+func (c *Config) ResolveVsPPU(cart *cartridge.Cartridge) cartridge.VsPPUType {
+	// Vs. システム以外は常に2C02
+	if !cart.IsVsSystem() {
+		return cartridge.VS_PPU_NONE
+	}
+
+	// 1. コンフィグで明示されていればそれを優先 (iNES 1.0 のROM向け)
+	if vsPPU, ok := cartridge.ParseVsPPUType(c.System.RAW_VS_PPU); ok {
+		return vsPPU
+	}
+	if c.System.RAW_VS_PPU != "" && !strings.EqualFold(c.System.RAW_VS_PPU, "auto") {
+		fmt.Printf("[Warning] System: unknown Vs. PPU '%s', using cartridge header.\n", c.System.RAW_VS_PPU)
+	}
+
+	// 2. ヘッダ (iNES 1.0 ならゲームデータベース) の判定結果
+	return cart.VsPPUType()
+}
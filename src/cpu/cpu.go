@@ -6,14 +6,51 @@ import (
 
 	"Famicom-emulator/bus"
 	"Famicom-emulator/config"
+	"Famicom-emulator/debugger"
 )
 
+// MARK: CPUから見たバスのインターフェース (*bus.Bus, テストではフラットなメモリ)
+type cpuBus interface {
+	Tick(uint)
+	Cycles() uint
+	ReadByteFrom(uint16) uint8
+	ReadWordFrom(uint16) uint16
+	DummyReadFrom(uint16) // 値を捨てる読み取り (CDL・ウォッチポイントには掛けない)
+	PeekByteFrom(uint16) uint8
+	WriteByteAt(uint16, uint8)
+	WriteWordAt(uint16, uint16)
+	Reset()
+
+	NMI() bool
+	APUIRQ() bool
+	MapperIRQ() bool
+	DMAPending() bool
+	RunDMA(uint16)
+
+	PPUFrame() uint
+	PPUPosition() (uint16, uint)
+	AttachDebugger(*debugger.Debugger)
+	Debugger() *debugger.Debugger
+	AttachCodeDataLogger(*debugger.CodeDataLogger)
+	CodeDataLogger() *debugger.CodeDataLogger
+	AttachTraceLogger(*debugger.TraceLogger)
+	TraceLogger() *debugger.TraceLogger
+}
+
 // MARK: CPUの定義
 type CPU struct {
 	registers      registers
 	InstructionSet instructionSet
-	bus            bus.Bus
+	bus            cpuBus
 	config         config.Config
+	symbols        *debugger.SymbolTable
+
+	// 割り込みのポーリング (各サイクルの終わりに信号線を見る)
+	nmiPending     bool // NMIのエッジを検出した
+	irqPending     bool // IRQが有効 (Iフラグが0でIRQ線がアクティブ)
+	prevNmiPending bool // 1サイクル前の nmiPending (命令の最後から2番目のサイクルの状態)
+	prevIrqPending bool // 1サイクル前の irqPending
+	skipPoll       bool // 次のサイクルでは prev* を更新しない (分岐命令の最後のサイクル)
 }
 
 // MARK: CPUの初期化メソッド (カートリッジ無し，デバッグ・テスト用)
@@ -36,15 +73,14 @@ func (c *CPU) InitForTest(debug bool) {
 		PC: 0x0000,
 		// PC: c.ReadWordFrom(0xFFFC),
 	}
-	c.bus = bus.Bus{}
-	c.bus.InitForTest()
+	c.bus = &flatBus{}
 	c.InstructionSet = generateInstructionSet(c)
 	c.config.Cpu.LOG_ENABLED = debug
 }
 
 // MARK: CPUの初期化メソッド (Bus有り)
 func (c *CPU) Init(bus bus.Bus, config config.Config) {
-	c.bus = bus
+	c.bus = &bus
 	c.registers = registers{
 		A: 0x00,
 		X: 0x00,
@@ -60,51 +96,70 @@ func (c *CPU) Init(bus bus.Bus, config config.Config) {
 			Carry:     false,
 		},
 		SP: 0xFD,
-		PC: c.ReadWordFrom(0xFFFC),
+		PC: c.ReadWordFrom(RESET.VectorAddress),
 	}
 	c.InstructionSet = generateInstructionSet(c)
 	c.config = config
+
+	// リセットシーケンスの7サイクル (最初の命令は CYC:7 から始まる)
+	c.bus.Tick(uint(RESET.CPUCycles))
 }
 
 // MARK:  命令の実行
 func (c *CPU) Step() {
-	// NMIの実行
-	if c.bus.NMI() {
-		c.interrupt(NMI)
+	// デバッガで停止中は何もしない
+	d := c.bus.Debugger()
+	if d != nil && d.Paused() {
+		return
 	}
 
-	// IRQの実行
-	if !c.registers.P.Interrupt && (c.bus.APUIRQ() || c.bus.MapperIRQ()) {
-		c.interrupt(IRQ)
+	// 割り込みの実行 (直前の命令の最後から2番目のサイクルでポーリングした結果を使う)
+	if c.prevNmiPending || c.prevIrqPending {
+		request := IRQ
+		if c.prevNmiPending {
+			c.nmiPending = false
+			request = NMI
+		}
+		taken := c.interrupt(request)
+		if d != nil {
+			d.Interrupt(taken.debuggerInterrupt())
+		}
 	}
 
-	// 実行ログのトレース
-	if c.config.Cpu.LOG_ENABLED {
+	// デバッガのフック (ブレークポイントに掛かったら命令を実行しない)
+	if d != nil && d.BeforeInstruction(c.debugState()) {
+		return
+	}
+
+	// Code/Data Logger に実行する命令を記録
+	if l := c.bus.CodeDataLogger(); l != nil {
+		c.logCode(l)
+	}
+
+	// 実行ログのトレース (TraceLoggerがあればファイルへ書き出す)
+	if t := c.bus.TraceLogger(); t != nil {
+		c.logTrace(t)
+	} else if c.config.Cpu.LOG_ENABLED {
 		fmt.Println(c.Trace())
 	}
 
-	// 命令のフェッチ
-	opecode := c.ReadByteFrom(c.registers.PC)
+	// 命令のフェッチ (メモリアクセスごとにバスを1サイクル進める)
+	opecode := c.fetch()
 
 	// 命令のデコード
 	instruction, exists := c.InstructionSet[opecode]
 
 	if !exists {
-		log.Fatalf("Error: Unknown opecode $%02X at PC=%04X", opecode, c.registers.PC)
+		log.Fatalf("Error: Unknown opecode $%02X at PC=%04X", opecode, c.registers.PC-1)
 	}
 
-	_, isPageCrossed := c.calcOperandAddress(instruction.AddressingMode)
-	instruction.Handler(instruction.AddressingMode)
-	if isPageCrossed {
-		c.bus.Tick(uint(1))
+	// オペランドの無い命令も2サイクル目に次のバイトを読む (ダミー読み取り)
+	if instruction.AddressingMode == Implied || instruction.AddressingMode == Accumulator {
+		c.dummyRead(c.registers.PC)
 	}
 
-	if !instruction.Jump {
-		// オペランド分プログラムカウンタを進める (オペコードの分 -1)
-		c.registers.PC += uint16(instruction.Bytes)
-	}
-
-	c.bus.Tick(uint(instruction.Cycles))
+	// 命令の実行 (オペランドのフェッチでPCが進む)
+	instruction.Handler(instruction.AddressingMode)
 }
 
 // MARK: ループ実行
@@ -126,10 +181,15 @@ func (c *CPU) RunWithCallback(callback func(c *CPU)) {
 	}
 }
 
-// MARK: NMIのハンドリング
-func (c *CPU) interrupt(interrupt Interrupt) {
+// MARK: NMI・IRQのハンドリング (実際に使った割り込みを返す)
+func (c *CPU) interrupt(interrupt Interrupt) Interrupt {
+	// 命令のフェッチの代わりに2サイクル分のダミー読み取り
+	c.dummyRead(c.registers.PC)
+	c.dummyRead(c.registers.PC)
+
 	// 現在のPCを退避
 	c.pushWord(c.registers.PC)
+	interrupt = c.hijack(interrupt)
 
 	// ステータスレジスタをスタックにプッシュ
 	status := c.registers.P
@@ -138,8 +198,34 @@ func (c *CPU) interrupt(interrupt Interrupt) {
 	c.pushByte(status.ToByte())
 	c.registers.P.Interrupt = true
 
-	c.bus.Tick(uint(interrupt.CPUCycles))
-	c.registers.PC = c.ReadWordFrom(interrupt.VectorAddress) // 割り込みベクタ
+	c.registers.PC = c.readWord(interrupt.VectorAddress) // 割り込みベクタ
+	return interrupt
+}
+
+// MARK: 割り込みベクタの乗っ取り (PCを退避した時点でNMIが来ていればNMIのベクタを読む)
+func (c *CPU) hijack(interrupt Interrupt) Interrupt {
+	if interrupt.Type != TYPE_NMI && c.nmiPending {
+		c.nmiPending = false
+		return NMI
+	}
+	return interrupt
+}
+
+// MARK: 割り込みのポーリング (各サイクルの終わり)
+func (c *CPU) pollInterrupts() {
+	// ポーリングしないサイクルでは，命令の終わりに使う結果を前のサイクルのまま残す
+	if c.skipPoll {
+		c.skipPoll = false
+	} else {
+		c.prevNmiPending = c.nmiPending
+		c.prevIrqPending = c.irqPending
+	}
+	// NMIはエッジトリガなので一度検出したら割り込みを実行するまで保持する
+	if c.bus.NMI() {
+		c.nmiPending = true
+	}
+	// IRQはレベルトリガ (信号線がアクティブな間だけ有効)
+	c.irqPending = !c.registers.P.Interrupt && (c.bus.APUIRQ() || c.bus.MapperIRQ())
 }
 
 // MARK: リセット
@@ -147,96 +233,201 @@ func (c *CPU) Reset() {
 	fmt.Println("[Info] System: Reset requested")
 	c.registers.SP -= 3
 	c.registers.P.Interrupt = true
-	c.registers.PC = c.bus.ReadWordFrom(0xFFFC)
+	c.registers.PC = c.bus.ReadWordFrom(RESET.VectorAddress)
 	c.bus.Reset()
+	c.bus.Tick(uint(RESET.CPUCycles))
 }
 
-// MARK: ワーキングメモリの参照 (1byte)
+// MARK: ワーキングメモリの参照 (1byte，サイクルを消費しない)
 func (c *CPU) ReadByteFrom(address uint16) uint8 {
 	return c.bus.ReadByteFrom(address)
 }
 
-// MARK: ワーキングメモリの参照 (2byte)
+// MARK: ワーキングメモリの参照 (2byte，サイクルを消費しない)
 func (c *CPU) ReadWordFrom(address uint16) uint16 {
 	return c.bus.ReadWordFrom(address)
 }
 
-// MARK: ワーキングメモリへの書き込み (1byte)
+// MARK: ワーキングメモリへの書き込み (1byte，サイクルを消費しない)
 func (c *CPU) WriteByteAt(address uint16, data uint8) {
 	c.bus.WriteByteAt(address, data)
 }
 
-// MARK: ワーキングメモリへの書き込み (2byte)
+// MARK: ワーキングメモリへの書き込み (2byte，サイクルを消費しない)
 func (c *CPU) WriteWordAt(address uint16, data uint16) {
 	c.bus.WriteWordAt(address, data)
 }
 
+// MARK: 1サイクルのメモリの読み取り (バスを1サイクル進めてから読む)
+func (c *CPU) read(address uint16) uint8 {
+	// DMAはCPUの読み取りサイクルでだけCPUを止められる
+	if c.bus.DMAPending() {
+		c.bus.RunDMA(address)
+	}
+	c.bus.Tick(1)
+	value := c.bus.ReadByteFrom(address)
+	c.pollInterrupts()
+	return value
+}
+
+// MARK: 1サイクルのダミー読み取り (値を使わない読み取り，タイミングはreadと同じ)
+func (c *CPU) dummyRead(address uint16) {
+	if c.bus.DMAPending() {
+		c.bus.RunDMA(address)
+	}
+	c.bus.Tick(1)
+	c.bus.DummyReadFrom(address)
+	c.pollInterrupts()
+}
+
+// MARK: 2サイクルのメモリの読み取り (2byte)
+func (c *CPU) readWord(address uint16) uint16 {
+	lower := c.read(address)
+	upper := c.read(address + 1)
+	return uint16(upper)<<8 | uint16(lower)
+}
+
+// MARK: 1サイクルのメモリへの書き込み (バスを1サイクル進めてから書く)
+func (c *CPU) write(address uint16, data uint8) {
+	c.bus.Tick(1)
+	c.bus.WriteByteAt(address, data)
+	c.pollInterrupts()
+}
+
+// MARK: PCが指すバイトの読み取り (PCを進める)
+func (c *CPU) fetch() uint8 {
+	value := c.read(c.registers.PC)
+	c.registers.PC++
+	return value
+}
+
+// MARK: PCが指すワードの読み取り (PCを進める)
+func (c *CPU) fetchWord() uint16 {
+	lower := c.fetch()
+	upper := c.fetch()
+	return uint16(upper)<<8 | uint16(lower)
+}
+
 func (c *CPU) isPageCrossed(address1 uint16, address2 uint16) bool {
 	cond := (address1 & 0xFF00) != (address2 & 0xFF00)
 	// fmt.Println("page crossed")
 	return cond
 }
 
-// MARK: アドレッシングモードからオペランドアドレスを計算
-func (c *CPU) calcOperandAddress(mode AddressingMode) (uint16, bool) {
+/*
+	オペランドのフェッチのサイクル (命令のフェッチの後)
+
+	zpg,X / zpg,Y : ゼロページのベースアドレスをダミーで読んでからインデックスを足す
+	abs,X / abs,Y : 下位バイトにインデックスを足したアドレスを読み，ページを跨いでいれば読み直す
+	X,Ind         : ベースアドレスをダミーで読んでからポインタを読む
+	Ind,Y         : ポインタを読んでから abs,Y と同様
+
+	書き込み・RMW命令はページを跨がなくても必ずダミー読み取りが入る
+*/
+// MARK: アドレッシングモードからオペランドアドレスを計算 (オペランドのフェッチでPCを進める)
+func (c *CPU) operandAddress(mode AddressingMode, access AccessKind) uint16 {
 	switch mode {
-	case Immediate:
-		return c.registers.PC + 1, false
+	case Immediate, Relative:
+		address := c.registers.PC
+		c.registers.PC++
+		return address
 	case ZeroPage:
-		return uint16(c.ReadByteFrom(c.registers.PC + 1)), false
+		return uint16(c.fetch())
 	case Absolute:
-		return c.ReadWordFrom(c.registers.PC + 1), false
+		return c.fetchWord()
 	case ZeroPageXIndexed:
-		base := c.ReadByteFrom(c.registers.PC + 1)
-		return uint16(base + c.registers.X), false
+		base := c.fetch()
+		c.dummyRead(uint16(base))
+		return uint16(base + c.registers.X)
 	case ZeroPageYIndexed:
-		base := c.ReadByteFrom(c.registers.PC + 1)
-		return uint16(base + c.registers.Y), false
+		base := c.fetch()
+		c.dummyRead(uint16(base))
+		return uint16(base + c.registers.Y)
 	case AbsoluteXIndexed:
-		base := c.ReadWordFrom(c.registers.PC + 1)
-		ptr := base + uint16(c.registers.X)
-		return ptr, c.isPageCrossed(base, ptr)
+		return c.indexed(c.fetchWord(), c.registers.X, access)
 	case AbsoluteYIndexed:
-		base := c.ReadWordFrom(c.registers.PC + 1)
-		ptr := base + uint16(c.registers.Y)
-		return ptr, c.isPageCrossed(base, ptr)
+		return c.indexed(c.fetchWord(), c.registers.Y, access)
 	case Indirect:
-		ptr := c.ReadWordFrom(c.registers.PC + 1)
-		// ページ境界をまたぐ際のバグを再現
-		if (ptr & 0xFF) == 0xFF {
-			lower := c.ReadByteFrom(ptr)
-			upper := c.ReadByteFrom(ptr & 0xFF00)
-			return uint16(upper)<<8 | uint16(lower), false
-		} else {
-			return c.ReadWordFrom(ptr), false
-		}
+		ptr := c.fetchWord()
+		// ページ境界をまたぐ際のバグを再現 (上位バイトは同じページの先頭から読む)
+		lower := c.read(ptr)
+		upper := c.read(ptr&0xFF00 | uint16(uint8(ptr)+1))
+		return uint16(upper)<<8 | uint16(lower)
 	case IndirectXIndexed:
-		base := c.ReadByteFrom(c.registers.PC + 1)
-		ptr := uint8(base + c.registers.X)
-		lower := c.ReadByteFrom(uint16(ptr))
-		upper := c.ReadByteFrom(uint16(ptr+1) & 0xFF)
-		return uint16(upper)<<8 | uint16(lower), false
+		base := c.fetch()
+		c.dummyRead(uint16(base))
+		ptr := base + c.registers.X
+		lower := c.read(uint16(ptr))
+		upper := c.read(uint16(ptr + 1))
+		return uint16(upper)<<8 | uint16(lower)
 	case IndirectYIndexed:
-		base := c.ReadByteFrom(c.registers.PC + 1)
-		ptr := uint8(base)
-		lower := c.ReadByteFrom(uint16(ptr))
-		upper := c.ReadByteFrom(uint16(ptr+1) & 0xFF)
-		derefBase := uint16(upper)<<8 | uint16(lower)
-		deref := derefBase + uint16(c.registers.Y)
-		return deref, c.isPageCrossed(deref, derefBase)
-	case Relative:
-		offset := int8(c.ReadByteFrom(c.registers.PC + 1))
-		return uint16(offset), false
-	case Accumulator:
-		// log.Fatalf("Error: Mode Accumulator doesn't take any operands")
-		return 0x0000, false
-	case Implied:
-		// log.Fatalf("Error: Mode Implied doesn't take any operands")
-		return 0x0000, false
+		ptr := c.fetch()
+		lower := c.read(uint16(ptr))
+		upper := c.read(uint16(ptr + 1))
+		return c.indexed(uint16(upper)<<8|uint16(lower), c.registers.Y, access)
 	default:
-		// log.Fatalf("Error: Unsupported addressing type '%v'", mode)
-		return 0x0000, false
+		// Implied / Accumulator はオペランドを持たない
+		return 0x0000
+	}
+}
+
+// MARK: インデックスを足したアドレスの計算 (ページを跨ぐか書き込みならダミー読み取り)
+func (c *CPU) indexed(base uint16, index uint8, access AccessKind) uint16 {
+	address := base + uint16(index)
+	if c.isPageCrossed(base, address) || access != ACCESS_READ {
+		// 上位バイトへの繰り上がりを反映する前のアドレスを読んでしまう
+		c.dummyRead(base&0xFF00 | address&0x00FF)
 	}
+	return address
+}
+
+// MARK: オペランドの値の読み取り
+func (c *CPU) readOperand(mode AddressingMode) uint8 {
+	return c.read(c.operandAddress(mode, ACCESS_READ))
+}
+
+// MARK: オペランドへの書き込み
+func (c *CPU) writeOperand(mode AddressingMode, value uint8) {
+	c.write(c.operandAddress(mode, ACCESS_WRITE), value)
+}
+
+// MARK: オペランドの読み取り・変更・書き込み (RMW命令)
+func (c *CPU) modify(mode AddressingMode, operation func(uint8) uint8) uint8 {
+	if mode == Accumulator {
+		c.registers.A = operation(c.registers.A)
+		return c.registers.A
+	}
+	address := c.operandAddress(mode, ACCESS_MODIFY)
+	value := c.read(address)
+	// 変更前の値を一度書き戻してから変更後の値を書き込む
+	c.write(address, value)
+	result := operation(value)
+	c.write(address, result)
+	return result
+}
+
+// MARK: 分岐命令の共通処理
+func (c *CPU) branch(condition bool) {
+	offset := int8(c.fetch())
+	if !condition {
+		return
+	}
+	jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
+	crossed := c.isPageCrossed(c.registers.PC, jumpAddr)
+
+	/*
+		分岐命令はオペランドのフェッチの前と，ページを跨ぐ場合は上位バイトを直す前にだけ割り込みをポーリングする
+		ページを跨がずに分岐する場合は最後のサイクルでポーリングしないので，
+		2サイクル目に来たNMI・IRQは次の命令の後まで遅れる
+	*/
+	c.skipPoll = !crossed
+
+	// 分岐する場合は1サイクル，ページを跨ぐ場合はさらに1サイクル掛かる
+	c.dummyRead(c.registers.PC)
+	if crossed {
+		c.dummyRead(c.registers.PC&0xFF00 | jumpAddr&0x00FF)
+	}
+	c.registers.PC = jumpAddr
 }
 
 // MARK: フラグ(N, Z)の更新
@@ -259,61 +450,36 @@ func (c *CPU) updateNZFlags(result uint8) {
 // MARK: スタック操作
 func (c *CPU) pushByte(value uint8) {
 	stack_addr := 0x0100 | uint16(c.registers.SP)
-	c.WriteByteAt(stack_addr, value)
+	c.write(stack_addr, value)
 	c.registers.SP--
 }
 
 func (c *CPU) pushWord(value uint16) {
-	stack_addr := 0x0100 | uint16(c.registers.SP)
-	c.WriteByteAt(stack_addr, (uint8(value >> 8)))
-	c.registers.SP--
-
-	stack_addr = 0x0100 | uint16(c.registers.SP)
-	c.WriteByteAt(stack_addr, (uint8(value & 0xFF)))
-	c.registers.SP--
+	c.pushByte(uint8(value >> 8))
+	c.pushByte(uint8(value & 0xFF))
 }
 
 func (c *CPU) popByte() uint8 {
 	c.registers.SP++
 	stack_addr := 0x0100 | uint16(c.registers.SP)
-	value := c.ReadByteFrom(stack_addr)
+	value := c.read(stack_addr)
 	return value
 }
 
 func (c *CPU) popWord() uint16 {
-	c.registers.SP++
-	stack_addr := 0x0100 | uint16(c.registers.SP)
-	lower := c.ReadByteFrom(stack_addr)
-
-	c.registers.SP++
-	stack_addr = 0x0100 | uint16(c.registers.SP)
-	upper := c.ReadByteFrom(stack_addr)
+	lower := c.popByte()
+	upper := c.popByte()
 
 	return uint16(upper)<<8 | uint16(lower)
 }
 
-// MARK: AAC命令の実装
-func (c *CPU) aac(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
-	c.registers.A &= value
-
-	c.updateNZFlags(c.registers.A)
-	c.registers.P.Carry = c.registers.P.Negative
+// MARK: スタックのダミー読み取り (SPを動かす前の1サイクル)
+func (c *CPU) peekStack() {
+	c.dummyRead(0x0100 | uint16(c.registers.SP))
 }
 
-// MARK: AAX命令の実装
-func (c *CPU) aax(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	result := c.registers.X & c.registers.A
-
-	c.WriteByteAt(addr, result)
-}
-
-// MARK: ADC命令の実装
-func (c *CPU) adc(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+// MARK: 加算 (ADC・SBC の共通処理)
+func (c *CPU) addWithCarry(value uint8) {
 	sum := uint16(c.registers.A) + uint16(value)
 
 	if c.registers.P.Carry {
@@ -333,10 +499,78 @@ func (c *CPU) adc(mode AddressingMode) {
 	c.registers.A = result
 }
 
+// MARK: 比較 (CMP・CPX・CPY の共通処理)
+func (c *CPU) compare(register uint8, value uint8) {
+	c.registers.P.Carry = register >= value
+	c.updateNZFlags(register - value)
+}
+
+// MARK: 左シフト (ASL)
+func (c *CPU) shiftLeft(value uint8) uint8 {
+	c.registers.P.Carry = (value >> 7) != 0
+	return value << 1
+}
+
+// MARK: 右シフト (LSR)
+func (c *CPU) shiftRight(value uint8) uint8 {
+	c.registers.P.Carry = (value & 0x01) != 0
+	return value >> 1
+}
+
+// MARK: 左回転 (ROL)
+func (c *CPU) rotateLeft(value uint8) uint8 {
+	result := value << 1
+	if c.registers.P.Carry {
+		result |= 0x01
+	}
+	c.registers.P.Carry = value>>7 != 0
+	return result
+}
+
+// MARK: 右回転 (ROR)
+func (c *CPU) rotateRight(value uint8) uint8 {
+	result := value >> 1
+	if c.registers.P.Carry {
+		result |= 1 << 7
+	}
+	c.registers.P.Carry = value&0x01 != 0
+	return result
+}
+
+// MARK: 1減算 (DEC)
+func (c *CPU) decrement(value uint8) uint8 {
+	return value - 1
+}
+
+// MARK: 1加算 (INC)
+func (c *CPU) increment(value uint8) uint8 {
+	return value + 1
+}
+
+// MARK: AAC命令の実装
+func (c *CPU) aac(mode AddressingMode) {
+	value := c.readOperand(mode)
+	c.registers.A &= value
+
+	c.updateNZFlags(c.registers.A)
+	c.registers.P.Carry = c.registers.P.Negative
+}
+
+// MARK: AAX命令の実装
+func (c *CPU) aax(mode AddressingMode) {
+	result := c.registers.X & c.registers.A
+
+	c.writeOperand(mode, result)
+}
+
+// MARK: ADC命令の実装
+func (c *CPU) adc(mode AddressingMode) {
+	c.addWithCarry(c.readOperand(mode))
+}
+
 // MARK: AND命令の実装
 func (c *CPU) and(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	c.registers.A &= value
 
 	c.updateNZFlags(c.registers.A)
@@ -344,8 +578,7 @@ func (c *CPU) and(mode AddressingMode) {
 
 // MARK: ARR命令の実装
 func (c *CPU) arr(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	c.registers.A &= value
 
 	// 1ビット右回転
@@ -363,30 +596,18 @@ func (c *CPU) arr(mode AddressingMode) {
 
 // MARK: ASL命令の実装
 func (c *CPU) asl(mode AddressingMode) {
-	if mode == Accumulator {
-		c.registers.P.Carry = (c.registers.A >> 7) != 0
-		c.registers.A = c.registers.A << 1
-		c.updateNZFlags(c.registers.A)
-	} else {
-		addr, _ := c.calcOperandAddress(mode)
-		value := c.ReadByteFrom(addr)
-		c.registers.P.Carry = (value >> 7) != 0
-		value <<= 1
-		c.WriteByteAt(addr, value)
-		c.updateNZFlags(value)
-	}
+	c.updateNZFlags(c.modify(mode, c.shiftLeft))
 }
 
 // MARK: ASR命令の実装
 func (c *CPU) asr(mode AddressingMode) {
-	c.and(mode)
-	c.lsr(Accumulator)
+	c.registers.A = c.shiftRight(c.registers.A & c.readOperand(mode))
+	c.updateNZFlags(c.registers.A)
 }
 
 // MARK: ATX命令の実装
 func (c *CPU) atx(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	c.registers.A &= value
 	c.registers.X = c.registers.A
 	c.updateNZFlags(c.registers.X)
@@ -394,15 +615,13 @@ func (c *CPU) atx(mode AddressingMode) {
 
 // MARK: AXA命令の実装
 func (c *CPU) axa(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
 	result := (c.registers.X & c.registers.A) & 7
-	c.WriteByteAt(addr, result)
+	c.writeOperand(mode, result)
 }
 
 // MARK: AXS命令の実装
 func (c *CPU) axs(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	c.registers.X &= c.registers.A
 
 	c.registers.P.Carry = c.registers.X >= value
@@ -413,47 +632,22 @@ func (c *CPU) axs(mode AddressingMode) {
 
 // MARK: BCC命令の実装
 func (c *CPU) bcc(mode AddressingMode) {
-	if !c.registers.P.Carry {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(!c.registers.P.Carry)
 }
 
 // MARK: BCS命令の実装
 func (c *CPU) bcs(mode AddressingMode) {
-	if c.registers.P.Carry {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(c.registers.P.Carry)
 }
 
 // MARK: BEQ命令の実装
 func (c *CPU) beq(mode AddressingMode) {
-	if c.registers.P.Zero {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(c.registers.P.Zero)
 }
 
 // MARK: BIT命令の実装
 func (c *CPU) bit(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	mask := c.registers.A
 
 	c.registers.P.Zero = (value & mask) == 0x00
@@ -463,79 +657,45 @@ func (c *CPU) bit(mode AddressingMode) {
 
 // MARK: BMI命令の実装
 func (c *CPU) bmi(mode AddressingMode) {
-	if c.registers.P.Negative {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(c.registers.P.Negative)
 }
 
 // MARK: BNE命令の実装
 func (c *CPU) bne(mode AddressingMode) {
-	if !c.registers.P.Zero {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(!c.registers.P.Zero)
 }
 
 // MARK: BPL命令の実装
 func (c *CPU) bpl(mode AddressingMode) {
-	if !c.registers.P.Negative {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(!c.registers.P.Negative)
 }
 
 // MARK: BRK命令の実装
 func (c *CPU) brk(mode AddressingMode) {
-	c.pushWord(c.registers.PC + 2)
+	// BRKの次のバイトは読み飛ばされる (2サイクル目のダミー読み取り)
+	c.pushWord(c.registers.PC + 1)
+	// NMIに乗っ取られてもBフラグは立ったままプッシュされる
+	interrupt := c.hijack(IRQ)
 
 	status := c.registers.P
 	status.Break = true
 	c.pushByte(status.ToByte())
 
 	c.registers.P.Interrupt = true
-	c.registers.PC = c.ReadWordFrom(0xFFFE)
+	c.registers.PC = c.readWord(interrupt.VectorAddress)
+	if d := c.bus.Debugger(); d != nil && interrupt.Type == TYPE_NMI {
+		d.Interrupt(debugger.INTERRUPT_NMI)
+	}
 }
 
 // MARK: BVC命令の実装
 func (c *CPU) bvc(mode AddressingMode) {
-	if !c.registers.P.Overflow {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(!c.registers.P.Overflow)
 }
 
 // MARK: BVS命令の実装
 func (c *CPU) bvs(mode AddressingMode) {
-	if c.registers.P.Overflow {
-		c.bus.Tick(1)
-		offset, _ := c.calcOperandAddress(mode)
-		jumpAddr := uint16(int32(c.registers.PC) + int32(offset)) // 符号反転させなずに足すためint32を用いる
-		if c.isPageCrossed(c.registers.PC, jumpAddr) {
-			c.bus.Tick(1)
-		}
-		c.registers.PC = jumpAddr
-	}
+	c.branch(c.registers.P.Overflow)
 }
 
 // MARK: CLC命令の実装
@@ -560,43 +720,27 @@ func (c *CPU) clv(mode AddressingMode) {
 
 // MARK: CMP命令の実装
 func (c *CPU) cmp(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
-
-	c.registers.P.Carry = c.registers.A >= value
-	c.updateNZFlags(c.registers.A - value)
+	c.compare(c.registers.A, c.readOperand(mode))
 }
 
 // MARK: CPX命令の実装
 func (c *CPU) cpx(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
-
-	c.registers.P.Carry = c.registers.X >= value
-	c.updateNZFlags(c.registers.X - value)
+	c.compare(c.registers.X, c.readOperand(mode))
 }
 
 // MARK: CPY命令の実装
 func (c *CPU) cpy(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
-
-	c.registers.P.Carry = c.registers.Y >= value
-	c.updateNZFlags(c.registers.Y - value)
+	c.compare(c.registers.Y, c.readOperand(mode))
 }
 
 // MARK: DCP命令の実装
 func (c *CPU) dcp(mode AddressingMode) {
-	c.dec(mode)
-	c.cmp(mode)
+	c.compare(c.registers.A, c.modify(mode, c.decrement))
 }
 
 // MARK: DEC命令の実装
 func (c *CPU) dec(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr) - 1
-	c.WriteByteAt(addr, value)
-	c.updateNZFlags(value)
+	c.updateNZFlags(c.modify(mode, c.decrement))
 }
 
 // MARK: DEX命令の実装
@@ -613,22 +757,19 @@ func (c *CPU) dey(mode AddressingMode) {
 
 // MARK: DOP命令の実装
 func (c *CPU) dop(mode AddressingMode) {
+	c.readOperand(mode)
 }
 
 // MARK: EOR命令の実装
 func (c *CPU) eor(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	c.registers.A ^= value
 	c.updateNZFlags(c.registers.A)
 }
 
 // MARK: INC命令の実装
 func (c *CPU) inc(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr) + 1
-	c.WriteByteAt(addr, value)
-	c.updateNZFlags(value)
+	c.updateNZFlags(c.modify(mode, c.increment))
 }
 
 // MARK: INX命令の実装
@@ -645,20 +786,22 @@ func (c *CPU) iny(mode AddressingMode) {
 
 // MARK: ISC命令の実装
 func (c *CPU) isc(mode AddressingMode) {
-	c.inc(mode)
-	c.sbc(mode)
+	c.addWithCarry(^c.modify(mode, c.increment))
 }
 
 // MARK: JMP命令の実装
 func (c *CPU) jmp(mode AddressingMode) {
-	c.registers.PC, _ = c.calcOperandAddress(mode)
+	c.registers.PC = c.operandAddress(mode, ACCESS_READ)
 }
 
 // MARK: JSR命令の実装
 func (c *CPU) jsr(mode AddressingMode) {
-	c.pushWord(c.registers.PC + 2)
-	addr, _ := c.calcOperandAddress(mode)
-	c.registers.PC = addr
+	// 下位バイトを読んだ後，上位バイトを読む前に戻り先 (JSRの最後のバイト) をプッシュする
+	lower := c.fetch()
+	c.peekStack()
+	c.pushWord(c.registers.PC)
+	upper := c.read(c.registers.PC)
+	c.registers.PC = uint16(upper)<<8 | uint16(lower)
 }
 
 // MARK: KIL命令の実装
@@ -667,8 +810,7 @@ func (c *CPU) kil(mode AddressingMode) {
 
 // MARK: LAR命令の実装
 func (c *CPU) lar(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	result := c.registers.SP & value
 
 	c.registers.A = result
@@ -679,14 +821,14 @@ func (c *CPU) lar(mode AddressingMode) {
 
 // MARK: LAX命令の実装
 func (c *CPU) lax(mode AddressingMode) {
-	c.lda(mode)
-	c.tax(mode)
+	c.registers.A = c.readOperand(mode)
+	c.registers.X = c.registers.A
+	c.updateNZFlags(c.registers.A)
 }
 
 // MARK: LDA命令の実装
 func (c *CPU) lda(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	operand := c.ReadByteFrom(addr)
+	operand := c.readOperand(mode)
 
 	c.registers.A = uint8(operand)
 	c.updateNZFlags(c.registers.A)
@@ -694,8 +836,7 @@ func (c *CPU) lda(mode AddressingMode) {
 
 // MARK: LDX命令の実装
 func (c *CPU) ldx(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	operand := c.ReadByteFrom(addr)
+	operand := c.readOperand(mode)
 
 	c.registers.X = uint8(operand)
 	c.updateNZFlags(c.registers.X)
@@ -703,8 +844,7 @@ func (c *CPU) ldx(mode AddressingMode) {
 
 // MARK: LDY命令の実装
 func (c *CPU) ldy(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	operand := c.ReadByteFrom(addr)
+	operand := c.readOperand(mode)
 
 	c.registers.Y = uint8(operand)
 	c.updateNZFlags(c.registers.Y)
@@ -712,18 +852,7 @@ func (c *CPU) ldy(mode AddressingMode) {
 
 // MARK: LSR命令の実装
 func (c *CPU) lsr(mode AddressingMode) {
-	if mode == Accumulator {
-		c.registers.P.Carry = (c.registers.A & 0x01) != 0
-		c.registers.A = c.registers.A >> 1
-		c.updateNZFlags(c.registers.A)
-	} else {
-		addr, _ := c.calcOperandAddress(mode)
-		value := c.ReadByteFrom(addr)
-		c.registers.P.Carry = (value & 0x01) != 0
-		value >>= 1
-		c.WriteByteAt(addr, value)
-		c.updateNZFlags(value)
-	}
+	c.updateNZFlags(c.modify(mode, c.shiftRight))
 }
 
 // MARK: NOP命令の実装
@@ -732,8 +861,7 @@ func (c *CPU) nop(mode AddressingMode) {
 
 // MARK: ORA命令の実装
 func (c *CPU) ora(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 
 	c.registers.A |= value
 	c.updateNZFlags(c.registers.A)
@@ -753,12 +881,14 @@ func (c *CPU) php(mode AddressingMode) {
 
 // MARK: PLA命令の実装
 func (c *CPU) pla(mode AddressingMode) {
+	c.peekStack()
 	c.registers.A = c.popByte()
 	c.updateNZFlags(c.registers.A)
 }
 
 // MARK: PLP命令の実装
 func (c *CPU) plp(mode AddressingMode) {
+	c.peekStack()
 	value := c.popByte()
 	// PLPでフラグレジスタを復元するときには常にBreakはリセット, Reservedはセット?
 	value = (value &^ 0x10) | 0x20
@@ -767,115 +897,52 @@ func (c *CPU) plp(mode AddressingMode) {
 
 // MARK: ROL命令の実装
 func (c *CPU) rol(mode AddressingMode) {
-	if mode == Accumulator {
-		carry := c.registers.A>>7 != 0
-		c.registers.A = c.registers.A << 1
-
-		if c.registers.P.Carry {
-			c.registers.A |= 0x01
-		}
-
-		c.registers.P.Carry = carry
-		c.updateNZFlags(c.registers.A)
-	} else {
-		addr, _ := c.calcOperandAddress(mode)
-		value := c.ReadByteFrom(addr)
-
-		carry := value>>7 != 0
-		value <<= 1
-
-		if c.registers.P.Carry {
-			value |= 0x01
-		}
-
-		c.registers.P.Carry = carry
-		c.registers.P.Negative = value>>7 != 0
-		c.updateNZFlags(value)
-
-		c.WriteByteAt(addr, value)
-	}
+	c.updateNZFlags(c.modify(mode, c.rotateLeft))
 }
 
 // MARK: RLA命令の実装
 func (c *CPU) rla(mode AddressingMode) {
-	c.rol(mode)
-	c.and(mode)
+	c.registers.A &= c.modify(mode, c.rotateLeft)
+	c.updateNZFlags(c.registers.A)
 }
 
 // MARK: ROR命令の実装
 func (c *CPU) ror(mode AddressingMode) {
-	if mode == Accumulator {
-		carry := c.registers.A&0x01 != 0
-		c.registers.A = c.registers.A >> 1
-
-		if c.registers.P.Carry {
-			c.registers.A |= 1 << 7
-		}
-
-		c.registers.P.Carry = carry
-		c.updateNZFlags(c.registers.A)
-	} else {
-		addr, _ := c.calcOperandAddress(mode)
-		value := c.ReadByteFrom(addr)
-
-		carry := value&0x01 != 0
-		value >>= 1
-
-		if c.registers.P.Carry {
-			value |= 1 << 7
-		}
-
-		c.registers.P.Carry = carry
-		c.registers.P.Negative = value>>7 != 0
-		c.updateNZFlags(value)
-
-		c.WriteByteAt(addr, value)
-	}
+	c.updateNZFlags(c.modify(mode, c.rotateRight))
 }
 
 // MARK: RRA命令の実装
 func (c *CPU) rra(mode AddressingMode) {
-	c.ror(mode)
-	c.adc(mode)
+	c.addWithCarry(c.modify(mode, c.rotateRight))
 }
 
 // MARK: RTI命令の実装
 func (c *CPU) rti(mode AddressingMode) {
+	c.peekStack()
 	status := c.popByte()
-	addr := c.popWord()
 
 	// RTIかにて復帰時には常にBreakはリセット, Reservedはセット？
 	status = (status &^ 0x10) | 0x20
+	// 復元したIフラグは続くPCのプル中のポーリングからすぐに効く (CLI・SEI・PLPと違って遅れない)
 	c.registers.P.SetFromByte(status)
-	c.registers.PC = addr
 	c.registers.P.Break = false
+
+	c.registers.PC = c.popWord()
 }
 
 // MARK: RTS命令の実装
 func (c *CPU) rts(mode AddressingMode) {
+	c.peekStack()
 	addr := c.popWord()
+	// 戻り先はJSRの最後のバイトなので，読み飛ばして次の命令へ
+	c.dummyRead(addr)
 	c.registers.PC = addr + 1
 }
 
 // MARK: SBC命令の実装
 func (c *CPU) sbc(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
-
-	sum := uint16(c.registers.A) + uint16(^value)
-
-	if c.registers.P.Carry {
-		sum++
-	}
-
-	result := uint8(sum)
-
-	// フラグ設定
-	c.registers.P.Carry = sum > 0xFF
-	c.registers.P.Overflow = ((c.registers.A^value)&0x80) != 0 && ((c.registers.A^result)&0x80) != 0
-
-	c.updateNZFlags(result)
-	c.registers.A = result
+	// A - M - (1 - C) = A + ^M + C
+	c.addWithCarry(^c.readOperand(mode))
 }
 
 // MARK: SEC命令の実装
@@ -895,46 +962,43 @@ func (c *CPU) sei(mode AddressingMode) {
 
 // MARK: SLO命令の実装
 func (c *CPU) slo(mode AddressingMode) {
-	c.asl(mode)
-	c.ora(mode)
+	c.registers.A |= c.modify(mode, c.shiftLeft)
+	c.updateNZFlags(c.registers.A)
 }
 
 // MARK: SRE命令の実装
 func (c *CPU) sre(mode AddressingMode) {
-	c.lsr(mode)
-	c.eor(mode)
+	c.registers.A ^= c.modify(mode, c.shiftRight)
+	c.updateNZFlags(c.registers.A)
 }
 
 // MARK: STA命令の実装
 func (c *CPU) sta(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	c.WriteByteAt(addr, c.registers.A)
+	c.writeOperand(mode, c.registers.A)
 }
 
 // MARK: STX命令の実装
 func (c *CPU) stx(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	c.WriteByteAt(addr, c.registers.X)
+	c.writeOperand(mode, c.registers.X)
 }
 
 // MARK: STY命令の実装
 func (c *CPU) sty(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
-	c.WriteByteAt(addr, c.registers.Y)
+	c.writeOperand(mode, c.registers.Y)
 }
 
 // MARK: SXA命令の実装
 func (c *CPU) sxa(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
+	addr := c.operandAddress(mode, ACCESS_WRITE)
 	result := c.registers.X & (uint8(addr>>8) + 1)
-	c.WriteByteAt(addr, result)
+	c.write(addr, result)
 }
 
 // MARK: SYA命令の実装
 func (c *CPU) sya(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
+	addr := c.operandAddress(mode, ACCESS_WRITE)
 	result := c.registers.Y & (uint8(addr>>8) + 1)
-	c.WriteByteAt(addr, result)
+	c.write(addr, result)
 }
 
 // MARK: TAX命令の実装
@@ -951,6 +1015,7 @@ func (c *CPU) tay(mode AddressingMode) {
 
 // MARK: TOP命令の実装
 func (c *CPU) top(mode AddressingMode) {
+	c.readOperand(mode)
 }
 
 // MARK: TSX命令の実装
@@ -979,17 +1044,16 @@ func (c *CPU) tya(mode AddressingMode) {
 // MARK: XAA命令の実装
 func (c *CPU) xaa(mode AddressingMode) {
 	// @NOTE 未定義動作
-	addr, _ := c.calcOperandAddress(mode)
-	value := c.ReadByteFrom(addr)
+	value := c.readOperand(mode)
 	c.registers.A = (c.registers.A | 0x80) & c.registers.X & value
 }
 
 // MARK: XAS命令の実装
 func (c *CPU) xas(mode AddressingMode) {
-	addr, _ := c.calcOperandAddress(mode)
+	addr := c.operandAddress(mode, ACCESS_WRITE)
 	c.registers.SP = c.registers.X & c.registers.A
 	result := c.registers.SP & (uint8(addr>>8) + 1)
-	c.WriteByteAt(addr, result)
+	c.write(addr, result)
 }
 
 // MARK: canPeek: トレース時に安全に読み取れるアドレスか (副作用やpanicを避ける)
@@ -1034,7 +1098,7 @@ func (c *CPU) canPeek(addr uint16) bool {
 // MARK: デバッグ用表示メソッド
 func (c *CPU) Trace() string {
 	pc := c.registers.PC
-	opcode := c.ReadByteFrom(pc)
+	opcode := c.bus.PeekByteFrom(pc)
 	inst, ok := c.InstructionSet[opcode]
 	if !ok {
 		return fmt.Sprintf("%04X  %02X        ???                         A:%02X X:%02X Y:%02X P:%02X SP:%02X",
@@ -1043,10 +1107,10 @@ func (c *CPU) Trace() string {
 
 	var b1, b2 uint8
 	if inst.Bytes > 1 {
-		b1 = c.ReadByteFrom(pc + 1)
+		b1 = c.bus.PeekByteFrom(pc + 1)
 	}
 	if inst.Bytes > 2 {
-		b2 = c.ReadByteFrom(pc + 2)
+		b2 = c.bus.PeekByteFrom(pc + 2)
 	}
 
 	hexDump := fmt.Sprintf("%02X", opcode)
@@ -1066,7 +1130,7 @@ func (c *CPU) Trace() string {
 
 	peek := func(addr uint16) (uint8, bool) {
 		if c.canPeek(addr) {
-			return c.ReadByteFrom(addr), true
+			return c.bus.PeekByteFrom(addr), true
 		}
 		return 0, false
 	}
@@ -1080,106 +1144,106 @@ func (c *CPU) Trace() string {
 	case Relative:
 		offset := int8(b1)
 		target := pc + 2 + uint16(offset)
-		operandStr = fmt.Sprintf("$%04X", target)
+		operandStr = c.symbolName(target, "$%04X")
 	case ZeroPage:
 		effAddr = uint16(b1)
 		if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("$%02X = %02X", b1, v)
+				operandStr = fmt.Sprintf("%s = %02X", c.symbolName(effAddr, "$%02X"), v)
 				break
 			}
 		}
-		operandStr = fmt.Sprintf("$%02X", b1)
+		operandStr = c.symbolName(effAddr, "$%02X")
 	case ZeroPageXIndexed:
 		base := b1
 		effAddr = uint16(uint8(base + c.registers.X))
 		if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("$%02X,X @ %02X = %02X", base, effAddr, v)
+				operandStr = fmt.Sprintf("%s,X @ %02X = %02X", c.symbolName(uint16(base), "$%02X"), effAddr, v)
 				break
 			}
 		}
-		operandStr = fmt.Sprintf("$%02X,X @ %02X", base, effAddr)
+		operandStr = fmt.Sprintf("%s,X @ %02X", c.symbolName(uint16(base), "$%02X"), effAddr)
 	case ZeroPageYIndexed:
 		base := b1
 		effAddr = uint16(uint8(base + c.registers.Y))
 		if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("$%02X,Y @ %02X = %02X", base, effAddr, v)
+				operandStr = fmt.Sprintf("%s,Y @ %02X = %02X", c.symbolName(uint16(base), "$%02X"), effAddr, v)
 				break
 			}
 		}
-		operandStr = fmt.Sprintf("$%02X,Y @ %02X", base, effAddr)
+		operandStr = fmt.Sprintf("%s,Y @ %02X", c.symbolName(uint16(base), "$%02X"), effAddr)
 	case Absolute:
 		effAddr = uint16(b1) | (uint16(b2) << 8)
 		if opcode == 0x20 || opcode == 0x4C { // JSR/JMP
-			operandStr = fmt.Sprintf("$%04X", effAddr)
+			operandStr = c.symbolName(effAddr, "$%04X")
 		} else if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("$%04X = %02X", effAddr, v)
+				operandStr = fmt.Sprintf("%s = %02X", c.symbolName(effAddr, "$%04X"), v)
 			} else {
-				operandStr = fmt.Sprintf("$%04X", effAddr)
+				operandStr = c.symbolName(effAddr, "$%04X")
 			}
 		} else {
-			operandStr = fmt.Sprintf("$%04X", effAddr)
+			operandStr = c.symbolName(effAddr, "$%04X")
 		}
 	case AbsoluteXIndexed:
 		base := uint16(b1) | (uint16(b2) << 8)
 		effAddr = base + uint16(c.registers.X)
 		if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("$%04X,X @ %04X = %02X", base, effAddr, v)
+				operandStr = fmt.Sprintf("%s,X @ %04X = %02X", c.symbolName(base, "$%04X"), effAddr, v)
 				break
 			}
 		}
-		operandStr = fmt.Sprintf("$%04X,X @ %04X", base, effAddr)
+		operandStr = fmt.Sprintf("%s,X @ %04X", c.symbolName(base, "$%04X"), effAddr)
 	case AbsoluteYIndexed:
 		base := uint16(b1) | (uint16(b2) << 8)
 		effAddr = base + uint16(c.registers.Y)
 		if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("$%04X,Y @ %04X = %02X", base, effAddr, v)
+				operandStr = fmt.Sprintf("%s,Y @ %04X = %02X", c.symbolName(base, "$%04X"), effAddr, v)
 				break
 			}
 		}
-		operandStr = fmt.Sprintf("$%04X,Y @ %04X", base, effAddr)
+		operandStr = fmt.Sprintf("%s,Y @ %04X", c.symbolName(base, "$%04X"), effAddr)
 	case Indirect:
 		ptr := uint16(b1) | (uint16(b2) << 8)
 		var target uint16
 		if ptr&0x00FF == 0x00FF {
-			low := c.ReadByteFrom(ptr)
-			high := c.ReadByteFrom(ptr & 0xFF00)
+			low := c.bus.PeekByteFrom(ptr)
+			high := c.bus.PeekByteFrom(ptr & 0xFF00)
 			target = uint16(high)<<8 | uint16(low)
 		} else {
-			target = c.ReadWordFrom(ptr)
+			target = uint16(c.bus.PeekByteFrom(ptr+1))<<8 | uint16(c.bus.PeekByteFrom(ptr))
 		}
-		operandStr = fmt.Sprintf("($%04X) = %04X", ptr, target)
+		operandStr = fmt.Sprintf("(%s) = %04X", c.symbolName(ptr, "$%04X"), target)
 	case IndirectXIndexed:
 		base := b1
 		ptr := uint8(base + c.registers.X)
-		low := c.ReadByteFrom(uint16(ptr))
-		high := c.ReadByteFrom(uint16(ptr+1) & 0x00FF)
+		low := c.bus.PeekByteFrom(uint16(ptr))
+		high := c.bus.PeekByteFrom(uint16(ptr+1) & 0x00FF)
 		effAddr = uint16(high)<<8 | uint16(low)
 		if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("($%02X,X) @ %02X = %04X = %02X", base, ptr, effAddr, v)
+				operandStr = fmt.Sprintf("(%s,X) @ %02X = %04X = %02X", c.symbolName(uint16(base), "$%02X"), ptr, effAddr, v)
 				break
 			}
 		}
-		operandStr = fmt.Sprintf("($%02X,X) @ %02X = %04X", base, ptr, effAddr)
+		operandStr = fmt.Sprintf("(%s,X) @ %02X = %04X", c.symbolName(uint16(base), "$%02X"), ptr, effAddr)
 	case IndirectYIndexed:
 		base := b1
-		low := c.ReadByteFrom(uint16(base))
-		high := c.ReadByteFrom(uint16(base+1) & 0x00FF)
+		low := c.bus.PeekByteFrom(uint16(base))
+		high := c.bus.PeekByteFrom(uint16(base+1) & 0x00FF)
 		baseAddr := uint16(high)<<8 | uint16(low)
 		effAddr = baseAddr + uint16(c.registers.Y)
 		if !isStore {
 			if v, ok := peek(effAddr); ok {
-				operandStr = fmt.Sprintf("($%02X),Y = %04X @ %04X = %02X", base, baseAddr, effAddr, v)
+				operandStr = fmt.Sprintf("(%s),Y = %04X @ %04X = %02X", c.symbolName(uint16(base), "$%02X"), baseAddr, effAddr, v)
 				break
 			}
 		}
-		operandStr = fmt.Sprintf("($%02X),Y = %04X @ %04X", base, baseAddr, effAddr)
+		operandStr = fmt.Sprintf("(%s),Y = %04X @ %04X", c.symbolName(uint16(base), "$%02X"), baseAddr, effAddr)
 	default:
 	}
 
@@ -1227,6 +1291,11 @@ func (c *CPU) RunCycles(targetCycles uint) {
 	var executed uint = 0
 
 	for executed < targetCycles {
+		// デバッガで停止したら残りのサイクルは捨てる
+		if d := c.bus.Debugger(); d != nil && d.Paused() {
+			return
+		}
+
 		prev := c.bus.Cycles()
 		c.Step()
 		post := c.bus.Cycles()
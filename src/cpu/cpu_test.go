@@ -4213,7 +4213,7 @@ func TestBRK(t *testing.T) {
 
 				// 割り込みベクタを WRAM 内に設定
 				c.WriteByteAt(0xFFFE, 0x34) // low (IRQ/BRK vector)
-				c.WriteByteAt(0xFFF, 0x12)  // high (→ 0x1234)
+				c.WriteByteAt(0xFFFF, 0x12) // high (→ 0x1234)
 
 				c.WriteByteAt(0x0200, 0x00) // BRK命令
 			},
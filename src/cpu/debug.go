@@ -0,0 +1,74 @@
+package cpu
+
+import (
+	"Famicom-emulator/debugger"
+	"fmt"
+)
+
+// MARK: デバッガの接続 (nilで切り離し)
+func (c *CPU) AttachDebugger(d *debugger.Debugger) {
+	c.bus.AttachDebugger(d)
+	if d != nil {
+		d.SetMemoryReader(c.bus.PeekByteFrom)
+	}
+}
+
+// MARK: デバッガに渡すCPUの状態の取得
+func (c *CPU) debugState() debugger.CPUState {
+	scanline, dot := c.bus.PPUPosition()
+	return debugger.CPUState{
+		PC:       c.registers.PC,
+		A:        c.registers.A,
+		X:        c.registers.X,
+		Y:        c.registers.Y,
+		P:        c.registers.P.ToByte(),
+		SP:       c.registers.SP,
+		Opcode:   c.bus.PeekByteFrom(c.registers.PC),
+		Scanline: scanline,
+		Dot:      dot,
+		Cycles:   c.bus.Cycles(),
+	}
+}
+
+// MARK: Code/Data Logger の接続 (nilで切り離し)
+func (c *CPU) AttachCodeDataLogger(l *debugger.CodeDataLogger) {
+	c.bus.AttachCodeDataLogger(l)
+}
+
+// MARK: 実行する命令を Code/Data Logger に記録
+func (c *CPU) logCode(l *debugger.CodeDataLogger) {
+	opcode := c.bus.PeekByteFrom(c.registers.PC)
+	instruction, exists := c.InstructionSet[opcode]
+	if !exists {
+		return
+	}
+	mode := instruction.AddressingMode
+	l.LogCode(c.registers.PC, opcode, instruction.Bytes, mode == IndirectXIndexed || mode == IndirectYIndexed)
+}
+
+// MARK: シンボルテーブルの接続 (nilで切り離し)
+func (c *CPU) AttachSymbols(s *debugger.SymbolTable) {
+	c.symbols = s
+}
+
+// MARK: アドレスのシンボル名の取得 (無ければ format で整形)
+func (c *CPU) symbolName(address uint16, format string) string {
+	if name, ok := c.symbols.Lookup(address); ok {
+		return name
+	}
+	return fmt.Sprintf(format, address)
+}
+
+// MARK: 実行ログの接続 (nilで切り離し)
+func (c *CPU) AttachTraceLogger(t *debugger.TraceLogger) {
+	c.bus.AttachTraceLogger(t)
+}
+
+// MARK: 実行する命令を実行ログに書き出す
+func (c *CPU) logTrace(t *debugger.TraceLogger) {
+	if !t.Check(c.registers.PC, c.bus.PPUFrame()) {
+		return
+	}
+	scanline, dot := c.bus.PPUPosition()
+	t.Write(c.Trace(), scanline, dot, c.bus.Cycles())
+}
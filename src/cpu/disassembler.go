@@ -0,0 +1,175 @@
+package cpu
+
+import "fmt"
+
+// MARK: 変数定義
+var (
+	// 逆アセンブル用の命令テーブル (ハンドラは呼ばない)
+	opcodeTable = generateInstructionSet(&CPU{})
+
+	// NESのI/Oレジスタ名
+	ioRegisterNames = map[uint16]string{
+		0x2000: "PPUCTRL", 0x2001: "PPUMASK", 0x2002: "PPUSTATUS", 0x2003: "OAMADDR",
+		0x2004: "OAMDATA", 0x2005: "PPUSCROLL", 0x2006: "PPUADDR", 0x2007: "PPUDATA",
+		0x4000: "SQ1_VOL", 0x4001: "SQ1_SWEEP", 0x4002: "SQ1_LO", 0x4003: "SQ1_HI",
+		0x4004: "SQ2_VOL", 0x4005: "SQ2_SWEEP", 0x4006: "SQ2_LO", 0x4007: "SQ2_HI",
+		0x4008: "TRI_LINEAR", 0x400A: "TRI_LO", 0x400B: "TRI_HI",
+		0x400C: "NOISE_VOL", 0x400E: "NOISE_LO", 0x400F: "NOISE_HI",
+		0x4010: "DMC_FREQ", 0x4011: "DMC_RAW", 0x4012: "DMC_START", 0x4013: "DMC_LEN",
+		0x4014: "OAMDMA", 0x4015: "SND_CHN", 0x4016: "JOY1", 0x4017: "JOY2",
+	}
+)
+
+// MARK: ラベル名の検索関数 (見つからなければ false)
+type Labeler func(address uint16) (string, bool)
+
+// MARK: 逆アセンブルした1命令
+type DisassembledInstruction struct {
+	Address   uint16
+	Bytes     []uint8
+	Code      InstructionCode
+	Mnemonic  string
+	Operand   string // 表示用のオペランド
+	Mode      AddressingMode
+	Target    uint16 // 分岐先・参照先のアドレス
+	HasTarget bool   // Targetが有効かどうか
+	Known     bool   // オペコードが命令テーブルに存在するかどうか
+}
+
+// MARK: 命令のバイト数の取得
+func (d DisassembledInstruction) Length() uint16 {
+	return uint16(len(d.Bytes))
+}
+
+// MARK: 命令の文字列表現の取得
+func (d DisassembledInstruction) String() string {
+	if d.Operand == "" {
+		return d.Mnemonic
+	}
+	return d.Mnemonic + " " + d.Operand
+}
+
+// MARK: 命令のメタデータの取得
+func LookupInstruction(opcode uint8) (Instruction, bool) {
+	inst, ok := opcodeTable[opcode]
+	return inst, ok
+}
+
+// MARK: I/Oレジスタ名の取得
+func IORegisterName(address uint16) (string, bool) {
+	name, ok := ioRegisterNames[address]
+	return name, ok
+}
+
+// MARK: 指定アドレスの1命令を逆アセンブル
+func Disassemble(read func(uint16) uint8, address uint16, labeler Labeler) DisassembledInstruction {
+	opcode := read(address)
+	inst, ok := opcodeTable[opcode]
+	if !ok {
+		return DisassembledInstruction{
+			Address:  address,
+			Bytes:    []uint8{opcode},
+			Mnemonic: ".BYTE",
+			Operand:  fmt.Sprintf("$%02X", opcode),
+		}
+	}
+
+	bytes := make([]uint8, inst.Bytes)
+	for i := range bytes {
+		bytes[i] = read(address + uint16(i))
+	}
+	d := DisassembledInstruction{
+		Address:  address,
+		Bytes:    bytes,
+		Code:     inst.Code,
+		Mnemonic: inst.Code.ToString(),
+		Mode:     inst.AddressingMode,
+		Known:    true,
+	}
+
+	// オペランドのアドレスをラベル名 (無ければI/Oレジスタ名) に置き換える
+	name := func(addr uint16, digits int) string {
+		if labeler != nil {
+			if label, ok := labeler(addr); ok {
+				return label
+			}
+		}
+		if label, ok := IORegisterName(addr); ok {
+			return label
+		}
+		return fmt.Sprintf("$%0*X", digits, addr)
+	}
+
+	var b1 uint8
+	var word uint16
+	if inst.Bytes > 1 {
+		b1 = bytes[1]
+		word = uint16(b1)
+	}
+	if inst.Bytes > 2 {
+		word |= uint16(bytes[2]) << 8
+	}
+
+	switch inst.AddressingMode {
+	case Implied:
+	case Accumulator:
+		d.Operand = "A"
+	case Immediate:
+		d.Operand = fmt.Sprintf("#$%02X", b1)
+	case ZeroPage:
+		d.Target, d.HasTarget = word, true
+		d.Operand = name(word, 2)
+	case ZeroPageXIndexed:
+		d.Target, d.HasTarget = word, true
+		d.Operand = name(word, 2) + ",X"
+	case ZeroPageYIndexed:
+		d.Target, d.HasTarget = word, true
+		d.Operand = name(word, 2) + ",Y"
+	case Absolute:
+		d.Target, d.HasTarget = word, true
+		d.Operand = name(word, 4)
+	case AbsoluteXIndexed:
+		d.Target, d.HasTarget = word, true
+		d.Operand = name(word, 4) + ",X"
+	case AbsoluteYIndexed:
+		d.Target, d.HasTarget = word, true
+		d.Operand = name(word, 4) + ",Y"
+	case Relative:
+		// 分岐先は命令の次のアドレスからの符号付きオフセット
+		d.Target = address + uint16(inst.Bytes) + uint16(int8(b1))
+		d.HasTarget = true
+		d.Operand = name(d.Target, 4)
+	case Indirect:
+		d.Target, d.HasTarget = word, true
+		d.Operand = "(" + name(word, 4) + ")"
+	case IndirectXIndexed:
+		d.Target, d.HasTarget = word, true
+		d.Operand = "(" + name(word, 2) + ",X)"
+	case IndirectYIndexed:
+		d.Target, d.HasTarget = word, true
+		d.Operand = "(" + name(word, 2) + "),Y"
+	}
+
+	return d
+}
+
+// MARK: 分岐・ジャンプ命令かどうか
+func (d DisassembledInstruction) IsBranch() bool {
+	return d.Known && (d.Mode == Relative || d.Code == JMP || d.Code == JSR)
+}
+
+// MARK: 公式の命令かどうか (非公式命令と重複するオペコードを除く)
+func (d DisassembledInstruction) Official() bool {
+	if !d.Known {
+		return false
+	}
+	switch d.Bytes[0] {
+	case 0xEB, 0x1A, 0x3A, 0x5A, 0x7A, 0xDA, 0xFA:
+		return false
+	}
+	switch d.Code {
+	case AAC, AAX, ARR, ASR, ATX, AXA, AXS, DCP, DOP, ISC, KIL, LAR, LAX, RLA, RRA, SLO, SRE, SXA, SYA, TOP, XAA, XAS:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package cpu
+
+import "Famicom-emulator/debugger"
+
+/*
+	InitForTest・REPL 用のバス
+
+	64kBの全域を読み書きできるメモリだけを持ち，PPU・APU・カートリッジは無い
+	割り込みの信号線は nmi / irq を直接操作する
+*/
+// MARK: フラットなメモリのバスの定義
+type flatBus struct {
+	memory [0x10000]uint8
+	cycles uint
+	nmi    bool // NMIのエッジ (一度読まれると下がる)
+	irq    bool // IRQの信号線
+
+	debugger *debugger.Debugger
+	cdl      *debugger.CodeDataLogger
+	trace    *debugger.TraceLogger
+}
+
+// MARK: サイクルを進める
+func (b *flatBus) Tick(cycles uint) {
+	b.cycles += cycles
+}
+
+// MARK: 現在のサイクル数の取得
+func (b *flatBus) Cycles() uint {
+	return b.cycles
+}
+
+// MARK: メモリの読み取り (1byte)
+func (b *flatBus) ReadByteFrom(address uint16) uint8 {
+	return b.memory[address]
+}
+
+// MARK: メモリの読み取り (2byte)
+func (b *flatBus) ReadWordFrom(address uint16) uint16 {
+	return uint16(b.memory[address+1])<<8 | uint16(b.memory[address])
+}
+
+// MARK: ダミー読み取り (レジスタが無いので何も起きない)
+func (b *flatBus) DummyReadFrom(address uint16) {}
+
+// MARK: 副作用なしでメモリを覗き見る
+func (b *flatBus) PeekByteFrom(address uint16) uint8 {
+	return b.memory[address]
+}
+
+// MARK: メモリの書き込み (1byte)
+func (b *flatBus) WriteByteAt(address uint16, data uint8) {
+	b.memory[address] = data
+}
+
+// MARK: メモリの書き込み (2byte)
+func (b *flatBus) WriteWordAt(address uint16, data uint16) {
+	b.memory[address] = uint8(data)
+	b.memory[address+1] = uint8(data >> 8)
+}
+
+// MARK: リセット
+func (b *flatBus) Reset() {}
+
+// MARK: NMIを取得 (エッジを読んだら下げる)
+func (b *flatBus) NMI() bool {
+	nmi := b.nmi
+	b.nmi = false
+	return nmi
+}
+
+// MARK: APUのIRQを取得
+func (b *flatBus) APUIRQ() bool {
+	return b.irq
+}
+
+// MARK: マッパーのIRQを取得
+func (b *flatBus) MapperIRQ() bool {
+	return false
+}
+
+// MARK: DMAの要求があるかどうか
+func (b *flatBus) DMAPending() bool {
+	return false
+}
+
+// MARK: DMAの実行
+func (b *flatBus) RunDMA(address uint16) {}
+
+// MARK: PPUのフレーム数の取得
+func (b *flatBus) PPUFrame() uint {
+	return 0
+}
+
+// MARK: PPUのスキャンラインとドットの取得
+func (b *flatBus) PPUPosition() (uint16, uint) {
+	return 0, 0
+}
+
+// MARK: デバッガの接続
+func (b *flatBus) AttachDebugger(d *debugger.Debugger) {
+	b.debugger = d
+}
+
+// MARK: デバッガの取得
+func (b *flatBus) Debugger() *debugger.Debugger {
+	return b.debugger
+}
+
+// MARK: Code/Data Logger の接続
+func (b *flatBus) AttachCodeDataLogger(l *debugger.CodeDataLogger) {
+	b.cdl = l
+}
+
+// MARK: Code/Data Logger の取得
+func (b *flatBus) CodeDataLogger() *debugger.CodeDataLogger {
+	return b.cdl
+}
+
+// MARK: 実行ログの接続
+func (b *flatBus) AttachTraceLogger(t *debugger.TraceLogger) {
+	b.trace = t
+}
+
+// MARK: 実行ログの取得
+func (b *flatBus) TraceLogger() *debugger.TraceLogger {
+	return b.trace
+}
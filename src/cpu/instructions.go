@@ -21,6 +21,15 @@ type AddressingMode uint8
 
 type InstructionHandler func(mode AddressingMode)
 
+// オペランドへのアクセスの種類 (インデックス付きのダミー読み取りの有無が変わる)
+type AccessKind uint8
+
+const (
+	ACCESS_READ   AccessKind = iota // 読み取り (ページを跨いだときだけダミー読み取り)
+	ACCESS_WRITE                    // 書き込み (常にダミー読み取り)
+	ACCESS_MODIFY                   // 読み取り・変更・書き込み (常にダミー読み取り)
+)
+
 // 命令セット型の定義
 type instructionSet map[uint8]Instruction
 
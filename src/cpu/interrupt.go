@@ -1,10 +1,13 @@
 package cpu
 
+import "Famicom-emulator/debugger"
+
 type InterruptType uint8
 
 const (
 	TYPE_NMI InterruptType = iota
 	TYPE_IRQ
+	TYPE_RESET
 )
 
 type Interrupt struct {
@@ -14,6 +17,16 @@ type Interrupt struct {
 	CPUCycles     uint8
 }
 
+// MARK: デバッガに通知する割り込みの種類
+func (i Interrupt) debuggerInterrupt() debugger.Interrupt {
+	if i.Type == TYPE_NMI {
+		return debugger.INTERRUPT_NMI
+	}
+	return debugger.INTERRUPT_IRQ
+}
+
 var NMI = Interrupt{Type: TYPE_NMI, VectorAddress: 0xFFFA, BFlagMask: 0b0010_0000, CPUCycles: 7}
 
 var IRQ = Interrupt{Type: TYPE_IRQ, VectorAddress: 0xFFFE, BFlagMask: 0b0010_0000, CPUCycles: 7}
+
+var RESET = Interrupt{Type: TYPE_RESET, VectorAddress: 0xFFFC, BFlagMask: 0b0010_0000, CPUCycles: 7}
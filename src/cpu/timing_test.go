@@ -0,0 +1,310 @@
+package cpu
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// テストヘルパー関数：バスへのアクセスをサイクルごとに記録するバス
+type recordingBus struct {
+	*flatBus
+	accesses []string
+	irqAt    uint // このサイクルからIRQ線をアクティブにする (0なら無効)
+	nmiAt    uint // このサイクルでNMIのエッジを立てる (0なら無効)
+}
+
+// テストヘルパー関数：サイクルを進めて予定した割り込みの信号線を立てる
+func (b *recordingBus) Tick(cycles uint) {
+	b.flatBus.Tick(cycles)
+	if b.irqAt != 0 && b.cycles >= b.irqAt {
+		b.irq = true
+	}
+	if b.nmiAt != 0 && b.cycles == b.nmiAt {
+		b.nmi = true
+	}
+}
+
+// テストヘルパー関数：読み取りを記録する
+func (b *recordingBus) ReadByteFrom(address uint16) uint8 {
+	value := b.flatBus.ReadByteFrom(address)
+	b.accesses = append(b.accesses, fmt.Sprintf("%d R $%04X", b.cycles, address))
+	return value
+}
+
+// テストヘルパー関数：ダミー読み取りを記録する
+func (b *recordingBus) DummyReadFrom(address uint16) {
+	b.flatBus.DummyReadFrom(address)
+	b.accesses = append(b.accesses, fmt.Sprintf("%d D $%04X", b.cycles, address))
+}
+
+// テストヘルパー関数：書き込みを記録する
+func (b *recordingBus) WriteByteAt(address uint16, data uint8) {
+	b.flatBus.WriteByteAt(address, data)
+	b.accesses = append(b.accesses, fmt.Sprintf("%d W $%04X=$%02X", b.cycles, address, data))
+}
+
+// テストヘルパー関数：記録するバスを繋いだCPUを初期化する (プログラムは $0200 から)
+func setupRecordingCPU(program []uint8) (*CPU, *recordingBus) {
+	cpu := setupCPU()
+	b := &recordingBus{flatBus: &flatBus{}}
+	cpu.bus = b
+	copy(b.memory[0x0200:], program)
+	cpu.registers.PC = 0x0200
+	return cpu, b
+}
+
+// TestBusAccessSequence は命令ごとのサイクル単位のバスアクセスの順序をテストします
+func TestBusAccessSequence(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []uint8
+		setup   func(c *CPU)
+		want    []string
+	}{
+		{
+			name:    "Implied: 2サイクル目は次のバイトのダミー読み取り",
+			program: []uint8{0xE8}, // INX
+			want:    []string{"1 R $0200", "2 D $0201"},
+		},
+		{
+			name:    "Accumulator: 2サイクル目は次のバイトのダミー読み取り",
+			program: []uint8{0x0A}, // ASL A
+			want:    []string{"1 R $0200", "2 D $0201"},
+		},
+		{
+			name:    "LDA abs,X: ページを跨がなければダミー読み取りは無い",
+			program: []uint8{0xBD, 0xF0, 0x02}, // LDA $02F0,X
+			setup:   func(c *CPU) { c.registers.X = 0x01 },
+			want:    []string{"1 R $0200", "2 R $0201", "3 R $0202", "4 R $02F1"},
+		},
+		{
+			name:    "LDA abs,X: ページを跨ぐと上位バイトを直す前のアドレスを読む",
+			program: []uint8{0xBD, 0xF0, 0x02}, // LDA $02F0,X
+			setup:   func(c *CPU) { c.registers.X = 0x20 },
+			want:    []string{"1 R $0200", "2 R $0201", "3 R $0202", "4 D $0210", "5 R $0310"},
+		},
+		{
+			name:    "LDA (ind),Y: ページを跨ぐとダミー読み取りが入る",
+			program: []uint8{0xB1, 0x10}, // LDA ($10),Y
+			setup: func(c *CPU) {
+				c.WriteWordAt(0x0010, 0x03F0)
+				c.registers.Y = 0x20
+			},
+			want: []string{"1 R $0200", "2 R $0201", "3 R $0010", "4 R $0011", "5 D $0310", "6 R $0410"},
+		},
+		{
+			name:    "STA abs,X: ページを跨がなくてもダミー読み取りがある",
+			program: []uint8{0x9D, 0x00, 0x03}, // STA $0300,X
+			setup: func(c *CPU) {
+				c.registers.A = 0x42
+				c.registers.X = 0x01
+			},
+			want: []string{"1 R $0200", "2 R $0201", "3 R $0202", "4 D $0301", "5 W $0301=$42"},
+		},
+		{
+			name:    "STA (ind),Y: ページを跨がなくてもダミー読み取りがある",
+			program: []uint8{0x91, 0x10}, // STA ($10),Y
+			setup: func(c *CPU) {
+				c.WriteWordAt(0x0010, 0x0300)
+				c.registers.A = 0x42
+				c.registers.Y = 0x01
+			},
+			want: []string{"1 R $0200", "2 R $0201", "3 R $0010", "4 R $0011", "5 D $0301", "6 W $0301=$42"},
+		},
+		{
+			name:    "INC abs: 元の値を書き戻してから結果を書く",
+			program: []uint8{0xEE, 0x00, 0x03}, // INC $0300
+			setup:   func(c *CPU) { c.WriteByteAt(0x0300, 0x41) },
+			want:    []string{"1 R $0200", "2 R $0201", "3 R $0202", "4 R $0300", "5 W $0300=$41", "6 W $0300=$42"},
+		},
+		{
+			name:    "ASL abs,X: ダミー読み取りの後に元の値と結果を書く",
+			program: []uint8{0x1E, 0x00, 0x03}, // ASL $0300,X
+			setup: func(c *CPU) {
+				c.registers.X = 0x01
+				c.WriteByteAt(0x0301, 0x21)
+			},
+			want: []string{"1 R $0200", "2 R $0201", "3 R $0202", "4 D $0301", "5 R $0301", "6 W $0301=$21", "7 W $0301=$42"},
+		},
+		{
+			name:    "LDA zpg,X: インデックスを足す前のアドレスをダミーで読む",
+			program: []uint8{0xB5, 0x10}, // LDA $10,X
+			setup:   func(c *CPU) { c.registers.X = 0x01 },
+			want:    []string{"1 R $0200", "2 R $0201", "3 D $0010", "4 R $0011"},
+		},
+		{
+			name:    "BNE: 分岐しなければ2サイクル",
+			program: []uint8{0xD0, 0x10}, // BNE +16
+			setup:   func(c *CPU) { c.registers.P.Zero = true },
+			want:    []string{"1 R $0200", "2 R $0201"},
+		},
+		{
+			name:    "BNE: 分岐してページを跨がなければ3サイクル",
+			program: []uint8{0xD0, 0x10}, // BNE +16
+			want:    []string{"1 R $0200", "2 R $0201", "3 D $0202"},
+		},
+		{
+			name:    "BNE: 分岐してページを跨ぐと4サイクル",
+			program: []uint8{0xD0, 0xFC}, // BNE -4
+			want:    []string{"1 R $0200", "2 R $0201", "3 D $0202", "4 D $02FE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu, b := setupRecordingCPU(tt.program)
+			if tt.setup != nil {
+				tt.setup(cpu)
+			}
+			b.accesses = nil
+
+			cpu.Step()
+
+			if !reflect.DeepEqual(b.accesses, tt.want) {
+				t.Errorf("bus accesses = %v, want %v", b.accesses, tt.want)
+			}
+		})
+	}
+}
+
+// TestInterruptLatency は割り込みが実行されるまでの命令数をテストします
+func TestInterruptLatency(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  uint16
+		program []uint8
+		setup   func(c *CPU, b *recordingBus)
+		want    []uint16 // 各Step後のPC
+	}{
+		{
+			name:    "CLI: 次の命令の後までIRQは遅れる",
+			program: []uint8{0x58, 0xEA, 0xEA}, // CLI; NOP; NOP
+			setup:   func(c *CPU, b *recordingBus) { b.irqAt = 1 },
+			want:    []uint16{0x0201, 0x0202, 0x8001},
+		},
+		{
+			name:    "SEI: 直前に来ていたIRQはSEIの直後に実行される",
+			program: []uint8{0x78, 0xEA}, // SEI; NOP
+			setup: func(c *CPU, b *recordingBus) {
+				c.registers.P.Interrupt = false
+				b.irqAt = 1
+			},
+			want: []uint16{0x0201, 0x8001},
+		},
+		{
+			name:    "PLP: Iフラグを下ろしても次の命令の後までIRQは遅れる",
+			program: []uint8{0x28, 0xEA, 0xEA}, // PLP; NOP; NOP
+			setup: func(c *CPU, b *recordingBus) {
+				c.WriteByteAt(0x01FE, 0x00)
+				b.irqAt = 1
+			},
+			want: []uint16{0x0201, 0x0202, 0x8001},
+		},
+		{
+			name:    "RTI: 復元したIフラグはすぐに効く",
+			program: []uint8{0x40}, // RTI
+			setup: func(c *CPU, b *recordingBus) {
+				c.registers.SP = 0xF0
+				c.WriteByteAt(0x01F1, 0x00)
+				c.WriteWordAt(0x01F2, 0x0300)
+				c.WriteByteAt(0x0300, 0xEA)
+				b.irqAt = 1
+			},
+			want: []uint16{0x0300, 0x8001},
+		},
+		{
+			name:    "BRK: PCをプッシュする前に来たNMIにベクタを乗っ取られる",
+			program: []uint8{0x00, 0x00}, // BRK
+			setup:   func(c *CPU, b *recordingBus) { b.nmiAt = 3 },
+			want:    []uint16{0x9000, 0x9001},
+		},
+		{
+			name:    "BRK: ベクタを読み始めてから来たNMIは乗っ取らない",
+			program: []uint8{0x00, 0x00}, // BRK
+			setup:   func(c *CPU, b *recordingBus) { b.nmiAt = 6 },
+			want:    []uint16{0x8000, 0x9001},
+		},
+		{
+			name:    "IRQ: 割り込みの途中で来たNMIにベクタを乗っ取られる",
+			program: []uint8{0xEA, 0xEA}, // NOP; NOP
+			setup: func(c *CPU, b *recordingBus) {
+				c.registers.P.Interrupt = false
+				b.irqAt = 1
+				b.nmiAt = 4
+			},
+			want: []uint16{0x0201, 0x9001, 0x9002},
+		},
+		{
+			name:    "分岐: ページを跨がずに分岐すると2サイクル目のIRQは次の命令の後まで遅れる",
+			program: []uint8{0xD0, 0x00, 0xEA, 0xEA}, // BNE +0; NOP; NOP
+			setup: func(c *CPU, b *recordingBus) {
+				c.registers.P.Interrupt = false
+				b.irqAt = 2
+			},
+			want: []uint16{0x0202, 0x0203, 0x8001},
+		},
+		{
+			name:    "分岐: ページを跨がずに分岐すると2サイクル目のNMIは次の命令の後まで遅れる",
+			program: []uint8{0xD0, 0x00, 0xEA, 0xEA}, // BNE +0; NOP; NOP
+			setup:   func(c *CPU, b *recordingBus) { b.nmiAt = 2 },
+			want:    []uint16{0x0202, 0x0203, 0x9001},
+		},
+		{
+			name:    "分岐: 1サイクル目に来たIRQは分岐の直後に実行される",
+			program: []uint8{0xD0, 0x00, 0xEA}, // BNE +0; NOP
+			setup: func(c *CPU, b *recordingBus) {
+				c.registers.P.Interrupt = false
+				b.irqAt = 1
+			},
+			want: []uint16{0x0202, 0x8001},
+		},
+		{
+			name:    "分岐: ページを跨ぐ場合は遅れない",
+			origin:  0x02FD,
+			program: []uint8{0xD0, 0x01, 0xEA, 0xEA}, // BNE +1; NOP; NOP
+			setup: func(c *CPU, b *recordingBus) {
+				c.registers.P.Interrupt = false
+				b.irqAt = 2
+			},
+			want: []uint16{0x0300, 0x8001},
+		},
+		{
+			name:    "分岐: 分岐しなければ遅れない",
+			program: []uint8{0xF0, 0x10, 0xEA}, // BEQ +16; NOP
+			setup: func(c *CPU, b *recordingBus) {
+				c.registers.P.Interrupt = false
+				b.irqAt = 1
+			},
+			want: []uint16{0x0202, 0x8001},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu, b := setupRecordingCPU(nil)
+			if tt.origin != 0 {
+				cpu.registers.PC = tt.origin
+			}
+			copy(b.memory[cpu.registers.PC:], tt.program)
+			// IRQ・BRKのハンドラは $8000，NMIのハンドラは $9000 (どちらもNOPが並ぶ)
+			for i := uint16(0); i < 0x10; i++ {
+				b.memory[0x8000+i] = 0xEA
+				b.memory[0x9000+i] = 0xEA
+			}
+			b.memory[0xFFFA], b.memory[0xFFFB] = 0x00, 0x90
+			b.memory[0xFFFE], b.memory[0xFFFF] = 0x00, 0x80
+			tt.setup(cpu, b)
+
+			got := make([]uint16, 0, len(tt.want))
+			for range tt.want {
+				cpu.Step()
+				got = append(got, cpu.registers.PC)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PC after each step = %04X, want %04X", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package debugger
+
+import (
+	"Famicom-emulator/cartridge"
+	"Famicom-emulator/cartridge/mappers"
+	"errors"
+	"fmt"
+	"os"
+)
+
+/*
+	FCEUX互換のCDLファイル (プログラムROMのサイズ + キャラクタROMのサイズ)
+
+	プログラムROMの1バイト
+	7  bit  0
+	---- ----
+	xPdc BBDC
+	 ||| ||||
+	 ||| |||+- コードとして実行された
+	 ||| ||+-- データとして読まれた
+	 ||| ++--- アクセスされたときのCPUアドレスの窓 (($8000-$FFFF >> 13) & 3)
+	 ||+------ JMP (ind) の飛び先として実行された
+	 |+------- (zp),Y / (zp,X) で間接参照された
+	 +-------- DMCのサンプルとして読まれた
+
+	キャラクタROMの1バイト
+	bit 0: PPUが描画のために読んだ
+	bit 1: $2007経由で読まれた
+*/
+
+// MARK: 定数定義
+const (
+	CDL_CODE          uint8 = 0x01
+	CDL_DATA          uint8 = 0x02
+	CDL_BANK_MASK     uint8 = 0x0C
+	CDL_INDIRECT_CODE uint8 = 0x10
+	CDL_INDIRECT_DATA uint8 = 0x20
+	CDL_PCM           uint8 = 0x40
+
+	CDL_RENDERED uint8 = 0x01
+	CDL_READ     uint8 = 0x02
+
+	CDL_EXT = ".cdl"
+
+	OPCODE_JMP_INDIRECT uint8 = 0x6C
+)
+
+// MARK: CodeDataLogger の定義
+type CodeDataLogger struct {
+	mapper    mappers.Mapper
+	program   []uint8 // プログラムROMの各バイトのフラグ
+	character []uint8 // キャラクタROMの各バイトのフラグ (キャラクタRAMならnil)
+
+	codeStart    uint16 // 実行中の命令の先頭アドレス
+	codeEnd      uint16 // 実行中の命令の次のアドレス
+	indirectData bool   // 実行中の命令が間接参照かどうか
+	lastOpcode   uint8  // 直前に実行した命令
+}
+
+// MARK: CodeDataLogger の初期化メソッド
+func (l *CodeDataLogger) Init(mapper mappers.Mapper) {
+	l.mapper = mapper
+	l.program = make([]uint8, len(mapper.ProgramRom()))
+	l.character = nil
+	if !mapper.IsCharacterRam() {
+		l.character = make([]uint8, len(mapper.CharacterRom()))
+	}
+	l.codeStart = 0
+	l.codeEnd = 0
+	l.indirectData = false
+	l.lastOpcode = 0x00
+}
+
+// MARK: CPUアドレスの窓のフラグの取得
+func bankFlag(address uint16) uint8 {
+	return uint8((address>>13)&0b11) << 2
+}
+
+// MARK: プログラムROMのフラグの設定
+func (l *CodeDataLogger) markProgram(address uint16, flags uint8) {
+	offset := l.mapper.ProgramRomOffset(address)
+	if offset < uint(len(l.program)) {
+		l.program[offset] |= flags | bankFlag(address)
+	}
+}
+
+// MARK: 命令のフェッチの記録 (命令を実行する前に呼ばれる)
+func (l *CodeDataLogger) LogCode(address uint16, opcode uint8, length uint8, indirectData bool) {
+	l.codeStart = address
+	l.codeEnd = address + uint16(length)
+	l.indirectData = indirectData
+
+	if address < mappers.PRG_ROM_START {
+		l.lastOpcode = opcode
+		return
+	}
+	for i := range uint16(length) {
+		flags := CDL_CODE
+		if i == 0 && l.lastOpcode == OPCODE_JMP_INDIRECT {
+			flags |= CDL_INDIRECT_CODE
+		}
+		l.markProgram(address+i, flags)
+	}
+	l.lastOpcode = opcode
+}
+
+// MARK: CPUの読み取りの記録 (実行中の命令自身のバイトは除く)
+func (l *CodeDataLogger) LogData(address uint16) {
+	if address < mappers.PRG_ROM_START || (l.codeStart <= address && address < l.codeEnd) {
+		return
+	}
+	flags := CDL_DATA
+	if l.indirectData {
+		flags |= CDL_INDIRECT_DATA
+	}
+	l.markProgram(address, flags)
+}
+
+// MARK: DMCのサンプルの読み取りの記録
+func (l *CodeDataLogger) LogSample(address uint16) {
+	if address >= mappers.PRG_ROM_START {
+		l.markProgram(address, CDL_DATA|CDL_PCM)
+	}
+}
+
+// MARK: PPUの描画のための読み取りの記録
+func (l *CodeDataLogger) LogRendered(address uint16) {
+	l.markCharacter(address, CDL_RENDERED)
+}
+
+// MARK: $2007経由のキャラクタROMの読み取りの記録
+func (l *CodeDataLogger) LogCharacterRead(address uint16) {
+	l.markCharacter(address, CDL_READ)
+}
+
+// MARK: キャラクタROMのフラグの設定
+func (l *CodeDataLogger) markCharacter(address uint16, flags uint8) {
+	if l.character == nil {
+		return
+	}
+	offset := l.mapper.CharacterRomOffset(address)
+	if offset < uint(len(l.character)) {
+		l.character[offset] |= flags
+	}
+}
+
+// MARK: プログラムROMのフラグの取得
+func (l *CodeDataLogger) Program() []uint8 {
+	return l.program
+}
+
+// MARK: キャラクタROMのフラグの取得
+func (l *CodeDataLogger) Character() []uint8 {
+	return l.character
+}
+
+// MARK: CDLファイルの読み込み (前回までの記録に追記する)
+func (l *CodeDataLogger) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) != len(l.program)+len(l.character) {
+		return fmt.Errorf("%s does not match the ROM size", path)
+	}
+	copy(l.program, data)
+	copy(l.character, data[len(l.program):])
+	return nil
+}
+
+// MARK: CDLファイルの書き出し
+func (l *CodeDataLogger) Save(path string) error {
+	data := make([]uint8, 0, len(l.program)+len(l.character))
+	data = append(data, l.program...)
+	data = append(data, l.character...)
+
+	return cartridge.WriteFileAtomic(path, data)
+}
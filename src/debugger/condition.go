@@ -0,0 +1,193 @@
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+	条件式の文法
+
+	or      := and ( "||" and )*
+	and     := compare ( "&&" compare )*
+	compare := operand ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) operand
+	operand := A | X | Y | P | SP | PC | SCANLINE | DOT | CYCLES
+	         | 数値 ($FF, 0xFF, %1010, 255)
+	         | "[" 数値 "]" (CPUメモリの値)
+
+	例: "A == $10 && [$0300] != 0"
+*/
+
+// MARK: 条件式の定義
+type Condition struct {
+	source string
+	or     [][]comparison // ORで結合されたANDの並び
+}
+
+// MARK: 比較の定義
+type comparison struct {
+	left  operand
+	op    string
+	right operand
+}
+
+// MARK: オペランドの定義
+type operand struct {
+	register string // レジスタ名 (空なら値またはメモリ)
+	value    uint
+	memory   bool // valueをアドレスとしてメモリを読む
+}
+
+// MARK: 条件式のパース
+func ParseCondition(source string) (*Condition, error) {
+	c := &Condition{source: strings.TrimSpace(source)}
+	for _, orTerm := range strings.Split(c.source, "||") {
+		var and []comparison
+		for _, andTerm := range strings.Split(orTerm, "&&") {
+			cmp, err := parseComparison(andTerm)
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, cmp)
+		}
+		c.or = append(c.or, and)
+	}
+	return c, nil
+}
+
+// MARK: 比較のパース
+func parseComparison(source string) (comparison, error) {
+	// 2文字の演算子を先に探す
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		left, right, found := strings.Cut(source, op)
+		if !found {
+			continue
+		}
+		l, err := parseOperand(left)
+		if err != nil {
+			return comparison{}, err
+		}
+		r, err := parseOperand(right)
+		if err != nil {
+			return comparison{}, err
+		}
+		return comparison{left: l, op: op, right: r}, nil
+	}
+	return comparison{}, fmt.Errorf("missing comparison operator in '%s'", strings.TrimSpace(source))
+}
+
+// MARK: オペランドのパース
+func parseOperand(source string) (operand, error) {
+	source = strings.ToUpper(strings.TrimSpace(source))
+	switch source {
+	case "A", "X", "Y", "P", "SP", "PC", "SCANLINE", "DOT", "CYCLES":
+		return operand{register: source}, nil
+	}
+
+	if strings.HasPrefix(source, "[") && strings.HasSuffix(source, "]") {
+		address, err := ParseNumber(source[1 : len(source)-1])
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{value: address, memory: true}, nil
+	}
+
+	value, err := ParseNumber(source)
+	if err != nil {
+		return operand{}, err
+	}
+	return operand{value: value}, nil
+}
+
+// MARK: 数値のパース ($FF, 0xFF, %1010, 255)
+func ParseNumber(source string) (uint, error) {
+	source = strings.TrimSpace(source)
+	var value uint64
+	var err error
+	switch {
+	case strings.HasPrefix(source, "$"):
+		value, err = strconv.ParseUint(source[1:], 16, 32)
+	case strings.HasPrefix(source, "0x"), strings.HasPrefix(source, "0X"):
+		value, err = strconv.ParseUint(source[2:], 16, 32)
+	case strings.HasPrefix(source, "%"):
+		value, err = strconv.ParseUint(source[1:], 2, 32)
+	default:
+		value, err = strconv.ParseUint(source, 10, 32)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s'", source)
+	}
+	return uint(value), nil
+}
+
+// MARK: 条件式の評価
+func (c *Condition) Eval(state CPUState, peek func(uint16) uint8) bool {
+	for _, and := range c.or {
+		matched := true
+		for _, cmp := range and {
+			if !cmp.eval(state, peek) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// MARK: 条件式の文字列の取得
+func (c *Condition) String() string {
+	return c.source
+}
+
+// MARK: 比較の評価
+func (cmp comparison) eval(state CPUState, peek func(uint16) uint8) bool {
+	l := cmp.left.eval(state, peek)
+	r := cmp.right.eval(state, peek)
+	switch cmp.op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	default:
+		return l >= r
+	}
+}
+
+// MARK: オペランドの評価
+func (o operand) eval(state CPUState, peek func(uint16) uint8) uint {
+	if o.memory {
+		return uint(peek(uint16(o.value)))
+	}
+	switch o.register {
+	case "A":
+		return uint(state.A)
+	case "X":
+		return uint(state.X)
+	case "Y":
+		return uint(state.Y)
+	case "P":
+		return uint(state.P)
+	case "SP":
+		return uint(state.SP)
+	case "PC":
+		return uint(state.PC)
+	case "SCANLINE":
+		return uint(state.Scanline)
+	case "DOT":
+		return state.Dot
+	case "CYCLES":
+		return state.Cycles
+	default:
+		return o.value
+	}
+}
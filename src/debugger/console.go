@@ -0,0 +1,267 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// コンソールのヘルプ
+const CONSOLE_HELP = `commands:
+  b <addr> [if <cond>]        add breakpoint (e.g. "b $C000 if A == $10", "b reset")
+  w <addr>[-<end>] [r|w|rw]   add CPU watchpoint (default rw)
+  pw <addr>[-<end>] [r|w|rw]  add PPU watchpoint ($2006/$2007 access)
+  on nmi|irq|brk / off ...    break on interrupt
+  d <id>                      delete breakpoint / watchpoint
+  l                           list breakpoints / watchpoints
+  p                           pause
+  c                           continue
+  s                           step into
+  n                           step over (JSR)
+  f                           step out (RTS/RTI)
+  sl <line>                   run to scanline
+  r                           show registers
+  x <addr> [len]              dump CPU memory
+  sym <name|addr>             look up a symbol`
+
+// MARK: 標準入力などからコマンドを1行ずつ読み取る (メインループでコマンドを実行する)
+func ReadCommands(r io.Reader) <-chan string {
+	commands := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			commands <- scanner.Text()
+		}
+		close(commands)
+	}()
+	return commands
+}
+
+// MARK: コンソールコマンドの実行
+func (d *Debugger) Execute(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	args := fields[1:]
+
+	switch strings.ToLower(fields[0]) {
+	case "b", "break":
+		return d.executeBreak(line, args)
+	case "w", "watch":
+		return d.executeWatch(SPACE_CPU, args)
+	case "pw", "ppuwatch":
+		return d.executeWatch(SPACE_PPU, args)
+	case "on", "off":
+		return d.executeInterrupt(strings.ToLower(fields[0]) == "on", args)
+	case "d", "delete":
+		if len(args) != 1 {
+			return "usage: d <id>"
+		}
+		id, err := ParseNumber(args[0])
+		if err != nil || !d.Delete(int(id)) {
+			return fmt.Sprintf("no breakpoint/watchpoint #%s", args[0])
+		}
+		return fmt.Sprintf("deleted #%d", id)
+	case "l", "list":
+		return d.list()
+	case "p", "pause":
+		d.Pause()
+		return ""
+	case "c", "continue":
+		d.Continue()
+		return "continue"
+	case "s", "step":
+		d.StepInto()
+		return ""
+	case "n", "next":
+		d.StepOver()
+		return ""
+	case "f", "finish":
+		d.StepOut()
+		return ""
+	case "sl", "scanline":
+		if len(args) != 1 {
+			return "usage: sl <line>"
+		}
+		scanline, err := ParseNumber(args[0])
+		if err != nil {
+			return err.Error()
+		}
+		d.RunToScanline(uint16(scanline))
+		return ""
+	case "r", "regs":
+		return d.registers()
+	case "x", "dump":
+		return d.dump(args)
+	case "sym", "symbol":
+		if len(args) != 1 {
+			return "usage: sym <name|addr>"
+		}
+		address, err := d.parseAddress(args[0])
+		if err != nil {
+			return err.Error()
+		}
+		return d.describe(address)
+	case "h", "help", "?":
+		return CONSOLE_HELP
+	default:
+		return fmt.Sprintf("unknown command '%s' (h for help)", fields[0])
+	}
+}
+
+// MARK: ブレークポイントの追加コマンド
+func (d *Debugger) executeBreak(line string, args []string) string {
+	if len(args) == 0 {
+		return "usage: b <addr> [if <cond>]"
+	}
+	address, err := d.parseAddress(args[0])
+	if err != nil {
+		return err.Error()
+	}
+
+	var condition *Condition
+	if _, source, found := strings.Cut(line, " if "); found {
+		if condition, err = ParseCondition(source); err != nil {
+			return err.Error()
+		}
+	}
+
+	bp := d.AddBreakpoint(address, condition)
+	if condition != nil {
+		return fmt.Sprintf("breakpoint #%d at %s if %s", bp.ID, d.describe(bp.Address), condition)
+	}
+	return fmt.Sprintf("breakpoint #%d at %s", bp.ID, d.describe(bp.Address))
+}
+
+// MARK: アドレスかシンボル名の解析
+func (d *Debugger) parseAddress(text string) (uint16, error) {
+	return parseAddress(d.symbols, text)
+}
+
+// MARK: アドレスかシンボル名の解析 (シンボルテーブルはnilでもよい)
+func parseAddress(symbols *SymbolTable, text string) (uint16, error) {
+	if address, ok := symbols.Address(text); ok {
+		return address, nil
+	}
+	value, err := ParseNumber(text)
+	if err != nil {
+		return 0, fmt.Errorf("unknown address or symbol '%s'", text)
+	}
+	return uint16(value), nil
+}
+
+// MARK: ウォッチポイントの追加コマンド
+func (d *Debugger) executeWatch(space AddressSpace, args []string) string {
+	if len(args) == 0 {
+		return "usage: w <addr>[-<end>] [r|w|rw]"
+	}
+
+	startText, endText, isRange := strings.Cut(args[0], "-")
+	start, err := ParseNumber(startText)
+	if err != nil {
+		return err.Error()
+	}
+	end := start
+	if isRange {
+		if end, err = ParseNumber(endText); err != nil {
+			return err.Error()
+		}
+	}
+
+	kind := WATCH_ACCESS
+	if len(args) > 1 {
+		switch strings.ToLower(args[1]) {
+		case "r":
+			kind = WATCH_READ
+		case "w":
+			kind = WATCH_WRITE
+		case "rw":
+			kind = WATCH_ACCESS
+		default:
+			return fmt.Sprintf("unknown access '%s'", args[1])
+		}
+	}
+
+	return "watchpoint " + d.AddWatchpoint(space, uint16(start), uint16(end), kind).String()
+}
+
+// MARK: 割り込みでの停止の切り替えコマンド
+func (d *Debugger) executeInterrupt(enabled bool, args []string) string {
+	if len(args) != 1 {
+		return "usage: on|off nmi|irq|brk"
+	}
+	var interrupt Interrupt
+	switch strings.ToLower(args[0]) {
+	case "nmi":
+		interrupt = INTERRUPT_NMI
+	case "irq":
+		interrupt = INTERRUPT_IRQ
+	case "brk":
+		interrupt = INTERRUPT_BRK
+	default:
+		return fmt.Sprintf("unknown interrupt '%s'", args[0])
+	}
+	d.SetBreakOnInterrupt(interrupt, enabled)
+	return fmt.Sprintf("break on %s: %v", interrupt, enabled)
+}
+
+// MARK: ブレークポイント・ウォッチポイントの一覧
+func (d *Debugger) list() string {
+	var sb strings.Builder
+	for _, bp := range d.breakpoints {
+		fmt.Fprintf(&sb, "#%d break %s", bp.ID, d.describe(bp.Address))
+		if bp.Condition != nil {
+			fmt.Fprintf(&sb, " if %s", bp.Condition)
+		}
+		fmt.Fprintf(&sb, " (hits %d)\n", bp.Hits)
+	}
+	for _, wp := range d.watchpoints {
+		fmt.Fprintf(&sb, "%s\n", wp)
+	}
+	for i := INTERRUPT_NMI; i <= INTERRUPT_BRK; i++ {
+		if d.breakOn[i] {
+			fmt.Fprintf(&sb, "break on %s\n", i)
+		}
+	}
+	if sb.Len() == 0 {
+		return "no breakpoints"
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// MARK: レジスタの表示
+func (d *Debugger) registers() string {
+	s := d.state
+	return fmt.Sprintf("PC:%04X A:%02X X:%02X Y:%02X P:%02X SP:%02X SL:%d DOT:%d CYC:%d",
+		s.PC, s.A, s.X, s.Y, s.P, s.SP, s.Scanline, s.Dot, s.Cycles)
+}
+
+// MARK: メモリダンプ
+func (d *Debugger) dump(args []string) string {
+	if len(args) == 0 {
+		return "usage: x <addr> [len]"
+	}
+	start, err := d.parseAddress(args[0])
+	if err != nil {
+		return err.Error()
+	}
+	address := uint(start)
+	length := uint(0x40)
+	if len(args) > 1 {
+		if length, err = ParseNumber(args[1]); err != nil {
+			return err.Error()
+		}
+	}
+
+	var sb strings.Builder
+	for row := uint(0); row < length; row += 16 {
+		fmt.Fprintf(&sb, "%04X:", uint16(address+row))
+		for col := uint(0); col < 16 && row+col < length; col++ {
+			fmt.Fprintf(&sb, " %02X", d.peek(uint16(address+row+col)))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,325 @@
+package debugger
+
+import "fmt"
+
+// MARK: 定数定義
+const (
+	OPCODE_BRK uint8 = 0x00
+	OPCODE_JSR uint8 = 0x20
+	OPCODE_RTI uint8 = 0x40
+	OPCODE_RTS uint8 = 0x60
+
+	JSR_BYTES = 3 // JSR命令のバイト数 (ステップオーバーの戻り先)
+)
+
+// MARK: ステップ実行のモード
+type StepMode uint8
+
+const (
+	STEP_NONE     StepMode = iota // 通常実行
+	STEP_INTO                     // 1命令だけ実行
+	STEP_OVER                     // JSRはサブルーチンから戻るまで実行
+	STEP_OUT                      // RTS/RTIで現在のサブルーチンを抜けるまで実行
+	STEP_SCANLINE                 // 指定したスキャンラインに到達するまで実行
+)
+
+// MARK: 割り込みの種類
+type Interrupt uint8
+
+const (
+	INTERRUPT_NMI Interrupt = iota
+	INTERRUPT_IRQ
+	INTERRUPT_BRK
+)
+
+// MARK: 割り込み名の取得
+func (i Interrupt) String() string {
+	switch i {
+	case INTERRUPT_NMI:
+		return "NMI"
+	case INTERRUPT_IRQ:
+		return "IRQ"
+	default:
+		return "BRK"
+	}
+}
+
+// MARK: 命令実行前のCPUの状態
+type CPUState struct {
+	PC       uint16
+	A        uint8
+	X        uint8
+	Y        uint8
+	P        uint8
+	SP       uint8
+	Opcode   uint8  // PCが指している命令
+	Scanline uint16 // PPUのスキャンライン
+	Dot      uint   // PPUのドット
+	Cycles   uint   // CPUサイクル
+}
+
+// MARK: ブレークポイントの定義
+type Breakpoint struct {
+	ID        int
+	Address   uint16
+	Condition *Condition // nilなら無条件
+	Enabled   bool
+	Hits      uint
+}
+
+// MARK: Debuggerの定義
+type Debugger struct {
+	breakpoints []*Breakpoint
+	watchpoints []*Watchpoint
+	nextID      int
+
+	breakOn [3]bool // 割り込みでの停止 (Interruptの並び)
+
+	paused  bool
+	reason  string // 停止した理由
+	resumed bool   // 再開直後の1命令はPCブレークポイントで止めない
+
+	mode           StepMode
+	stepSP         uint8  // ステップオーバー/アウトの基準となるSP
+	stepReturn     uint16 // ステップオーバーでのJSRの戻り先
+	targetScanline uint16 // 実行を止めるスキャンライン
+	lastScanline   uint16
+	lastOpcode     uint8
+
+	state   CPUState           // 最後に観測したCPUの状態
+	peek    func(uint16) uint8 // 副作用のないメモリ読み取り
+	out     func(format string, args ...any)
+	symbols *SymbolTable // ラベルの表示 (無ければnil)
+}
+
+// MARK: Debuggerの初期化メソッド
+func (d *Debugger) Init() {
+	d.breakpoints = nil
+	d.watchpoints = nil
+	d.nextID = 1
+	d.breakOn = [3]bool{}
+	d.paused = false
+	d.reason = ""
+	d.resumed = false
+	d.mode = STEP_NONE
+	d.peek = func(uint16) uint8 { return 0x00 }
+	d.out = func(format string, args ...any) {
+		fmt.Printf("[Debugger] "+format+"\n", args...)
+	}
+}
+
+// MARK: 副作用のないメモリ読み取りの設定
+func (d *Debugger) SetMemoryReader(peek func(uint16) uint8) {
+	d.peek = peek
+}
+
+// MARK: シンボルテーブルの設定 (nilで解除)
+func (d *Debugger) SetSymbols(s *SymbolTable) {
+	d.symbols = s
+}
+
+// MARK: シンボルテーブルの取得
+func (d *Debugger) Symbols() *SymbolTable {
+	return d.symbols
+}
+
+// MARK: アドレスの表示用の文字列 (ラベルがあれば併記)
+func (d *Debugger) describe(address uint16) string {
+	if name, ok := d.symbols.Lookup(address); ok {
+		return fmt.Sprintf("$%04X <%s>", address, name)
+	}
+	return fmt.Sprintf("$%04X", address)
+}
+
+// MARK: 命令実行前のフック (停止中ならtrueを返し，命令は実行しない)
+func (d *Debugger) BeforeInstruction(state CPUState) bool {
+	d.state = state
+	if d.paused {
+		return true
+	}
+
+	resumed := d.resumed
+	d.resumed = false
+	lastOpcode := d.lastOpcode
+	lastScanline := d.lastScanline
+	d.lastOpcode = state.Opcode
+	d.lastScanline = state.Scanline
+
+	// ステップ実行の判定
+	switch d.mode {
+	case STEP_INTO:
+		if !resumed {
+			d.Break("step")
+			return true
+		}
+	case STEP_OVER:
+		if !resumed && state.PC == d.stepReturn && state.SP >= d.stepSP {
+			d.Break("step over")
+			return true
+		}
+	case STEP_OUT:
+		if !resumed && (lastOpcode == OPCODE_RTS || lastOpcode == OPCODE_RTI) && state.SP > d.stepSP {
+			d.Break("step out")
+			return true
+		}
+	case STEP_SCANLINE:
+		if state.Scanline == d.targetScanline && lastScanline != d.targetScanline {
+			d.Break(fmt.Sprintf("scanline %d", state.Scanline))
+			return true
+		}
+	}
+
+	if resumed {
+		return false
+	}
+
+	// PCブレークポイントの判定
+	for _, bp := range d.breakpoints {
+		if !bp.Enabled || bp.Address != state.PC {
+			continue
+		}
+		if bp.Condition != nil && !bp.Condition.Eval(state, d.peek) {
+			continue
+		}
+		bp.Hits++
+		d.Break(fmt.Sprintf("breakpoint #%d", bp.ID))
+		return true
+	}
+
+	// BRK命令の判定
+	if state.Opcode == OPCODE_BRK && d.breakOn[INTERRUPT_BRK] {
+		d.Break("BRK")
+		return true
+	}
+
+	return false
+}
+
+// MARK: 割り込み発生時のフック
+func (d *Debugger) Interrupt(interrupt Interrupt) {
+	if d.breakOn[interrupt] {
+		d.Break(interrupt.String())
+	}
+}
+
+// MARK: 実行の停止
+func (d *Debugger) Break(reason string) {
+	d.paused = true
+	d.reason = reason
+	d.mode = STEP_NONE
+	d.out("break (%s) at %s", reason, d.describe(d.state.PC))
+}
+
+// MARK: 実行の一時停止 (ホットキー)
+func (d *Debugger) Pause() {
+	if !d.paused {
+		d.Break("pause")
+	}
+}
+
+// MARK: 実行の再開
+func (d *Debugger) Continue() {
+	d.resume(STEP_NONE)
+}
+
+// MARK: ステップイン (1命令実行)
+func (d *Debugger) StepInto() {
+	d.resume(STEP_INTO)
+}
+
+// MARK: ステップオーバー (JSRはサブルーチンを実行し終えるまで)
+func (d *Debugger) StepOver() {
+	if d.state.Opcode != OPCODE_JSR {
+		d.resume(STEP_INTO)
+		return
+	}
+	d.stepReturn = d.state.PC + JSR_BYTES
+	d.stepSP = d.state.SP
+	d.resume(STEP_OVER)
+}
+
+// MARK: ステップアウト (現在のサブルーチンからRTS/RTIで戻るまで)
+func (d *Debugger) StepOut() {
+	d.stepSP = d.state.SP
+	d.resume(STEP_OUT)
+}
+
+// MARK: 指定したスキャンラインまで実行
+func (d *Debugger) RunToScanline(scanline uint16) {
+	d.targetScanline = scanline
+	d.lastScanline = d.state.Scanline
+	d.resume(STEP_SCANLINE)
+}
+
+// MARK: 実行再開の共通処理
+func (d *Debugger) resume(mode StepMode) {
+	d.paused = false
+	d.reason = ""
+	d.resumed = true
+	d.mode = mode
+}
+
+// MARK: 停止中かどうか
+func (d *Debugger) Paused() bool {
+	return d.paused
+}
+
+// MARK: 停止した理由の取得
+func (d *Debugger) Reason() string {
+	return d.reason
+}
+
+// MARK: 最後に観測したCPUの状態の取得
+func (d *Debugger) State() CPUState {
+	return d.state
+}
+
+// MARK: 副作用のないメモリ読み取り
+func (d *Debugger) Peek(address uint16) uint8 {
+	return d.peek(address)
+}
+
+// MARK: ブレークポイントの追加
+func (d *Debugger) AddBreakpoint(address uint16, condition *Condition) *Breakpoint {
+	bp := &Breakpoint{
+		ID:        d.nextID,
+		Address:   address,
+		Condition: condition,
+		Enabled:   true,
+	}
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp
+}
+
+// MARK: ブレークポイントの一覧の取得
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	return d.breakpoints
+}
+
+// MARK: 割り込みでの停止の切り替え
+func (d *Debugger) SetBreakOnInterrupt(interrupt Interrupt, enabled bool) {
+	d.breakOn[interrupt] = enabled
+}
+
+// MARK: 割り込みで停止するかどうか
+func (d *Debugger) BreakOnInterrupt(interrupt Interrupt) bool {
+	return d.breakOn[interrupt]
+}
+
+// MARK: ブレークポイント・ウォッチポイントの削除
+func (d *Debugger) Delete(id int) bool {
+	for i, bp := range d.breakpoints {
+		if bp.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	for i, wp := range d.watchpoints {
+		if wp.ID == id {
+			d.watchpoints = append(d.watchpoints[:i], d.watchpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,333 @@
+package debugger
+
+import (
+	"Famicom-emulator/cartridge/mappers"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MARK: 定数定義
+const (
+	NL_BANK_SIZE     = 16 * 1024 // FCEUXの .nl ファイルのバンクサイズ
+	INES_HEADER_SIZE = 16        // .dbg のファイル内の位置からプログラムROMのオフセットを求めるときに引く
+	PRG_RAM_BASE     = 0x6000
+	SYMBOL_NOT_FOUND = ""
+)
+
+/*
+	シンボルの種類
+
+	- CPUのアドレスに対応するもの (RAM, I/Oレジスタ, プログラムRAM)
+	- プログラムROMのオフセットに対応するもの (マップされているバンクのときだけ有効)
+
+	Mesen の .mlb のようにCPUのアドレスを持たないROMのシンボルは，名前から引くときに
+	現在のバンクの割り当てでオフセットからアドレスを求める
+*/
+
+// MARK: SymbolTable の定義
+type SymbolTable struct {
+	mapper  mappers.Mapper
+	cpu     map[uint16]string // CPUのアドレスのシンボル
+	program map[uint]string   // プログラムROMのオフセットのシンボル
+	names   map[string]uint16 // 名前からCPUのアドレスへの逆引き (ROMは最後に見つかった位置)
+	offsets map[string]uint   // 名前からプログラムROMのオフセットへの逆引き (CPUのアドレスが不明なもの)
+}
+
+// MARK: SymbolTable の初期化メソッド
+func (s *SymbolTable) Init(mapper mappers.Mapper) {
+	s.mapper = mapper
+	s.cpu = make(map[uint16]string)
+	s.program = make(map[uint]string)
+	s.names = make(map[string]uint16)
+	s.offsets = make(map[string]uint)
+}
+
+// MARK: シンボルの数の取得
+func (s *SymbolTable) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.cpu) + len(s.program)
+}
+
+// MARK: アドレスのシンボルの検索 (ROMは現在マップされているバンクのシンボルのみ)
+func (s *SymbolTable) Lookup(address uint16) (string, bool) {
+	if s == nil {
+		return SYMBOL_NOT_FOUND, false
+	}
+	if address >= mappers.PRG_ROM_START {
+		if len(s.program) == 0 {
+			return SYMBOL_NOT_FOUND, false
+		}
+		name, ok := s.program[s.mapper.ProgramRomOffset(address)]
+		return name, ok
+	}
+	name, ok := s.cpu[address]
+	return name, ok
+}
+
+// MARK: 名前からアドレスの検索 (オフセットしか無いROMのシンボルはマップされているときだけ見つかる)
+func (s *SymbolTable) Address(name string) (uint16, bool) {
+	if s == nil {
+		return 0, false
+	}
+	if address, ok := s.names[name]; ok {
+		return address, true
+	}
+	if offset, ok := s.offsets[name]; ok {
+		return s.programAddress(offset)
+	}
+	return 0, false
+}
+
+// MARK: プログラムROMのオフセットが現在マップされているCPUのアドレスの検索
+func (s *SymbolTable) programAddress(offset uint) (uint16, bool) {
+	for address := uint32(mappers.PRG_ROM_START); address <= 0xFFFF; address++ {
+		if s.mapper.ProgramRomOffset(uint16(address)) == offset {
+			return uint16(address), true
+		}
+	}
+	return 0, false
+}
+
+// MARK: CPUのアドレスのシンボルの追加
+func (s *SymbolTable) addCPU(address uint16, name string) {
+	if name == "" {
+		return
+	}
+	s.cpu[address] = name
+	s.names[name] = address
+	delete(s.offsets, name)
+}
+
+// MARK: プログラムROMのオフセットのシンボルの追加 (address はCPUから見えるアドレス)
+func (s *SymbolTable) addProgram(offset uint, address uint16, name string) {
+	if name == "" {
+		return
+	}
+	s.program[offset] = name
+	s.names[name] = address
+	delete(s.offsets, name)
+}
+
+// MARK: CPUのアドレスが不明なプログラムROMのオフセットのシンボルの追加
+func (s *SymbolTable) addProgramOffset(offset uint, name string) {
+	if name == "" {
+		return
+	}
+	s.program[offset] = name
+	s.offsets[name] = offset
+	delete(s.names, name)
+}
+
+// MARK: ROMと同じ場所にあるシンボルファイルをすべて読み込む
+func (s *SymbolTable) LoadForROM(rom string, programOffset int) ([]string, error) {
+	base := strings.TrimSuffix(rom, filepath.Ext(rom))
+	candidates := []string{base + ".dbg", base + ".mlb", rom + ".ram.nl"}
+	banks, _ := filepath.Glob(rom + ".*.nl")
+	for _, path := range banks {
+		if path != rom+".ram.nl" {
+			candidates = append(candidates, path)
+		}
+	}
+
+	var loaded []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := s.LoadFile(path, programOffset); err != nil {
+			return loaded, err
+		}
+		loaded = append(loaded, path)
+	}
+	return loaded, nil
+}
+
+// MARK: 拡張子に応じたシンボルファイルの読み込み
+func (s *SymbolTable) LoadFile(path string, programOffset int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dbg":
+		err = s.loadDbg(scanner, programOffset)
+	case ".mlb":
+		err = s.loadMlb(scanner)
+	case ".nl":
+		err = s.loadNl(scanner, path)
+	default:
+		return fmt.Errorf("unknown symbol file %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return scanner.Err()
+}
+
+/*
+	ca65/ld65 の .dbg ファイル (ld65 --dbgfile)
+
+	seg  id=0,name="CODE",start=0x008000,size=0x0123,addrsize=absolute,type=ro,oname="game.nes",ooffs=16
+	sym  id=0,name="reset",addrsize=absolute,scope=0,def=1,val=0x8000,seg=0,type=lab
+
+	ROMに出力されるセグメント (ooffsあり) のラベルはファイル内の位置からプログラムROMのオフセットを求める
+*/
+// MARK: .dbg ファイルの読み込み
+func (s *SymbolTable) loadDbg(scanner *bufio.Scanner, programOffset int) error {
+	type segment struct {
+		start  uint64
+		offset int64 // 出力ファイル内の位置 (ROMに出力されなければ-1)
+	}
+	segments := make(map[string]segment)
+	var symbols []map[string]string
+
+	for scanner.Scan() {
+		kind, rest, found := strings.Cut(strings.TrimSpace(scanner.Text()), "\t")
+		if !found {
+			continue
+		}
+		fields := dbgFields(rest)
+		switch kind {
+		case "seg":
+			start, _ := strconv.ParseUint(fields["start"], 0, 32)
+			offset := int64(-1)
+			if ooffs, ok := fields["ooffs"]; ok {
+				offset, _ = strconv.ParseInt(ooffs, 0, 64)
+			}
+			segments[fields["id"]] = segment{start: start, offset: offset}
+		case "sym":
+			symbols = append(symbols, fields)
+		}
+	}
+
+	// セグメントがシンボルの後に出てくることもあるので最後にまとめて解決する
+	for _, fields := range symbols {
+		if fields["type"] != "lab" {
+			continue
+		}
+		value, err := strconv.ParseUint(fields["val"], 0, 32)
+		if err != nil {
+			continue
+		}
+		address := uint16(value)
+		seg, ok := segments[fields["seg"]]
+		if ok && seg.offset >= 0 && address >= mappers.PRG_ROM_START {
+			offset := seg.offset + int64(value-seg.start) - int64(programOffset)
+			if offset >= 0 {
+				s.addProgram(uint(offset), address, fields["name"])
+			}
+			continue
+		}
+		s.addCPU(address, fields["name"])
+	}
+	return nil
+}
+
+// MARK: .dbg ファイルの key=value の並びの分解
+func dbgFields(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range strings.Split(text, ",") {
+		key, value, found := strings.Cut(field, "=")
+		if found {
+			fields[key] = strings.Trim(value, "\"")
+		}
+	}
+	return fields
+}
+
+/*
+	Mesen の .mlb ファイル
+
+	P:1F00:reset:コメント    (プログラムROMのオフセット)
+	R:0010:player_x         (内部RAM)
+	S:0000:save_data        (バッテリーバックアップのRAM, $6000~)
+	W:0000:work             (プログラムRAM, $6000~)
+	G:2000:PPUCTRL          (レジスタ)
+
+	Mesen 2 では NesPrgRom / NesInternalRam / NesSaveRam / NesWorkRam / NesMemory となる
+*/
+// MARK: .mlb ファイルの読み込み
+func (s *SymbolTable) loadMlb(scanner *bufio.Scanner) error {
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 4)
+		if len(parts) < 3 || parts[2] == "" {
+			continue
+		}
+		// 範囲指定 (1F00-1F0F) は先頭のアドレスを使う
+		start, _, _ := strings.Cut(parts[1], "-")
+		value, err := strconv.ParseUint(start, 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid address '%s'", parts[1])
+		}
+		name := parts[2]
+
+		switch parts[0] {
+		case "P", "NesPrgRom":
+			// どのアドレスに割り当てられるかはマッパー次第なので，オフセットだけを覚えておく
+			s.addProgramOffset(uint(value), name)
+		case "R", "NesInternalRam", "G", "NesMemory":
+			s.addCPU(uint16(value), name)
+		case "S", "NesSaveRam", "W", "NesWorkRam":
+			s.addCPU(PRG_RAM_BASE+uint16(value), name)
+		}
+	}
+	return nil
+}
+
+/*
+	FCEUX の .nl ファイル
+
+	game.nes.ram.nl : RAMなどCPUのアドレスのシンボル
+	game.nes.0.nl   : 16KBのバンク0 (バンク番号は16進数)
+
+	$C000#reset#コメント
+	$0300/10#buffer#     (/の後ろはサイズ)
+*/
+// MARK: .nl ファイルの読み込み
+func (s *SymbolTable) loadNl(scanner *bufio.Scanner, path string) error {
+	// ファイル名からバンク番号を取得 (ram ならバンク無し)
+	bankText := filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path)))
+	bankText = strings.TrimPrefix(bankText, ".")
+	bank := int64(-1)
+	if strings.ToLower(bankText) != "ram" {
+		var err error
+		if bank, err = strconv.ParseInt(bankText, 16, 32); err != nil {
+			return fmt.Errorf("invalid bank '%s'", bankText)
+		}
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "$") {
+			continue
+		}
+		parts := strings.SplitN(line[1:], "#", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		addressText, _, _ := strings.Cut(parts[0], "/")
+		value, err := strconv.ParseUint(addressText, 16, 16)
+		if err != nil {
+			return fmt.Errorf("invalid address '%s'", parts[0])
+		}
+		address := uint16(value)
+
+		if bank < 0 || address < mappers.PRG_ROM_START {
+			s.addCPU(address, parts[1])
+			continue
+		}
+		offset := uint(bank)*NL_BANK_SIZE + uint(address-mappers.PRG_ROM_START)%NL_BANK_SIZE
+		s.addProgram(offset, address, parts[1])
+	}
+	return nil
+}
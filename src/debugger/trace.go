@@ -0,0 +1,234 @@
+package debugger
+
+import (
+	"Famicom-emulator/cartridge/mappers"
+	"Famicom-emulator/config"
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MARK: 定数定義
+const (
+	TRACE_EXT         = ".trace.log"
+	TRACE_BUFFER_SIZE = 1024 * 1024 // 書き出しのバッファサイズ
+	TRACE_BANK_SIZE   = 16 * 1024   // バンクでの絞り込みに使うPRGバンクのサイズ
+	TRACE_NO_PC       = -1          // 開始・停止のPCが指定されていない
+)
+
+// MARK: PCの範囲
+type addressRange struct {
+	start uint16
+	end   uint16
+}
+
+/*
+	実行ログの書き出し
+
+	1行の形式は nestest.log と同じ (列は設定で追加する)
+	C000  4C F5 C5  JMP $C5F5                       A:00 X:00 Y:00 P:24 SP:FD PPU:  0, 21 CYC:7
+
+	開始・停止はPC・フレーム・ホットキーで切り替え，書き出すPCは範囲とバンクで絞り込む
+*/
+
+// MARK: TraceLogger の定義
+type TraceLogger struct {
+	mapper mappers.Mapper
+	path   string
+	file   *os.File // 最初に書き出しを開始したときに作成する
+	writer *bufio.Writer
+
+	recording bool
+	lines     uint // 書き出した行数
+
+	startPC    int // 開始するPC (無ければTRACE_NO_PC)
+	stopPC     int // 停止するPC (無ければTRACE_NO_PC)
+	startFrame uint
+	stopFrame  uint
+	lastFrame  uint // 前回の命令のときのフレーム数
+
+	ranges      []addressRange
+	banks       map[uint]bool
+	ppuColumn   bool
+	cycleColumn bool
+}
+
+// MARK: TraceLogger の初期化メソッド (PCにはシンボル名も使える)
+func (t *TraceLogger) Init(mapper mappers.Mapper, cfg config.TraceConfig, path string, symbols *SymbolTable) error {
+	t.mapper = mapper
+	t.path = path
+	t.file = nil
+	t.writer = nil
+	t.recording = false
+	t.lines = 0
+	t.startFrame = cfg.START_FRAME
+	t.stopFrame = cfg.STOP_FRAME
+	t.lastFrame = 0
+	t.ppuColumn = cfg.PPU_COLUMN
+	t.cycleColumn = cfg.CYCLE_COLUMN
+
+	var err error
+	if t.startPC, err = parseTracePC(symbols, cfg.START_PC); err != nil {
+		return err
+	}
+	if t.stopPC, err = parseTracePC(symbols, cfg.STOP_PC); err != nil {
+		return err
+	}
+
+	t.ranges = nil
+	for _, text := range cfg.RANGES {
+		startText, endText, isRange := strings.Cut(text, "-")
+		start, err := parseAddress(symbols, strings.TrimSpace(startText))
+		if err != nil {
+			return err
+		}
+		end := start
+		if isRange {
+			if end, err = parseAddress(symbols, strings.TrimSpace(endText)); err != nil {
+				return err
+			}
+		}
+		t.ranges = append(t.ranges, addressRange{start: min(start, end), end: max(start, end)})
+	}
+
+	t.banks = nil
+	if len(cfg.BANKS) > 0 {
+		t.banks = make(map[uint]bool)
+		for _, bank := range cfg.BANKS {
+			t.banks[bank] = true
+		}
+	}
+	return nil
+}
+
+// MARK: 開始・停止のPCの解析
+func parseTracePC(symbols *SymbolTable, text string) (int, error) {
+	if text == "" {
+		return TRACE_NO_PC, nil
+	}
+	address, err := parseAddress(symbols, text)
+	return int(address), err
+}
+
+// MARK: 開始の条件が設定されているかどうか
+func (t *TraceLogger) HasStartTrigger() bool {
+	return t.startPC != TRACE_NO_PC || t.startFrame != 0
+}
+
+// MARK: 書き出し中かどうか
+func (t *TraceLogger) Recording() bool {
+	return t.recording
+}
+
+// MARK: 書き出しの開始
+func (t *TraceLogger) Start() error {
+	if t.recording {
+		return nil
+	}
+	if t.file == nil {
+		file, err := os.Create(t.path)
+		if err != nil {
+			return err
+		}
+		t.file = file
+		t.writer = bufio.NewWriterSize(file, TRACE_BUFFER_SIZE)
+	}
+	t.recording = true
+	fmt.Printf("[CPU] Trace log: ON (%s)\n", t.path)
+	return nil
+}
+
+// MARK: 書き出しの停止 (バッファの内容をファイルに書き出す)
+func (t *TraceLogger) Stop() error {
+	if !t.recording {
+		return nil
+	}
+	t.recording = false
+	fmt.Printf("[CPU] Trace log: OFF (%d lines)\n", t.lines)
+	return t.writer.Flush()
+}
+
+// MARK: 書き出しの開始・停止の切り替え (ホットキー)
+func (t *TraceLogger) Toggle() error {
+	if t.recording {
+		return t.Stop()
+	}
+	return t.Start()
+}
+
+// MARK: ファイルを閉じる
+func (t *TraceLogger) Close() error {
+	if t.file == nil {
+		return nil
+	}
+	err := t.Stop()
+	if closeErr := t.file.Close(); err == nil {
+		err = closeErr
+	}
+	t.file = nil
+	t.writer = nil
+	return err
+}
+
+// MARK: 命令を書き出すかどうかの判定 (開始・停止の条件もここで判定する)
+func (t *TraceLogger) Check(pc uint16, frame uint) bool {
+	lastFrame := t.lastFrame
+	t.lastFrame = frame
+
+	if !t.recording {
+		started := int(pc) == t.startPC ||
+			(t.startFrame != 0 && lastFrame < t.startFrame && frame >= t.startFrame)
+		if !started {
+			return false
+		}
+		if err := t.Start(); err != nil {
+			// ファイルを作れなければ以降は開始しない
+			fmt.Printf("[Warning] Trace: %v\n", err)
+			t.startPC = TRACE_NO_PC
+			t.startFrame = 0
+			return false
+		}
+	} else if int(pc) == t.stopPC || (t.stopFrame != 0 && lastFrame < t.stopFrame && frame >= t.stopFrame) {
+		t.Stop()
+		return false
+	}
+
+	return t.filter(pc)
+}
+
+// MARK: PCの範囲とバンクでの絞り込み
+func (t *TraceLogger) filter(pc uint16) bool {
+	if len(t.ranges) > 0 {
+		found := false
+		for _, r := range t.ranges {
+			if r.start <= pc && pc <= r.end {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if t.banks != nil {
+		if pc < mappers.PRG_ROM_START {
+			return false
+		}
+		return t.banks[t.mapper.ProgramRomOffset(pc)/TRACE_BANK_SIZE]
+	}
+	return true
+}
+
+// MARK: 1行の書き出し (nestest.log と同じ列を追加する)
+func (t *TraceLogger) Write(line string, scanline uint16, dot uint, cycles uint) {
+	t.writer.WriteString(line)
+	if t.ppuColumn {
+		fmt.Fprintf(t.writer, " PPU:%3d,%3d", scanline, dot)
+	}
+	if t.cycleColumn {
+		fmt.Fprintf(t.writer, " CYC:%d", cycles)
+	}
+	t.writer.WriteByte('\n')
+	t.lines++
+}
@@ -0,0 +1,109 @@
+package debugger
+
+import "fmt"
+
+// MARK: ウォッチするアクセスの種類
+type WatchKind uint8
+
+const (
+	WATCH_READ   WatchKind = 1 << iota // 読み取り
+	WATCH_WRITE                        // 書き込み
+	WATCH_ACCESS = WATCH_READ | WATCH_WRITE
+)
+
+// MARK: ウォッチするアドレス空間
+type AddressSpace uint8
+
+const (
+	SPACE_CPU AddressSpace = iota // CPUのアドレス空間
+	SPACE_PPU                     // $2006/$2007経由のPPUのアドレス空間
+)
+
+// MARK: ウォッチポイントの定義
+type Watchpoint struct {
+	ID      int
+	Space   AddressSpace
+	Start   uint16
+	End     uint16 // 範囲の終端 (含む)
+	Kind    WatchKind
+	Enabled bool
+	Hits    uint
+}
+
+// MARK: ウォッチポイントの説明の取得
+func (w *Watchpoint) String() string {
+	kind := map[WatchKind]string{WATCH_READ: "r", WATCH_WRITE: "w", WATCH_ACCESS: "rw"}[w.Kind]
+	space := "cpu"
+	if w.Space == SPACE_PPU {
+		space = "ppu"
+	}
+	if w.Start == w.End {
+		return fmt.Sprintf("#%d %s %s $%04X (hits %d)", w.ID, space, kind, w.Start, w.Hits)
+	}
+	return fmt.Sprintf("#%d %s %s $%04X-$%04X (hits %d)", w.ID, space, kind, w.Start, w.End, w.Hits)
+}
+
+// MARK: ウォッチポイントの追加
+func (d *Debugger) AddWatchpoint(space AddressSpace, start uint16, end uint16, kind WatchKind) *Watchpoint {
+	if end < start {
+		start, end = end, start
+	}
+	wp := &Watchpoint{
+		ID:      d.nextID,
+		Space:   space,
+		Start:   start,
+		End:     end,
+		Kind:    kind,
+		Enabled: true,
+	}
+	d.nextID++
+	d.watchpoints = append(d.watchpoints, wp)
+	return wp
+}
+
+// MARK: ウォッチポイントの一覧の取得
+func (d *Debugger) Watchpoints() []*Watchpoint {
+	return d.watchpoints
+}
+
+// MARK: CPUの読み取りのフック
+func (d *Debugger) CPURead(address uint16, value uint8) {
+	d.checkWatch(SPACE_CPU, WATCH_READ, address, value)
+}
+
+// MARK: CPUの書き込みのフック
+func (d *Debugger) CPUWrite(address uint16, value uint8) {
+	d.checkWatch(SPACE_CPU, WATCH_WRITE, address, value)
+}
+
+// MARK: $2007経由のPPUの読み取りのフック
+func (d *Debugger) PPURead(address uint16, value uint8) {
+	d.checkWatch(SPACE_PPU, WATCH_READ, address, value)
+}
+
+// MARK: $2007経由のPPUの書き込みのフック
+func (d *Debugger) PPUWrite(address uint16, value uint8) {
+	d.checkWatch(SPACE_PPU, WATCH_WRITE, address, value)
+}
+
+// MARK: ウォッチポイントの判定 (命令の途中で止められないので，命令の完了後に停止する)
+func (d *Debugger) checkWatch(space AddressSpace, kind WatchKind, address uint16, value uint8) {
+	if d.paused {
+		return
+	}
+	for _, wp := range d.watchpoints {
+		if !wp.Enabled || wp.Space != space || wp.Kind&kind == 0 {
+			continue
+		}
+		if address < wp.Start || wp.End < address {
+			continue
+		}
+		wp.Hits++
+		verb := "read"
+		if kind == WATCH_WRITE {
+			verb = "write"
+		}
+		d.Break(fmt.Sprintf("watchpoint #%d %s $%04X = $%02X", wp.ID, verb, address, value))
+		return
+	}
+}
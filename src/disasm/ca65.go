@@ -0,0 +1,170 @@
+package disasm
+
+import (
+	"Famicom-emulator/cpu"
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MARK: 定数定義
+const (
+	BYTES_PER_LINE  = 16 // .byte の1行あたりのバイト数
+	COMMENT_COLUMN  = 24 // アドレスのコメントを置く桁
+	VECTOR_BYTES    = 6  // 割り込みベクタ ($FFFA-$FFFF) のバイト数
+	IO_REGISTER_END = 0x4018
+)
+
+// MARK: ca65形式のソースの書き出し
+func (img *Image) WriteCA65(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	name := filepath.Base(img.Path)
+
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	fmt.Fprintf(out, "; %s\n", name)
+	fmt.Fprintf(out, "; reassemble: ca65 %s.s && ld65 -C nes.cfg -o %s %s.o\n", base, name, base)
+	fmt.Fprintln(out, "; nes.cfg:   MEMORY { ROM: start = 0, size = $1000000, file = %O; }")
+	fmt.Fprintln(out, ";            SEGMENTS { CODE: load = ROM; }")
+	fmt.Fprintf(out, "; the CHR ROM is included from %s, so keep it next to this file\n\n", name)
+	fmt.Fprintln(out, "\t.setcpu \"6502\"")
+	fmt.Fprintln(out)
+
+	// I/Oレジスタの定義
+	for address := uint16(0x2000); address < IO_REGISTER_END; address++ {
+		if register, ok := cpu.IORegisterName(address); ok {
+			fmt.Fprintf(out, "%s = $%04X\n", register, address)
+		}
+	}
+	fmt.Fprintln(out)
+
+	fmt.Fprintln(out, "\t.segment \"CODE\"")
+	fmt.Fprintln(out, "\n; iNES header")
+	writeBytes(out, img.Header)
+	if img.Trainer != nil {
+		fmt.Fprintln(out, "\n; trainer")
+		writeBytes(out, img.Trainer)
+	}
+
+	for _, bank := range img.Banks {
+		end := int(bank.Base) + len(bank.Data) - 1
+		fmt.Fprintf(out, "\n; PRG bank $%02X ($%04X-$%04X)\n", bank.Index, bank.Base, end)
+		fmt.Fprintf(out, "\t.org $%04X\n", bank.Base)
+		img.writeBank(out, bank)
+	}
+
+	if len(img.Character) > 0 {
+		fmt.Fprintln(out, "\n; CHR ROM")
+		fmt.Fprintln(out, "\t.reloc")
+		fmt.Fprintf(out, "\t.incbin \"%s\", $%X, $%X\n", name, img.characterOffset, len(img.Character))
+	}
+
+	return out.Flush()
+}
+
+// MARK: 1バンク分の書き出し
+func (img *Image) writeBank(out *bufio.Writer, bank *Bank) {
+	// 命令の途中を指すラベルは命令の直前に定義する
+	inner := make(map[int][]uint16)
+	for address := range bank.labels {
+		offset := int(address - bank.Base)
+		start := offset
+		for start > 0 && bank.kinds[start] == KIND_OPERAND {
+			start--
+		}
+		if start != offset {
+			inner[start] = append(inner[start], address)
+		}
+	}
+
+	labeler := func(address uint16) (string, bool) {
+		target, resolved := img.resolve(address, bank)
+		if target == nil || resolved != address {
+			return "", false
+		}
+		name, ok := target.labels[address]
+		return name, ok
+	}
+
+	for offset := 0; offset < len(bank.Data); {
+		address := bank.Base + uint16(offset)
+		if name, ok := bank.labels[address]; ok && bank.kinds[offset] != KIND_OPERAND {
+			fmt.Fprintf(out, "%s:\n", name)
+		}
+
+		if bank.kinds[offset] == KIND_OPCODE {
+			sort.Slice(inner[offset], func(i, j int) bool { return inner[offset][i] < inner[offset][j] })
+			for _, labelAddress := range inner[offset] {
+				fmt.Fprintf(out, "%s := * + %d\n", bank.labels[labelAddress], labelAddress-address)
+			}
+
+			inst := cpu.Disassemble(bank.Read, address, labeler)
+			operand := inst.Operand
+			// ゼロページのアドレスを絶対アドレッシングで参照している場合は明示する
+			if inst.HasTarget && inst.Target < 0x100 && strings.HasPrefix(operand, "$") {
+				switch inst.Mode {
+				case cpu.Absolute, cpu.AbsoluteXIndexed, cpu.AbsoluteYIndexed:
+					operand = "a:" + operand
+				}
+			}
+			line := "\t" + inst.Mnemonic
+			if operand != "" {
+				line += " " + operand
+			}
+			fmt.Fprintf(out, "%-*s; $%04X\n", COMMENT_COLUMN, line, address)
+			offset += int(inst.Length())
+			continue
+		}
+
+		// 割り込みベクタは .word で書き出す
+		if address == VECTOR_NMI && offset+VECTOR_BYTES <= len(bank.Data) && bank.isData(offset, VECTOR_BYTES) && !bank.hasLabel(address+1, VECTOR_BYTES-1) {
+			var words []string
+			for _, vector := range []uint16{VECTOR_NMI, VECTOR_RESET, VECTOR_IRQ} {
+				target := bank.readWord(vector)
+				if name, ok := labeler(target); ok {
+					words = append(words, name)
+				} else {
+					words = append(words, fmt.Sprintf("$%04X", target))
+				}
+			}
+			fmt.Fprintf(out, "\t.word %s\n", strings.Join(words, ", "))
+			offset += VECTOR_BYTES
+			continue
+		}
+
+		// データはラベル・命令・ベクタの手前か16バイトごとに区切る
+		length := 1
+		for length < BYTES_PER_LINE && offset+length < len(bank.Data) && bank.kinds[offset+length] == KIND_DATA {
+			next := bank.Base + uint16(offset+length)
+			if _, ok := bank.labels[next]; ok || next == VECTOR_NMI {
+				break
+			}
+			length++
+		}
+		writeBytes(out, bank.Data[offset:offset+length])
+		offset += length
+	}
+}
+
+// MARK: 指定した範囲にラベルがあるかどうか
+func (b *Bank) hasLabel(address uint16, length int) bool {
+	for i := range length {
+		if _, ok := b.labels[address+uint16(i)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MARK: .byte 行の書き出し
+func writeBytes(out *bufio.Writer, data []uint8) {
+	for i := 0; i < len(data); i += BYTES_PER_LINE {
+		values := make([]string, 0, BYTES_PER_LINE)
+		for _, value := range data[i:min(i+BYTES_PER_LINE, len(data))] {
+			values = append(values, fmt.Sprintf("$%02X", value))
+		}
+		fmt.Fprintf(out, "\t.byte %s\n", strings.Join(values, ","))
+	}
+}
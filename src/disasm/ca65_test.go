@@ -0,0 +1,133 @@
+package disasm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// テストヘルパー関数：$FFE0-$FFFF の32バイトのプログラムROMを持つNROMのイメージを作る
+func setupImage(t *testing.T, program []uint8) *Image {
+	t.Helper()
+	if len(program) != 32 {
+		t.Fatalf("program is %d bytes, want 32", len(program))
+	}
+	header := []uint8{0x4E, 0x45, 0x53, 0x1A, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	image := &Image{Path: "test.nes", Header: header, Program: program}
+	if err := image.layout(16 * 1024); err != nil {
+		t.Fatal(err)
+	}
+	return image
+}
+
+// テストヘルパー関数：count バイトの埋め草
+func filler(count int) []uint8 {
+	return bytes.Repeat([]uint8{0x07}, count)
+}
+
+// テストヘルパー関数：バイト列の連結
+func concat(parts ...[]uint8) []uint8 {
+	var out []uint8
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// TestWriteCA65 は解析結果から再アセンブルできるソースが書き出されることをテストします
+func TestWriteCA65(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  []uint8
+		expected string // バンクの .org 以降
+	}{
+		{
+			name: "zero page operand with absolute addressing",
+			program: concat(
+				[]uint8{0xAD, 0x10, 0x00}, // LDA $0010 (絶対)
+				[]uint8{0x9D, 0x20, 0x00}, // STA $0020,X (絶対)
+				[]uint8{0xA5, 0x10},       // LDA $10 (ゼロページ)
+				[]uint8{0x4C, 0xE8, 0xFF}, // JMP $FFE8
+				filler(15),
+				[]uint8{0xE8, 0xFF, 0xE0, 0xFF, 0xE8, 0xFF},
+			),
+			expected: "" +
+				"RESET:\n" +
+				"\tLDA a:$0010            ; $FFE0\n" +
+				"\tSTA a:$0020,X          ; $FFE3\n" +
+				"\tLDA $10                ; $FFE6\n" +
+				"NMI:\n" +
+				"\tJMP NMI                ; $FFE8\n" +
+				"\t.byte $07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07\n" +
+				"\t.word NMI, RESET, NMI\n",
+		},
+		{
+			name: "label inside an instruction",
+			program: concat(
+				[]uint8{0xD0, 0x01},       // BNE $FFE3
+				[]uint8{0x2C, 0xA9, 0x01}, // BIT $01A9 ($FFE3 は LDA #$01)
+				[]uint8{0x4C, 0xE5, 0xFF}, // JMP $FFE5
+				filler(18),
+				[]uint8{0xE5, 0xFF, 0xE0, 0xFF, 0xE5, 0xFF},
+			),
+			expected: "" +
+				"RESET:\n" +
+				"\tBNE LFFE3              ; $FFE0\n" +
+				"LFFE3 := * + 1\n" +
+				"\tBIT $01A9              ; $FFE2\n" +
+				"NMI:\n" +
+				"\tJMP NMI                ; $FFE5\n" +
+				"\t.byte $07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07\n" +
+				"\t.byte $07,$07\n" +
+				"\t.word NMI, RESET, NMI\n",
+		},
+		{
+			name: "data split at labels and unresolved vectors",
+			program: concat(
+				[]uint8{0xF0, 0x05}, // BEQ $FFE7
+				[]uint8{0x40},       // RTI
+				filler(4),           // データ
+				[]uint8{0x40},       // RTI ($FFE7)
+				filler(18),          // データ
+				[]uint8{0x00, 0x80, 0xE0, 0xFF, 0xE0, 0xFF},
+			),
+			expected: "" +
+				"RESET:\n" +
+				"\tBEQ LFFE7              ; $FFE0\n" +
+				"\tRTI                    ; $FFE2\n" +
+				"\t.byte $07,$07,$07,$07\n" +
+				"LFFE7:\n" +
+				"\tRTI                    ; $FFE7\n" +
+				"\t.byte $07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07,$07\n" +
+				"\t.byte $07,$07\n" +
+				"\t.word $8000, RESET, RESET\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image := setupImage(t, tt.program)
+			image.Analyze()
+
+			var out bytes.Buffer
+			if err := image.WriteCA65(&out); err != nil {
+				t.Fatal(err)
+			}
+			source := out.String()
+
+			// ヘッダはそのままのバイト列で書き出される
+			header := "\t.byte $4E,$45,$53,$1A,$01,$00,$00,$00,$00,$00,$00,$00,$00,$00,$00,$00\n"
+			if !strings.Contains(source, header) {
+				t.Errorf("header is missing:\n%s", source)
+			}
+
+			_, bank, ok := strings.Cut(source, "\t.org $FFE0\n")
+			if !ok {
+				t.Fatalf("bank is missing:\n%s", source)
+			}
+			if bank != tt.expected {
+				t.Errorf("bank source =\n%s\nwant\n%s", bank, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package disasm
+
+import (
+	"Famicom-emulator/cartridge"
+	"Famicom-emulator/cartridge/mappers"
+	"Famicom-emulator/cpu"
+	"Famicom-emulator/debugger"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MARK: 定数定義
+const (
+	HEADER_SIZE = 16
+
+	VECTOR_NMI   uint16 = 0xFFFA
+	VECTOR_RESET uint16 = 0xFFFC
+	VECTOR_IRQ   uint16 = 0xFFFE
+
+	WINDOW_SIZE = 32 * 1024 // プログラムROMの窓 ($8000-$FFFF)
+)
+
+// MARK: バイトの種類
+type byteKind uint8
+
+const (
+	KIND_DATA    byteKind = iota // データ (未解析)
+	KIND_OPCODE                  // 命令の先頭
+	KIND_OPERAND                 // 命令のオペランド
+)
+
+// MARK: プログラムROMのバンク
+type Bank struct {
+	Index int
+	Base  uint16 // CPUのアドレス空間での先頭アドレス
+	Data  []uint8
+	Fixed bool // 常に同じ位置にマップされるかどうか
+
+	kinds  []byteKind
+	labels map[uint16]string
+	cdl    []uint8 // CDLファイルのフラグ (無ければnil)
+}
+
+// MARK: ROMイメージの定義
+type Image struct {
+	Path      string
+	Header    []uint8
+	Trainer   []uint8 // トレーナー (無ければnil)
+	Program   []uint8
+	Character []uint8
+	Banks     []*Bank
+
+	characterOffset int  // ファイル内でのキャラクタROMの位置
+	mirrored        bool // 16KBのプログラムROMが$8000と$C000に重ねてマップされる
+}
+
+// MARK: 逆アセンブルの待ち行列の要素
+type entry struct {
+	bank    *Bank
+	address uint16
+}
+
+// MARK: disasm サブコマンドの実行
+func Run(args []string) error {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default: stdout)")
+	bankSize := flags.Int("bank", 16, "switchable PRG bank size in KB (16 or 32)")
+	cdlPath := flags.String("cdl", "", "code/data log recorded by the emulator or FCEUX")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("usage: disasm [-o out.s] [-bank 16|32] [-cdl game.cdl] <rom.nes>")
+	}
+
+	image, err := Load(flags.Arg(0), *bankSize*1024)
+	if err != nil {
+		return err
+	}
+	if *cdlPath != "" {
+		if err := image.LoadCDL(*cdlPath); err != nil {
+			return err
+		}
+	}
+	image.Analyze()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return image.WriteCA65(w)
+}
+
+// MARK: ROMファイルの読み込み
+func Load(path string, bankSize int) (*Image, error) {
+	rom, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read file %s", path)
+	}
+	if len(rom) < HEADER_SIZE || string(rom[0:4]) != string(cartridge.NES_TAG) {
+		return nil, fmt.Errorf("invalid cartridge header in %s", path)
+	}
+
+	image := &Image{Path: path, Header: rom[:HEADER_SIZE]}
+	offset := HEADER_SIZE
+	if (rom[6] & 0b100) != 0 {
+		image.Trainer = rom[offset : offset+int(mappers.TRAINER_SIZE)]
+		offset += int(mappers.TRAINER_SIZE)
+	}
+	programSize := int(rom[4]) * int(mappers.PRG_ROM_PAGE_SIZE)
+	characterSize := int(rom[5]) * int(mappers.CHR_ROM_PAGE_SIZE)
+	if len(rom) < offset+programSize+characterSize {
+		return nil, fmt.Errorf("%s is shorter than its header says", path)
+	}
+	image.Program = rom[offset : offset+programSize]
+	image.characterOffset = offset + programSize
+	image.Character = rom[image.characterOffset : image.characterOffset+characterSize]
+
+	if err := image.layout(bankSize); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+/*
+	バンクの配置 (実行時のバンク切り替えは分からないので一般的な配置を仮定する)
+
+	16KB        : $C000に配置し，$8000にもミラー (NROM-128)
+	32KB以下    : $8000-$FFFFにそのまま配置 (NROM-256)
+	16KBバンク  : 最後のバンクを$C000に固定し，他のバンクは$8000 (UxROM, MMC1)
+	32KBバンク  : すべてのバンクを$8000 (AxROM, GxROM)
+*/
+// MARK: バンクの配置の決定
+func (img *Image) layout(bankSize int) error {
+	programSize := len(img.Program)
+	switch {
+	case programSize == 0:
+		return errors.New("cartridge has no PRG ROM")
+	case programSize == int(mappers.PRG_ROM_PAGE_SIZE):
+		img.addBank(0xC000, img.Program, true)
+		img.mirrored = true
+	case programSize <= WINDOW_SIZE:
+		img.addBank(uint16(0x10000-programSize), img.Program, true)
+	case bankSize == int(mappers.PRG_ROM_PAGE_SIZE):
+		last := programSize/bankSize - 1
+		for i := range last {
+			img.addBank(0x8000, img.Program[i*bankSize:(i+1)*bankSize], false)
+		}
+		img.addBank(0xC000, img.Program[last*bankSize:], true)
+	case bankSize == WINDOW_SIZE:
+		for i := 0; i < programSize; i += bankSize {
+			img.addBank(0x8000, img.Program[i:min(i+bankSize, programSize)], false)
+		}
+	default:
+		return fmt.Errorf("unsupported bank size %dKB (16 or 32)", bankSize/1024)
+	}
+	return nil
+}
+
+// MARK: CDLファイルの読み込み
+func (img *Image) LoadCDL(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(data) != len(img.Program)+len(img.Character) {
+		return fmt.Errorf("%s does not match the ROM size", path)
+	}
+	offset := 0
+	for _, bank := range img.Banks {
+		bank.cdl = data[offset : offset+len(bank.Data)]
+		offset += len(bank.Data)
+	}
+	return nil
+}
+
+// MARK: バンクの追加
+func (img *Image) addBank(base uint16, data []uint8, fixed bool) {
+	img.Banks = append(img.Banks, &Bank{
+		Index:  len(img.Banks),
+		Base:   base,
+		Data:   data,
+		Fixed:  fixed,
+		kinds:  make([]byteKind, len(data)),
+		labels: make(map[uint16]string),
+	})
+}
+
+// MARK: バンク内のアドレスかどうか
+func (b *Bank) Contains(address uint16) bool {
+	return address >= b.Base && int(address-b.Base) < len(b.Data)
+}
+
+// MARK: バンク内のバイトの読み取り (範囲外は0)
+func (b *Bank) Read(address uint16) uint8 {
+	if !b.Contains(address) {
+		return 0x00
+	}
+	return b.Data[address-b.Base]
+}
+
+// MARK: バンク内のワードの読み取り
+func (b *Bank) readWord(address uint16) uint16 {
+	return uint16(b.Read(address)) | uint16(b.Read(address+1))<<8
+}
+
+// MARK: アドレスがどのバンクを指すかの解決 (分からなければnil)
+func (img *Image) resolve(address uint16, from *Bank) (*Bank, uint16) {
+	if from.Contains(address) {
+		return from, address
+	}
+	for _, bank := range img.Banks {
+		if bank.Fixed && bank.Contains(address) {
+			return bank, address
+		}
+	}
+	if img.mirrored && address >= 0x8000 {
+		return img.Banks[0], address | 0x4000
+	}
+	// 固定バンクから切り替え可能なバンクへのジャンプは行き先のバンクが分からない
+	return nil, 0
+}
+
+// MARK: ラベル名の生成
+func (img *Image) labelName(bank *Bank, address uint16) string {
+	if bank.Fixed {
+		return fmt.Sprintf("L%04X", address)
+	}
+	return fmt.Sprintf("B%02X_%04X", bank.Index, address)
+}
+
+// MARK: ラベルの追加 (既にあれば何もしない)
+func (img *Image) addLabel(bank *Bank, address uint16, name string) {
+	if _, ok := bank.labels[address]; ok {
+		return
+	}
+	if name == "" {
+		name = img.labelName(bank, address)
+	}
+	bank.labels[address] = name
+}
+
+// MARK: 割り込みベクタを持つバンクの一覧
+func (img *Image) vectorBanks() []*Bank {
+	var banks []*Bank
+	for _, bank := range img.Banks {
+		if bank.Contains(VECTOR_NMI) && bank.Contains(VECTOR_IRQ+1) {
+			banks = append(banks, bank)
+		}
+	}
+	return banks
+}
+
+// MARK: 割り込みベクタから辿れるコードの解析
+func (img *Image) Analyze() {
+	var queue []entry
+	vectorBanks := img.vectorBanks()
+	for _, bank := range vectorBanks {
+		for _, vector := range []struct {
+			address uint16
+			name    string
+		}{{VECTOR_RESET, "RESET"}, {VECTOR_NMI, "NMI"}, {VECTOR_IRQ, "IRQ"}} {
+			target, address := img.resolve(bank.readWord(vector.address), bank)
+			if target == nil {
+				continue
+			}
+			name := vector.name
+			if len(vectorBanks) > 1 {
+				name = fmt.Sprintf("%s_%02X", vector.name, bank.Index)
+			}
+			img.addLabel(target, address, name)
+			queue = append(queue, entry{target, address})
+		}
+	}
+
+	// CDLで実行されたと記録されているコードの先頭も辿る
+	for _, bank := range img.Banks {
+		for offset := range bank.cdl {
+			if bank.isLoggedCode(offset) && (offset == 0 || !bank.isLoggedCode(offset-1)) {
+				address := bank.Base + uint16(offset)
+				img.addLabel(bank, address, "")
+				queue = append(queue, entry{bank, address})
+			}
+		}
+	}
+
+	img.trace(queue)
+}
+
+// MARK: CDLでコードとして記録されているかどうか
+func (b *Bank) isLoggedCode(offset int) bool {
+	return b.cdl != nil && b.cdl[offset]&debugger.CDL_CODE != 0
+}
+
+// MARK: CDLでデータとしてのみ記録されているかどうか
+func (b *Bank) isLoggedData(offset int) bool {
+	return b.cdl != nil && b.cdl[offset]&(debugger.CDL_CODE|debugger.CDL_DATA) == debugger.CDL_DATA
+}
+
+// MARK: 実行経路を辿ってコードとデータを分離
+func (img *Image) trace(queue []entry) {
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		bank, address := e.bank, e.address
+
+		for bank.Contains(address) {
+			offset := int(address - bank.Base)
+			if bank.kinds[offset] != KIND_DATA || bank.isLoggedData(offset) {
+				break
+			}
+			inst := cpu.Disassemble(bank.Read, address, nil)
+			length := int(inst.Length())
+			if !inst.Official() || offset+length > len(bank.Data) || !bank.isData(offset, length) {
+				break
+			}
+			bank.kinds[offset] = KIND_OPCODE
+			for i := 1; i < length; i++ {
+				bank.kinds[offset+i] = KIND_OPERAND
+			}
+
+			// 分岐・ジャンプ先を辿る (JMP (ind) の行き先は分からない)
+			if inst.IsBranch() && inst.Mode != cpu.Indirect {
+				if target, targetAddress := img.resolve(inst.Target, bank); target != nil {
+					img.addLabel(target, targetAddress, "")
+					queue = append(queue, entry{target, targetAddress})
+				}
+			}
+
+			// 実行が次の命令に進まない命令で打ち切る
+			if inst.Code == cpu.JMP || inst.Code == cpu.RTS || inst.Code == cpu.RTI || inst.Code == cpu.BRK {
+				break
+			}
+			address += inst.Length()
+		}
+	}
+}
+
+// MARK: 指定した範囲がすべて未解析のデータかどうか
+func (b *Bank) isData(offset int, length int) bool {
+	for i := range length {
+		if b.kinds[offset+i] != KIND_DATA {
+			return false
+		}
+	}
+	return true
+}
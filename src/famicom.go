@@ -6,6 +6,7 @@ import (
 	"Famicom-emulator/cartridge"
 	"Famicom-emulator/config"
 	"Famicom-emulator/cpu"
+	"Famicom-emulator/debugger"
 	"Famicom-emulator/joypad"
 	"Famicom-emulator/ppu"
 	"Famicom-emulator/ui"
@@ -14,16 +15,12 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-// MARK: 定数定義
-const (
-	FRAME_PER_SECOND = 60
-)
-
 // MARK: InputStateの定義
 type InputState struct {
 	Left, Right, Up, Down bool
@@ -37,6 +34,7 @@ type Famicom struct {
 	apu       apu.APU
 	joypad1   joypad.JoyPad
 	joypad2   joypad.JoyPad
+	vsSystem  joypad.VsSystem
 	bus       bus.Bus
 	cartridge cartridge.Cartridge
 
@@ -52,6 +50,12 @@ type Famicom struct {
 
 	romLoaded bool
 
+	debugger      *debugger.Debugger       // デバッガ (有効化するまでnil)
+	debugCommands <-chan string            // 標準入力から受け取るデバッガのコマンド
+	cdl           *debugger.CodeDataLogger // Code/Data Logger (無効ならnil)
+	symbols       *debugger.SymbolTable    // ROMと同じ場所にあるシンボルファイル (無ければnil)
+	trace         *debugger.TraceLogger    // 実行ログ (使うまでnil)
+
 	config  *config.Config
 	windows *ui.WindowManager
 }
@@ -63,7 +67,13 @@ func (f *Famicom) Init(cartridge cartridge.Cartridge, config *config.Config) {
 	// ROMファイルのロード
 	f.cartridge = cartridge
 	err := f.cartridge.Load()
+	if err != nil && f.cartridge.ROM != "" {
+		fmt.Printf("Failed to load cartridge: %v\n", err)
+	}
 	f.romLoaded = err == nil
+	if f.romLoaded {
+		f.applySystem()
+	}
 
 	// 各コンポーネントの接続
 	f.bus.ConnectComponents(
@@ -72,20 +82,29 @@ func (f *Famicom) Init(cartridge cartridge.Cartridge, config *config.Config) {
 		&f.cartridge,
 		&f.joypad1,
 		&f.joypad2,
+		&f.vsSystem,
 		f.config,
 	)
 }
 
 func (f *Famicom) loadDroppedFile(path string) {
 	fmt.Printf("Loading dropped file: %s\n", path)
-	cartridge := cartridge.Cartridge{ROM: path}
+	cartridge := f.config.NewCartridge(path)
 	err := cartridge.Load()
 	if err != nil {
 		fmt.Printf("Failed to load cartridge: %v\n", err)
 		return
 	}
 
+	// 差し替える前に現在のカートリッジのセーブデータと実行ログを書き出す
+	if f.romLoaded {
+		f.flushSaveData()
+	}
+	f.closeTraceLogger()
+
 	f.cartridge = cartridge
+	f.applySystem()
+
 	// 各コンポーネントの接続
 	f.romLoaded = true
 	f.bus.ConnectComponents(
@@ -94,13 +113,31 @@ func (f *Famicom) loadDroppedFile(path string) {
 		&f.cartridge,
 		&f.joypad1,
 		&f.joypad2,
+		&f.vsSystem,
 		f.config,
 	)
 	f.cpu.Init(f.bus, *f.config)
+	if f.debugger != nil {
+		f.cpu.AttachDebugger(f.debugger)
+	}
+	f.startCodeDataLogger()
+	f.loadSymbols()
+	f.startTraceLogger()
 	f.cpu.Reset()
 	fmt.Printf("Load ROM file: %s\n", filepath.Base(path))
 }
 
+// MARK: カートリッジと設定から地域・Vs. システムのPPUを決定して反映
+func (f *Famicom) applySystem() {
+	f.config.System.REGION = f.config.ResolveRegion(&f.cartridge)
+	fmt.Printf("Region: %s\n", f.config.System.REGION)
+
+	f.config.System.VS_PPU = f.config.ResolveVsPPU(&f.cartridge)
+	if f.cartridge.IsVsSystem() {
+		fmt.Printf("Vs. System PPU: %s\n", f.config.System.VS_PPU)
+	}
+}
+
 // MARK: Famicomの起動
 func (f *Famicom) Start() {
 	// SDLの初期化
@@ -125,8 +162,22 @@ func (f *Famicom) Start() {
 		f.cpu.Init(f.bus, *f.config)
 	}
 
+	// デバッガの有効化
+	if f.config.Cpu.DEBUGGER_ENABLED {
+		f.enableDebugger()
+	}
+
+	// Code/Data Logger の開始
+	f.startCodeDataLogger()
+
+	// シンボルファイルの読み込み
+	f.loadSymbols()
+
+	// 実行ログの開始 (設定されていれば)
+	f.startTraceLogger()
+
 	// ゲームウィンドウの作成
-	gameWindow, err := ui.NewGameWindow(f.config.Render.SCALE_FACTOR, f.config.Render.FULLSCREEN, f.bus.Canvas(), func() {
+	gameWindow, err := ui.NewGameWindow(f.config.Render, f.bus.Canvas(), func() {
 		f.requestShutdown()
 	})
 	if err != nil {
@@ -139,10 +190,11 @@ func (f *Famicom) Start() {
 			- CPUは実時間に追従するようにサイクルを進める
 			- 描画は SDL の VSync(Present待ち) がフレームペースを作る
 	*/
-	const ntscCpuClockHz = 1789773.0
 	lastTick := time.Now()
 	cpuCycleAcc := 0.0
 	const maxDtSec = 0.25
+	saveInterval := time.Duration(f.config.Rom.SAVE_INTERVAL) * time.Second
+	lastSave := time.Now()
 
 	for {
 		// イベント処理
@@ -183,14 +235,39 @@ func (f *Famicom) Start() {
 								log.Printf("failed to toggle audio window: %v", err)
 							}
 						}
+					case sdl.K_F5:
+						if f.romLoaded {
+							f.flushSaveData()
+						}
+					case sdl.K_F6:
+						if f.romLoaded && f.windows != nil {
+							f.enableDebugger()
+							if _, err := f.windows.ToggleDebuggerWindow(f.debugger, f.config.Render.SCALE_FACTOR); err != nil {
+								log.Printf("failed to toggle debugger window: %v", err)
+							}
+						}
+					case sdl.K_F7:
+						if f.romLoaded {
+							f.toggleDebuggerPause()
+						}
 					case sdl.K_F8:
 						f.ppu.ToggleBackgroundEnabled()
 					case sdl.K_F9:
 						f.ppu.ToggleSpriteEnabled()
+					case sdl.K_p:
+						f.ppu.TogglePaletteGenerator()
+					case sdl.K_LEFTBRACKET:
+						f.ppu.AdjustPalette(-1)
+					case sdl.K_RIGHTBRACKET:
+						f.ppu.AdjustPalette(1)
+					case sdl.K_9:
+						f.ppu.SelectPaletteControl(-1)
+					case sdl.K_0:
+						f.ppu.SelectPaletteControl(1)
 					case sdl.K_F10:
 						f.apu.ToggleLog()
 					case sdl.K_F11:
-						f.cpu.ToggleLog()
+						f.toggleTraceLogger()
 					case sdl.K_y:
 						if f.romLoaded {
 							f.cpu.Reset()
@@ -231,6 +308,9 @@ func (f *Famicom) Start() {
 			f.windows.HandleEvent(event)
 		}
 
+		// デバッガのコマンドを処理
+		f.pollDebuggerCommands()
+
 		// JoyPad状態の更新
 		f.updateJoyPad(&f.joypad1, &f.keyboard1, &f.controller1)
 		f.updateJoyPad(&f.joypad2, &f.keyboard2, &f.controller2)
@@ -242,7 +322,7 @@ func (f *Famicom) Start() {
 		if dtSec > maxDtSec {
 			dtSec = maxDtSec
 		}
-		cpuCycleAcc += ntscCpuClockHz * dtSec
+		cpuCycleAcc += f.config.System.REGION.CPUClock() * dtSec
 		cyclesToRun := uint(cpuCycleAcc)
 		if cyclesToRun > 0 {
 			if f.romLoaded {
@@ -255,6 +335,14 @@ func (f *Famicom) Start() {
 			f.renderStartScreen()
 		}
 
+		// 一定間隔でセーブデータを書き出す (クラッシュ時の消失を防ぐ)
+		if saveInterval > 0 && now.Sub(lastSave) >= saveInterval {
+			if f.romLoaded {
+				f.flushSaveData()
+			}
+			lastSave = now
+		}
+
 		// 全ウィンドウを描画
 		f.windows.RenderAll()
 	}
@@ -294,6 +382,160 @@ func (f *Famicom) renderStartScreen() {
 	f.bus.Canvas().Swap()
 }
 
+// MARK: セーブデータの書き出しメソッド
+func (f *Famicom) flushSaveData() {
+	if err := f.cartridge.Save(); err != nil {
+		fmt.Printf("Error saving game data: %v\n", err)
+	}
+	f.saveCodeDataLog()
+}
+
+// MARK: Code/Data Logger の開始メソッド (前回の .cdl があれば追記する)
+func (f *Famicom) startCodeDataLogger() {
+	if !f.config.Cpu.CDL_ENABLED || !f.romLoaded {
+		return
+	}
+	f.cdl = &debugger.CodeDataLogger{}
+	f.cdl.Init(f.cartridge.Mapper())
+	if err := f.cdl.Load(f.codeDataLogPath()); err != nil {
+		fmt.Printf("[Warning] CDL: %v\n", err)
+	}
+	f.bus.AttachCodeDataLogger(f.cdl)
+	f.cpu.AttachCodeDataLogger(f.cdl)
+	f.ppu.AttachCodeDataLogger(f.cdl)
+}
+
+// MARK: CDLファイルの書き出しメソッド
+func (f *Famicom) saveCodeDataLog() {
+	if f.cdl == nil {
+		return
+	}
+	if err := f.cdl.Save(f.codeDataLogPath()); err != nil {
+		fmt.Printf("Error saving CDL: %v\n", err)
+	}
+}
+
+// MARK: CDLファイルのパスの取得 (ROMと同じ場所)
+func (f *Famicom) codeDataLogPath() string {
+	return strings.TrimSuffix(f.cartridge.ROM, filepath.Ext(f.cartridge.ROM)) + debugger.CDL_EXT
+}
+
+// MARK: シンボルファイルの読み込みメソッド (.dbg / .mlb / .nl)
+func (f *Famicom) loadSymbols() {
+	f.symbols = nil
+	if f.romLoaded {
+		symbols := &debugger.SymbolTable{}
+		symbols.Init(f.cartridge.Mapper())
+		paths, err := symbols.LoadForROM(f.cartridge.ROM, debugger.INES_HEADER_SIZE+len(f.cartridge.Trainer()))
+		if err != nil {
+			fmt.Printf("[Warning] Symbols: %v\n", err)
+		}
+		if len(paths) > 0 {
+			fmt.Printf("[Debugger] loaded %d symbols from %s\n", symbols.Len(), strings.Join(paths, ", "))
+			f.symbols = symbols
+		}
+	}
+	f.cpu.AttachSymbols(f.symbols)
+	if f.debugger != nil {
+		f.debugger.SetSymbols(f.symbols)
+	}
+}
+
+// MARK: 実行ログの開始メソッド ("log" か開始の条件が設定されていれば)
+func (f *Famicom) startTraceLogger() {
+	trace := f.config.Cpu.TRACE
+	if !f.config.Cpu.LOG_ENABLED && trace.START_PC == "" && trace.START_FRAME == 0 {
+		return
+	}
+	if f.createTraceLogger() && !f.trace.HasStartTrigger() {
+		if err := f.trace.Start(); err != nil {
+			fmt.Printf("[Warning] Trace: %v\n", err)
+		}
+	}
+}
+
+// MARK: 実行ログの作成メソッド
+func (f *Famicom) createTraceLogger() bool {
+	if !f.romLoaded {
+		return false
+	}
+	path := f.config.Cpu.TRACE.FILE
+	if path == "" {
+		path = strings.TrimSuffix(f.cartridge.ROM, filepath.Ext(f.cartridge.ROM)) + debugger.TRACE_EXT
+	}
+	trace := &debugger.TraceLogger{}
+	if err := trace.Init(f.cartridge.Mapper(), f.config.Cpu.TRACE, path, f.symbols); err != nil {
+		fmt.Printf("[Warning] Trace: %v\n", err)
+		return false
+	}
+	f.trace = trace
+	f.cpu.AttachTraceLogger(f.trace)
+	return true
+}
+
+// MARK: 実行ログの開始・停止の切り替えメソッド (ホットキー)
+func (f *Famicom) toggleTraceLogger() {
+	if f.trace == nil && !f.createTraceLogger() {
+		return
+	}
+	if err := f.trace.Toggle(); err != nil {
+		fmt.Printf("[Warning] Trace: %v\n", err)
+	}
+}
+
+// MARK: 実行ログを閉じるメソッド
+func (f *Famicom) closeTraceLogger() {
+	if f.trace == nil {
+		return
+	}
+	if err := f.trace.Close(); err != nil {
+		fmt.Printf("Error writing trace log: %v\n", err)
+	}
+	f.trace = nil
+	f.cpu.AttachTraceLogger(nil)
+}
+
+// MARK: デバッガの有効化メソッド
+func (f *Famicom) enableDebugger() {
+	if f.debugger != nil {
+		return
+	}
+	f.debugger = &debugger.Debugger{}
+	f.debugger.Init()
+	f.debugger.SetSymbols(f.symbols)
+	f.cpu.AttachDebugger(f.debugger)
+	f.debugCommands = debugger.ReadCommands(os.Stdin)
+	fmt.Println("[Debugger] enabled, type 'h' for help")
+}
+
+// MARK: デバッガの一時停止・再開メソッド
+func (f *Famicom) toggleDebuggerPause() {
+	f.enableDebugger()
+	if f.debugger.Paused() {
+		f.debugger.Continue()
+	} else {
+		f.debugger.Pause()
+	}
+}
+
+// MARK: 標準入力から届いたデバッガのコマンドを実行するメソッド
+func (f *Famicom) pollDebuggerCommands() {
+	for {
+		select {
+		case line, ok := <-f.debugCommands:
+			if !ok {
+				f.debugCommands = nil
+				return
+			}
+			if result := f.debugger.Execute(line); result != "" {
+				fmt.Println(result)
+			}
+		default:
+			return
+		}
+	}
+}
+
 // MARK: ゲームの終了メソッド
 func (f *Famicom) requestShutdown() {
 	if f.windows != nil {
@@ -301,7 +543,9 @@ func (f *Famicom) requestShutdown() {
 	}
 	if f.romLoaded {
 		f.bus.Shutdown()
+		f.saveCodeDataLog()
 	}
+	f.closeTraceLogger()
 	os.Exit(0)
 }
 
@@ -344,6 +588,14 @@ func (f *Famicom) handleKeyPress(e *sdl.KeyboardEvent, c1 *InputState, c2 *Input
 		c2.Start = pressed
 	case f.config.Control.KEY_2P.BUTTON_SELECT:
 		c2.Select = pressed
+
+	// Vs. システム (押している間だけコインが投入される)
+	case f.config.Control.KEY_VS.COIN_1:
+		f.vsSystem.Coin1 = pressed
+	case f.config.Control.KEY_VS.COIN_2:
+		f.vsSystem.Coin2 = pressed
+	case f.config.Control.KEY_VS.SERVICE:
+		f.vsSystem.Service = pressed
 	}
 }
 
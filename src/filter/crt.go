@@ -0,0 +1,134 @@
+package filter
+
+import (
+	"strings"
+)
+
+// MARK: 定数定義
+const (
+	CRT_DEFAULT_STRENGTH = 0.3 // マスクの濃さの既定値
+	CRT_TRIAD_WIDTH      = 3   // シャドウマスクの1組 (R, G, B) の幅 [出力ピクセル]
+	CRT_DOT_HEIGHT       = 2   // シャドウマスクの1段の高さ [出力ピクセル]
+)
+
+// MARK: CRTマスクの種類の定義
+type CRTMask uint8
+
+const (
+	CRT_MASK_NONE CRTMask = iota
+	CRT_MASK_SCANLINES
+	CRT_MASK_SHADOWMASK
+	CRT_MASK_COUNT
+)
+
+var crtMaskNames = [CRT_MASK_COUNT]string{"none", "scanlines", "shadowmask"}
+
+// MARK: CRTマスク名からの変換
+func ParseCRTMask(name string) (CRTMask, bool) {
+	if name == "" {
+		return CRT_MASK_NONE, true
+	}
+	for mask, maskName := range crtMaskNames {
+		if strings.EqualFold(name, maskName) {
+			return CRTMask(mask), true
+		}
+	}
+	return CRT_MASK_NONE, false
+}
+
+// MARK: CRTマスク名の取得
+func (m CRTMask) String() string {
+	if m < CRT_MASK_COUNT {
+		return crtMaskNames[m]
+	}
+	return "unknown"
+}
+
+// MARK: 次のCRTマスクの取得 (ホットキーで巡回する)
+func (m CRTMask) Next() CRTMask {
+	return (m + 1) % CRT_MASK_COUNT
+}
+
+/*
+	CRTマスク (画面に乗算で重ねるRGB24の画像をCPUで作る)
+
+	scanlines  : 元の1ラインごとに下半分を暗くする (表示が2倍未満なら何もしない)
+	shadowmask : R, G, B の縦の列を並べ，1段ごとに半組ずらす + scanlines
+
+	白 (0xFF) の部分はそのまま，strength の分だけ暗くなる
+*/
+// MARK: CRTマスクの定義
+type CRT struct {
+	mask     CRTMask
+	strength float64
+	overlay  []byte
+}
+
+// MARK: CRTマスクの初期化メソッド
+func (c *CRT) Init(mask CRTMask, strength float64) {
+	if strength <= 0 {
+		strength = CRT_DEFAULT_STRENGTH
+	}
+	c.mask = mask
+	c.strength = min(strength, 1.0)
+	c.overlay = nil
+}
+
+// MARK: CRTマスクの種類の取得
+func (c *CRT) Mask() CRTMask {
+	return c.mask
+}
+
+// MARK: CRTマスクの種類の設定
+func (c *CRT) SetMask(mask CRTMask) {
+	c.mask = mask
+}
+
+// MARK: 重ねる画像の作成 (lineHeight は元の1ラインの表示上の高さ)
+func (c *CRT) Overlay(width int, height int, lineHeight float64) []byte {
+	size := width * height * 3
+	if cap(c.overlay) < size {
+		c.overlay = make([]byte, size)
+	}
+	out := c.overlay[:size]
+
+	dark := uint8(255 * (1 - c.strength))
+	for y := range height {
+		// 元のラインの下半分か
+		isGap := false
+		if lineHeight >= 2 {
+			position := float64(y) + 0.5
+			isGap = position-lineHeight*float64(int(position/lineHeight)) > lineHeight/2
+		}
+
+		for x := range width {
+			r, g, b := uint8(0xFF), uint8(0xFF), uint8(0xFF)
+			if c.mask == CRT_MASK_SHADOWMASK {
+				// 1段ごとに半組ずらして点を千鳥に並べる
+				column := x
+				if (y/CRT_DOT_HEIGHT)%2 == 1 {
+					column += CRT_TRIAD_WIDTH / 2
+				}
+				switch column % CRT_TRIAD_WIDTH {
+				case 0:
+					g, b = dark, dark
+				case 1:
+					r, b = dark, dark
+				default:
+					r, g = dark, dark
+				}
+			}
+			if isGap {
+				r, g, b = scaleChannel(r, c.strength), scaleChannel(g, c.strength), scaleChannel(b, c.strength)
+			}
+			i := (y*width + x) * 3
+			out[i], out[i+1], out[i+2] = r, g, b
+		}
+	}
+	return out
+}
+
+// MARK: チャンネルを strength の分だけ暗くする
+func scaleChannel(value uint8, strength float64) uint8 {
+	return uint8(float64(value) * (1 - strength))
+}
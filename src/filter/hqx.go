@@ -0,0 +1,377 @@
+package filter
+
+/*
+	hqx (Maxim Stepin)
+
+	中心 w4 と近傍8ピクセルをYUVの閾値で比べ，異なる近傍のビットを立てた
+	8ビットのパターン (256通り) と近傍どうしの比較から補間の方法を選ぶ
+
+	  w0 w1 w2      0x01 0x02 0x04
+	  w3 w4 w5  ->  0x08  --  0x10
+	  w6 w7 w8      0x20 0x40 0x80
+
+	表は左上の角 (hq3x は左上の角と上の辺) の分だけを持ち，
+	他の角は近傍を反転 (hq2x / hq4x) または回転 (hq3x) させて同じ表を引く
+*/
+// MARK: hqx の3x3の近傍の定義
+type hqxWindow struct {
+	pattern uint8 // 中心と異なる近傍のビット
+	rgb     [9]uint32
+	yuv     [9]uint32
+}
+
+// 近傍の並べ替え (新しい位置 i に元の位置 p[i] の近傍を置く)
+var (
+	hqxFlipX   = [9]int{2, 1, 0, 5, 4, 3, 8, 7, 6}
+	hqxFlipY   = [9]int{6, 7, 8, 3, 4, 5, 0, 1, 2}
+	hqxFlipXY  = [9]int{8, 7, 6, 5, 4, 3, 2, 1, 0}
+	hqxRotate1 = [9]int{2, 5, 8, 1, 4, 7, 0, 3, 6} // 右上の角を左上へ
+	hqxRotate2 = [9]int{8, 7, 6, 5, 4, 3, 2, 1, 0} // 右下の角を左上へ
+	hqxRotate3 = [9]int{6, 3, 0, 7, 4, 1, 8, 5, 2} // 左下の角を左上へ
+)
+
+// MARK: 3x3の近傍の取得 (画面外は端のピクセルで補う)
+func (s *Upscale) hqxGather(width int, height int, x int, y int, w *hqxWindow) {
+	for i := range 9 {
+		nx := min(max(x+i%3-1, 0), width-1)
+		ny := min(max(y+i/3-1, 0), height-1)
+		w.rgb[i] = s.pixels[ny*width+nx]
+		w.yuv[i] = s.yuv[ny*width+nx]
+	}
+	w.pattern = hqxPattern(&w.rgb, &w.yuv)
+}
+
+// MARK: 近傍のパターンの計算
+func hqxPattern(rgb *[9]uint32, yuv *[9]uint32) uint8 {
+	var pattern uint8
+	bit := uint8(1)
+	for i := range 9 {
+		if i == 4 {
+			continue
+		}
+		if rgb[i] != rgb[4] && !similar(yuv[i], yuv[4]) {
+			pattern |= bit
+		}
+		bit <<= 1
+	}
+	return pattern
+}
+
+// MARK: 近傍を並べ替えた窓の取得
+func (w *hqxWindow) permute(p *[9]int) hqxWindow {
+	var out hqxWindow
+	for i, from := range p {
+		out.rgb[i] = w.rgb[from]
+		out.yuv[i] = w.yuv[from]
+	}
+	out.pattern = hqxPattern(&out.rgb, &out.yuv)
+	return out
+}
+
+// MARK: パターンの一致 (マスクと期待値の組のどれかに一致するか)
+func (w *hqxWindow) match(pairs ...uint8) bool {
+	for i := 0; i < len(pairs); i += 2 {
+		if w.pattern&pairs[i] == pairs[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+// MARK: 近傍どうしの比較 (YUVの差が閾値を超えるか)
+func (w *hqxWindow) diff(a int, b int) bool {
+	return !similar(w.yuv[a], w.yuv[b])
+}
+
+// MARK: 近傍2色の補間
+func (w *hqxWindow) interp(a int, wa uint32, b int, wb uint32) uint32 {
+	return blend(w.rgb[a], w.rgb[b], wa, wb)
+}
+
+// MARK: 近傍3色の補間
+func (w *hqxWindow) interp3(a int, wa uint32, b int, wb uint32, c int, wc uint32) uint32 {
+	var out uint32
+	for shift := 0; shift <= 16; shift += 8 {
+		channel := ((w.rgb[a]>>shift&0xFF)*wa + (w.rgb[b]>>shift&0xFF)*wb + (w.rgb[c]>>shift&0xFF)*wc) / (wa + wb + wc)
+		out |= channel << shift
+	}
+	return out
+}
+
+// MARK: hq2x / hq3x / hq4x
+func (s *Upscale) hqx(width int, height int, scale int) {
+	outWidth := width * scale
+	var w hqxWindow
+
+	for y := range height {
+		for x := range width {
+			if s.isFlat(width, height, x, y) && s.isFlatCorners(width, height, x, y) {
+				s.fill(x, y, width, scale, s.pixels[y*width+x])
+				continue
+			}
+			s.hqxGather(width, height, x, y, &w)
+			put := func(sx int, sy int, color uint32) {
+				s.result[(y*scale+sy)*outWidth+x*scale+sx] = color
+			}
+
+			switch scale {
+			case 2:
+				fx, fy, fxy := w.permute(&hqxFlipX), w.permute(&hqxFlipY), w.permute(&hqxFlipXY)
+				put(0, 0, hq2xCorner(&w))
+				put(1, 0, hq2xCorner(&fx))
+				put(0, 1, hq2xCorner(&fy))
+				put(1, 1, hq2xCorner(&fxy))
+			case 3:
+				// 角と時計回りに隣の辺をまとめて求める
+				put(0, 0, hq3xCorner(&w))
+				put(1, 0, hq3xEdge(&w))
+				r1, r2, r3 := w.permute(&hqxRotate1), w.permute(&hqxRotate2), w.permute(&hqxRotate3)
+				put(2, 0, hq3xCorner(&r1))
+				put(2, 1, hq3xEdge(&r1))
+				put(2, 2, hq3xCorner(&r2))
+				put(1, 2, hq3xEdge(&r2))
+				put(0, 2, hq3xCorner(&r3))
+				put(0, 1, hq3xEdge(&r3))
+				put(1, 1, w.rgb[4])
+			case 4:
+				for i, p := range [4]*[9]int{nil, &hqxFlipX, &hqxFlipY, &hqxFlipXY} {
+					local := w
+					if p != nil {
+						local = w.permute(p)
+					}
+					quadrant := hq4xQuadrant(&local)
+					for k, color := range quadrant {
+						sx, sy := k%2, k/2
+						if i&1 != 0 {
+							sx = 3 - sx
+						}
+						if i&2 != 0 {
+							sy = 3 - sy
+						}
+						put(sx, sy, color)
+					}
+				}
+			}
+		}
+	}
+}
+
+// MARK: 斜めの近傍も同じ色か (hqx は斜めの近傍も見るので isFlat だけでは足りない)
+func (s *Upscale) isFlatCorners(width int, height int, x int, y int) bool {
+	e := s.pixels[y*width+x]
+	return pixelAt(s.pixels, width, height, x-1, y-1) == e && pixelAt(s.pixels, width, height, x+1, y-1) == e &&
+		pixelAt(s.pixels, width, height, x-1, y+1) == e && pixelAt(s.pixels, width, height, x+1, y+1) == e
+}
+
+// MARK: hq2x の左上の出力
+func hq2xCorner(w *hqxWindow) uint32 {
+	switch {
+	case w.match(0xbf, 0x37, 0xdb, 0x13) && w.diff(1, 5):
+		return w.interp(4, 3, 3, 1)
+	case w.match(0xdb, 0x49, 0xef, 0x6d) && w.diff(7, 3):
+		return w.interp(4, 3, 1, 1)
+	case w.match(0x0b, 0x0b, 0xfe, 0x4a, 0xfe, 0x1a) && w.diff(3, 1):
+		return w.rgb[4]
+	case w.match(0x6f, 0x2a, 0x5b, 0x0a, 0xbf, 0x3a, 0xdf, 0x5a, 0x9f, 0x8a, 0xcf, 0x8a, 0xef, 0x4e, 0x3f, 0x0e,
+		0xfb, 0x5a, 0xbb, 0x8a, 0x7f, 0x5a, 0xaf, 0x8a, 0xeb, 0x8a) && w.diff(3, 1):
+		return w.interp(4, 3, 0, 1)
+	case w.match(0x0b, 0x08):
+		return w.interp3(4, 2, 0, 1, 1, 1)
+	case w.match(0x0b, 0x02):
+		return w.interp3(4, 2, 0, 1, 3, 1)
+	case w.match(0x2f, 0x2f):
+		return w.interp3(4, 14, 3, 1, 1, 1)
+	case w.match(0xbf, 0x37, 0xdb, 0x13):
+		return w.interp3(4, 5, 1, 2, 3, 1)
+	case w.match(0xdb, 0x49, 0xef, 0x6d):
+		return w.interp3(4, 5, 3, 2, 1, 1)
+	case w.match(0x1b, 0x03, 0x4f, 0x43, 0x8b, 0x83, 0x6b, 0x43):
+		return w.interp(4, 3, 3, 1)
+	case w.match(0x4b, 0x09, 0x8b, 0x89, 0x1f, 0x19, 0x3b, 0x19):
+		return w.interp(4, 3, 1, 1)
+	case w.match(0x7e, 0x2a, 0xef, 0xab, 0xbf, 0x8f, 0x7e, 0x0e):
+		return w.interp3(4, 2, 3, 3, 1, 3)
+	case w.match(0xfb, 0x6a, 0x6f, 0x6e, 0x3f, 0x3e, 0xfb, 0xfa, 0xdf, 0xde, 0xdf, 0x1e):
+		return w.interp3(4, 6, 3, 1, 1, 1)
+	case w.match(0x0a, 0x00, 0x4f, 0x4b, 0x9f, 0x1b, 0x2f, 0x0b, 0xbe, 0x0a, 0xee, 0x0a, 0x7e, 0x0a, 0xeb, 0x4b, 0x3b, 0x1b):
+		return w.interp3(4, 2, 3, 1, 1, 1)
+	default:
+		return w.interp3(4, 6, 3, 1, 1, 1)
+	}
+}
+
+// MARK: hq3x の左上の角の出力
+func hq3xCorner(w *hqxWindow) uint32 {
+	switch {
+	case w.match(0xdb, 0x49, 0xef, 0x6d) && w.diff(7, 3):
+		return w.interp(4, 3, 1, 1)
+	case w.match(0xbf, 0x37, 0xdb, 0x13) && w.diff(1, 5):
+		return w.interp(4, 3, 3, 1)
+	case w.match(0x0b, 0x0b, 0xfe, 0x4a, 0xfe, 0x1a) && w.diff(3, 1):
+		return w.rgb[4]
+	case w.match(0x6f, 0x2a, 0x5b, 0x0a, 0xbf, 0x3a, 0xdf, 0x5a, 0x9f, 0x8a, 0xcf, 0x8a, 0xef, 0x4e, 0x3f, 0x0e,
+		0xfb, 0x5a, 0xbb, 0x8a, 0x7f, 0x5a, 0xaf, 0x8a, 0xeb, 0x8a) && w.diff(3, 1):
+		return w.interp(4, 3, 0, 1)
+	case w.match(0x4b, 0x09, 0x8b, 0x89, 0x1f, 0x19, 0x3b, 0x19):
+		return w.interp(4, 3, 1, 1)
+	case w.match(0x1b, 0x03, 0x4f, 0x43, 0x8b, 0x83, 0x6b, 0x43):
+		return w.interp(4, 3, 3, 1)
+	case w.match(0x7e, 0x2a, 0xef, 0xab, 0xbf, 0x8f, 0x7e, 0x0e):
+		return w.interp(3, 1, 1, 1)
+	case w.match(0x4f, 0x4b, 0x9f, 0x1b, 0x2f, 0x0b, 0xbe, 0x0a, 0xee, 0x0a, 0x7e, 0x0a, 0xeb, 0x4b, 0x3b, 0x1b):
+		return w.interp3(4, 2, 3, 7, 1, 7)
+	case w.match(0x0b, 0x08, 0xf9, 0x68, 0xf3, 0x62, 0x6d, 0x6c, 0x67, 0x66, 0x3d, 0x3c, 0x37, 0x36,
+		0xf9, 0xf8, 0xdd, 0xdc, 0xf3, 0xf2, 0xd7, 0xd6, 0xdd, 0x1c, 0xd7, 0x16, 0x0b, 0x02):
+		return w.interp(4, 3, 0, 1)
+	default:
+		return w.interp3(4, 2, 3, 1, 1, 1)
+	}
+}
+
+// MARK: hq3x の上の辺の出力
+func hq3xEdge(w *hqxWindow) uint32 {
+	switch {
+	case w.match(0x17, 0x16, 0x5b, 0x12, 0x96, 0x16, 0xbb, 0x12, 0xda, 0x12, 0xf3, 0x12) && w.diff(1, 5):
+		return w.rgb[4]
+	case w.match(0x0f, 0x0b, 0x5e, 0x0a, 0x2b, 0x0b, 0xbe, 0x0a, 0x7a, 0x0a, 0xee, 0x0a) && w.diff(1, 3):
+		return w.rgb[4]
+	case w.match(0xbf, 0x8f, 0x7e, 0x0e, 0xbf, 0x37, 0xdb, 0x13):
+		return w.interp(1, 3, 4, 1)
+	case w.match(0x02, 0x00, 0x7c, 0x28, 0xed, 0xa9, 0xf5, 0xb4, 0xd9, 0x90):
+		return w.interp(4, 3, 1, 1)
+	case w.match(0x4f, 0x4b, 0xfb, 0x7b, 0xfe, 0x7e, 0x9f, 0x1b, 0x2f, 0x0b, 0xbe, 0x0a, 0x7e, 0x0a, 0xfb, 0x4b,
+		0xfb, 0xdb, 0xfe, 0xde, 0xfe, 0x56, 0x57, 0x56, 0x97, 0x16, 0x3f, 0x1e, 0xdb, 0x12, 0xbb, 0x12):
+		return w.interp(4, 7, 1, 1)
+	default:
+		return w.rgb[4]
+	}
+}
+
+// MARK: hq4x の左上の2x2の出力 (00, 01, 10, 11 の順)
+func hq4xQuadrant(w *hqxWindow) [4]uint32 {
+	cond00 := w.match(0xbf, 0x37, 0xdb, 0x13) && w.diff(1, 5)
+	cond01 := w.match(0xdb, 0x49, 0xef, 0x6d) && w.diff(7, 3)
+	cond02 := w.match(0x6f, 0x2a, 0x5b, 0x0a, 0xbf, 0x3a, 0xdf, 0x5a, 0x9f, 0x8a, 0xcf, 0x8a, 0xef, 0x4e, 0x3f, 0x0e,
+		0xfb, 0x5a, 0xbb, 0x8a, 0x7f, 0x5a, 0xaf, 0x8a, 0xeb, 0x8a) && w.diff(3, 1)
+	cond03 := w.match(0xdb, 0x49, 0xef, 0x6d)
+	cond04 := w.match(0xbf, 0x37, 0xdb, 0x13)
+	cond05 := w.match(0x1b, 0x03, 0x4f, 0x43, 0x8b, 0x83, 0x6b, 0x43)
+	cond06 := w.match(0x4b, 0x09, 0x8b, 0x89, 0x1f, 0x19, 0x3b, 0x19)
+	cond07 := w.match(0x0b, 0x08, 0xf9, 0x68, 0xf3, 0x62, 0x6d, 0x6c, 0x67, 0x66, 0x3d, 0x3c, 0x37, 0x36,
+		0xf9, 0xf8, 0xdd, 0xdc, 0xf3, 0xf2, 0xd7, 0xd6, 0xdd, 0x1c, 0xd7, 0x16, 0x0b, 0x02)
+	cond08 := w.match(0x0f, 0x0b, 0x2b, 0x0b, 0xfe, 0x4a, 0xfe, 0x1a) && w.diff(3, 1)
+	cond09 := w.match(0x2f, 0x2f)
+	cond10 := w.match(0x0a, 0x00)
+	cond11 := w.match(0x0b, 0x09)
+	cond12 := w.match(0x7e, 0x2a, 0xef, 0xab)
+	cond13 := w.match(0xbf, 0x8f, 0x7e, 0x0e)
+	cond14 := w.match(0x4f, 0x4b, 0x9f, 0x1b, 0x2f, 0x0b, 0xbe, 0x0a, 0xee, 0x0a, 0x7e, 0x0a, 0xeb, 0x4b, 0x3b, 0x1b)
+	cond15 := w.match(0x0b, 0x03)
+	c := w.rgb[4]
+
+	var out [4]uint32
+	switch {
+	case cond00:
+		out[0] = w.interp(4, 5, 3, 3)
+	case cond01:
+		out[0] = w.interp(4, 5, 1, 3)
+	case w.match(0x0b, 0x0b, 0xfe, 0x4a, 0xfe, 0x1a) && w.diff(3, 1):
+		out[0] = c
+	case cond02:
+		out[0] = w.interp(4, 5, 0, 3)
+	case cond03:
+		out[0] = w.interp(4, 3, 3, 1)
+	case cond04:
+		out[0] = w.interp(4, 3, 1, 1)
+	case cond05:
+		out[0] = w.interp(4, 5, 3, 3)
+	case cond06:
+		out[0] = w.interp(4, 5, 1, 3)
+	case w.match(0x0f, 0x0b, 0x5e, 0x0a, 0x2b, 0x0b, 0xbe, 0x0a, 0x7a, 0x0a, 0xee, 0x0a):
+		out[0] = w.interp(1, 1, 3, 1)
+	case cond07:
+		out[0] = w.interp(4, 5, 0, 3)
+	default:
+		out[0] = w.interp3(4, 2, 1, 1, 3, 1)
+	}
+
+	switch {
+	case cond00:
+		out[1] = w.interp(4, 7, 3, 1)
+	case cond08:
+		out[1] = c
+	case cond02:
+		out[1] = w.interp(4, 3, 0, 1)
+	case cond09:
+		out[1] = c
+	case cond10:
+		out[1] = w.interp3(4, 5, 1, 2, 3, 1)
+	case w.match(0x0b, 0x08):
+		out[1] = w.interp3(4, 5, 1, 2, 0, 1)
+	case cond11:
+		out[1] = w.interp(4, 5, 1, 3)
+	case cond04:
+		out[1] = w.interp(1, 3, 4, 1)
+	case cond12:
+		out[1] = w.interp3(1, 2, 4, 1, 3, 1)
+	case cond13:
+		out[1] = w.interp(1, 5, 3, 3)
+	case cond05:
+		out[1] = w.interp(4, 7, 3, 1)
+	case w.match(0xf3, 0x62, 0x67, 0x66, 0x37, 0x36, 0xf3, 0xf2, 0xd7, 0xd6, 0xd7, 0x16, 0x0b, 0x02):
+		out[1] = w.interp(4, 3, 0, 1)
+	case cond14:
+		out[1] = w.interp(1, 1, 4, 1)
+	default:
+		out[1] = w.interp(4, 3, 1, 1)
+	}
+
+	switch {
+	case cond01:
+		out[2] = w.interp(4, 7, 1, 1)
+	case cond08:
+		out[2] = c
+	case cond02:
+		out[2] = w.interp(4, 3, 0, 1)
+	case cond09:
+		out[2] = c
+	case cond10:
+		out[2] = w.interp3(4, 5, 3, 2, 1, 1)
+	case w.match(0x0b, 0x02):
+		out[2] = w.interp3(4, 5, 3, 2, 0, 1)
+	case cond15:
+		out[2] = w.interp(4, 5, 3, 3)
+	case cond03:
+		out[2] = w.interp(3, 3, 4, 1)
+	case cond13:
+		out[2] = w.interp3(3, 2, 4, 1, 1, 1)
+	case cond12:
+		out[2] = w.interp(3, 5, 1, 3)
+	case cond06:
+		out[2] = w.interp(4, 7, 1, 1)
+	case w.match(0x0b, 0x08, 0xf9, 0x68, 0x6d, 0x6c, 0x3d, 0x3c, 0xf9, 0xf8, 0xdd, 0xdc, 0xdd, 0x1c):
+		out[2] = w.interp(4, 3, 0, 1)
+	case cond14:
+		out[2] = w.interp(3, 1, 4, 1)
+	default:
+		out[2] = w.interp(4, 3, 3, 1)
+	}
+
+	switch {
+	case w.match(0x7f, 0x2b, 0xef, 0xab, 0xbf, 0x8f, 0x7f, 0x0f) && w.diff(3, 1):
+		out[3] = c
+	case cond02:
+		out[3] = w.interp(4, 7, 0, 1)
+	case cond15:
+		out[3] = w.interp(4, 7, 3, 1)
+	case cond11:
+		out[3] = w.interp(4, 7, 1, 1)
+	case w.match(0x0a, 0x00, 0x7e, 0x2a, 0xef, 0xab, 0xbf, 0x8f, 0x7e, 0x0e):
+		out[3] = w.interp3(4, 6, 3, 1, 1, 1)
+	case cond07:
+		out[3] = w.interp(4, 7, 0, 1)
+	default:
+		out[3] = c
+	}
+	return out
+}
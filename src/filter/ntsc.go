@@ -0,0 +1,204 @@
+package filter
+
+import (
+	"Famicom-emulator/ppu"
+	"math"
+	"strings"
+)
+
+// MARK: 定数定義
+const (
+	NTSC_SAMPLES_PER_PIXEL = 8 // 1ピクセルあたりの信号のサンプル数 (マスタークロックの半周期)
+	NTSC_PHASES            = 12
+	NTSC_LINE_PHASE_SHIFT  = 341 * NTSC_SAMPLES_PER_PIXEL % NTSC_PHASES // 1ラインごとの色副搬送波の位相のずれ
+	NTSC_FRAME_PHASES      = 3                                          // ドットクロールの周期 [フレーム]
+	NTSC_OUTPUT_SCALE      = 2                                          // 1ピクセルあたりの出力の横幅
+	NTSC_LINE_SAMPLES      = int(ppu.SCREEN_WIDTH) * NTSC_SAMPLES_PER_PIXEL
+	NTSC_OUTPUT_WIDTH      = int(ppu.SCREEN_WIDTH) * NTSC_OUTPUT_SCALE
+	NTSC_OUTPUT_HEIGHT     = int(ppu.SCREEN_HEIGHT)
+)
+
+// MARK: NTSCフィルタのプリセットの定義
+type NTSCPreset uint8
+
+const (
+	NTSC_PRESET_NONE NTSCPreset = iota
+	NTSC_PRESET_COMPOSITE
+	NTSC_PRESET_SVIDEO
+	NTSC_PRESET_RGB
+	NTSC_PRESET_COUNT
+)
+
+var ntscPresetNames = [NTSC_PRESET_COUNT]string{"none", "composite", "svideo", "rgb"}
+
+// MARK: プリセットごとの復調の設定
+type ntscSetup struct {
+	lumaWidth   int  // 輝度のローパスの幅 [サンプル]
+	chromaWidth int  // 色差のローパスの幅 [サンプル]
+	separated   bool // 輝度と色差が別の信号 (S-Video)
+	rgb         bool // 信号を通さずにRGBをそのまま出力
+}
+
+var ntscSetups = [NTSC_PRESET_COUNT]ntscSetup{
+	NTSC_PRESET_COMPOSITE: {lumaWidth: NTSC_PHASES, chromaWidth: NTSC_PHASES * 2},
+	NTSC_PRESET_SVIDEO:    {lumaWidth: NTSC_SAMPLES_PER_PIXEL / 2, chromaWidth: NTSC_PHASES, separated: true},
+	NTSC_PRESET_RGB:       {rgb: true},
+}
+
+// MARK: プリセット名からの変換
+func ParseNTSCPreset(name string) (NTSCPreset, bool) {
+	if name == "" {
+		return NTSC_PRESET_NONE, true
+	}
+	for preset, presetName := range ntscPresetNames {
+		if strings.EqualFold(name, presetName) {
+			return NTSCPreset(preset), true
+		}
+	}
+	return NTSC_PRESET_NONE, false
+}
+
+// MARK: プリセット名の取得
+func (p NTSCPreset) String() string {
+	if p < NTSC_PRESET_COUNT {
+		return ntscPresetNames[p]
+	}
+	return "unknown"
+}
+
+// MARK: 次のプリセットの取得 (ホットキーで巡回する)
+func (p NTSCPreset) Next() NTSCPreset {
+	return (p + 1) % NTSC_PRESET_COUNT
+}
+
+/*
+	NTSCフィルタ (blargg の nes_ntsc と同じ考え方)
+
+	1. PPUのカラー番号 (色強調を含む9bit) から，1ピクセル8サンプルのコンポジット信号を作る
+	   色副搬送波は12サンプルで1周期なので，ラインごと・フレームごとに位相がずれる (ドットクロール)
+	2. 輝度はローパス，色差は副搬送波で復調してからローパスをかける
+	   コンポジットでは輝度に色差が漏れ込み (色のにじみ・アーティファクト)，S-Videoでは漏れ込まない
+	3. YIQ を RGB に変換し，横2倍の解像度で出力する
+*/
+// MARK: NTSCフィルタの定義
+type NTSC struct {
+	preset NTSCPreset
+	frame  uint
+
+	// カラー番号と位相ごとの信号 (輝度 / 副搬送波で復調した I / Q)
+	composite [ppu.EMPHASIS_PALETTE_SIZE][NTSC_PHASES]ntscSample
+	separated [ppu.EMPHASIS_PALETTE_SIZE][NTSC_PHASES]ntscSample // S-Video (輝度に色副搬送波を含まない)
+
+	// 1ライン分の累積和 (ローパスを区間の平均で計算する)
+	sumY [NTSC_LINE_SAMPLES + 1]float64
+	sumI [NTSC_LINE_SAMPLES + 1]float64
+	sumQ [NTSC_LINE_SAMPLES + 1]float64
+
+	output [NTSC_OUTPUT_WIDTH * NTSC_OUTPUT_HEIGHT * 3]byte
+}
+
+// MARK: 1サンプル分の信号の定義
+type ntscSample struct {
+	y, i, q float64
+}
+
+// MARK: NTSCフィルタの初期化メソッド
+func (n *NTSC) Init(preset NTSCPreset) {
+	n.preset = preset
+	n.frame = 0
+
+	hue := ppu.NTSC_HUE_OFFSET * math.Pi / 180
+	for color := range n.composite {
+		var levels [NTSC_PHASES]float64
+		luma := 0.0
+		for phase := range NTSC_PHASES {
+			levels[phase] = ppu.NTSCLevel(uint16(color), phase)
+			luma += levels[phase] / NTSC_PHASES
+		}
+
+		for phase, level := range levels {
+			angle := 2*math.Pi*float64(phase)/NTSC_PHASES + hue
+			cos, sin := math.Cos(angle), math.Sin(angle)
+			n.composite[color][phase] = ntscSample{y: level, i: level * cos, q: level * sin}
+			n.separated[color][phase] = ntscSample{y: luma, i: (level - luma) * cos, q: (level - luma) * sin}
+		}
+	}
+}
+
+// MARK: プリセットの取得
+func (n *NTSC) Preset() NTSCPreset {
+	return n.preset
+}
+
+// MARK: プリセットの設定
+func (n *NTSC) SetPreset(preset NTSCPreset) {
+	n.preset = preset
+}
+
+// MARK: 1フレーム分のカラー番号にフィルタをかけてRGB24の画像を取得 (pixelsはPPUがパレットで描いた同じフレーム)
+func (n *NTSC) Apply(colors *[uint(ppu.SCREEN_WIDTH) * uint(ppu.SCREEN_HEIGHT)]uint16, pixels *[uint(ppu.SCREEN_WIDTH) * uint(ppu.SCREEN_HEIGHT) * 3]byte) []byte {
+	setup := ntscSetups[n.preset]
+	framePhase := int(n.frame%NTSC_FRAME_PHASES) * NTSC_LINE_PHASE_SHIFT
+	n.frame++
+
+	for y := range NTSC_OUTPUT_HEIGHT {
+		line := colors[y*int(ppu.SCREEN_WIDTH) : (y+1)*int(ppu.SCREEN_WIDTH)]
+		out := n.output[y*NTSC_OUTPUT_WIDTH*3 : (y+1)*NTSC_OUTPUT_WIDTH*3]
+
+		if setup.rgb {
+			row := pixels[y*int(ppu.SCREEN_WIDTH)*3 : (y+1)*int(ppu.SCREEN_WIDTH)*3]
+			for x := range NTSC_OUTPUT_WIDTH {
+				src := x / NTSC_OUTPUT_SCALE * 3
+				copy(out[x*3:x*3+3], row[src:src+3])
+			}
+			continue
+		}
+
+		phase := (framePhase + y*NTSC_LINE_PHASE_SHIFT) % NTSC_PHASES
+		n.modulateLine(line, phase, setup.separated)
+
+		for x := range NTSC_OUTPUT_WIDTH {
+			// 出力ピクセルの中心のサンプル
+			center := x*NTSC_SAMPLES_PER_PIXEL/NTSC_OUTPUT_SCALE + NTSC_SAMPLES_PER_PIXEL/NTSC_OUTPUT_SCALE/2
+			luma := average(n.sumY[:], center, setup.lumaWidth)
+			i := 2 * average(n.sumI[:], center, setup.chromaWidth)
+			q := 2 * average(n.sumQ[:], center, setup.chromaWidth)
+			rgb := ppu.YIQToRGB(luma, i, q, ppu.DEFAULT_PALETTE_GAMMA)
+			copy(out[x*3:x*3+3], rgb[:])
+		}
+	}
+	return n.output[:]
+}
+
+// MARK: 1ライン分の信号を作り，輝度と復調した色差の累積和を計算
+func (n *NTSC) modulateLine(line []uint16, phase int, separated bool) {
+	table := &n.composite
+	if separated {
+		table = &n.separated
+	}
+
+	k := 0
+	for _, color := range line {
+		samples := &table[color]
+		for range NTSC_SAMPLES_PER_PIXEL {
+			sample := &samples[phase]
+			n.sumY[k+1] = n.sumY[k] + sample.y
+			n.sumI[k+1] = n.sumI[k] + sample.i
+			n.sumQ[k+1] = n.sumQ[k] + sample.q
+			k++
+			if phase++; phase == NTSC_PHASES {
+				phase = 0
+			}
+		}
+	}
+}
+
+// MARK: 累積和から center を中心とした幅 width の区間の平均を取得 (ラインの端は切り詰める)
+func average(sum []float64, center int, width int) float64 {
+	start := max(center-width/2, 0)
+	end := min(start+width, len(sum)-1)
+	if end <= start {
+		return 0
+	}
+	return (sum[end] - sum[start]) / float64(end-start)
+}
@@ -0,0 +1,427 @@
+package filter
+
+import (
+	"Famicom-emulator/ppu"
+	"strings"
+)
+
+// MARK: 定数定義
+const (
+	UPSCALE_MAX = 4 // 拡大率の最大
+
+	// hqx / xBR の色の比較に使うYUVの閾値と重み (hqx と同じ値)
+	YUV_THRESHOLD_Y = 48
+	YUV_THRESHOLD_U = 7
+	YUV_THRESHOLD_V = 6
+)
+
+// MARK: 拡大フィルタの定義
+type Upscaler uint8
+
+const (
+	UPSCALER_NEAREST Upscaler = iota
+	UPSCALER_SCALE2X
+	UPSCALER_SCALE3X
+	UPSCALER_SCALE4X
+	UPSCALER_HQ2X
+	UPSCALER_HQ3X
+	UPSCALER_HQ4X
+	UPSCALER_XBR2X
+	UPSCALER_XBR3X
+	UPSCALER_XBR4X
+	UPSCALER_COUNT
+)
+
+var upscalerNames = [UPSCALER_COUNT]string{"nearest", "scale2x", "scale3x", "scale4x", "hq2x", "hq3x", "hq4x", "xbr2x", "xbr3x", "xbr4x"}
+var upscalerScales = [UPSCALER_COUNT]int{1, 2, 3, 4, 2, 3, 4, 2, 3, 4}
+
+// MARK: 拡大フィルタ名からの変換
+func ParseUpscaler(name string) (Upscaler, bool) {
+	if name == "" || strings.EqualFold(name, "none") {
+		return UPSCALER_NEAREST, true
+	}
+	for upscaler, upscalerName := range upscalerNames {
+		if strings.EqualFold(name, upscalerName) {
+			return Upscaler(upscaler), true
+		}
+	}
+	return UPSCALER_NEAREST, false
+}
+
+// MARK: 拡大フィルタ名の取得
+func (u Upscaler) String() string {
+	if u < UPSCALER_COUNT {
+		return upscalerNames[u]
+	}
+	return "unknown"
+}
+
+// MARK: 次の拡大フィルタの取得 (ホットキーで巡回する)
+func (u Upscaler) Next() Upscaler {
+	return (u + 1) % UPSCALER_COUNT
+}
+
+// MARK: 拡大率の取得
+func (u Upscaler) Scale() int {
+	if u < UPSCALER_COUNT {
+		return upscalerScales[u]
+	}
+	return 1
+}
+
+/*
+	拡大フィルタ (すべてCPUで処理し，同じ入力には常に同じ出力を返す)
+
+	nearest       : 拡大しない (SDLの最近傍補間に任せる)
+	scale2x/3x    : EPX / AdvMAME3x の規則で上下左右の一致からエッジを伸ばす
+	scale4x       : scale2x を2回かける
+	hq2x/3x/4x    : Maxim Stepin の hqx (近傍のYUVの差の256通りのパターンから補間の方法を選ぶ)
+	xbr2x/3x/4x   : Hyllian の xBR (レベル1) の重み付きエッジ判定で45度のエッジを滑らかにする
+*/
+// MARK: 拡大フィルタの処理の定義
+type Upscale struct {
+	upscaler Upscaler
+
+	pixels  []uint32 // 入力の 0xRRGGBB
+	yuv     []uint32 // 入力の 0xYYUUVV
+	scratch []uint32 // scale4x の中間結果
+	result  []uint32
+	output  []byte
+}
+
+// MARK: 拡大フィルタの初期化メソッド
+func (s *Upscale) Init(upscaler Upscaler) {
+	size := int(ppu.SCREEN_WIDTH * ppu.SCREEN_HEIGHT)
+	s.upscaler = upscaler
+	s.pixels = make([]uint32, size)
+	s.yuv = make([]uint32, size)
+	s.scratch = make([]uint32, size*2*2)
+	s.result = make([]uint32, size*UPSCALE_MAX*UPSCALE_MAX)
+	s.output = make([]byte, size*UPSCALE_MAX*UPSCALE_MAX*3)
+}
+
+// MARK: 拡大フィルタの取得
+func (s *Upscale) Upscaler() Upscaler {
+	return s.upscaler
+}
+
+// MARK: 拡大フィルタの設定
+func (s *Upscale) SetUpscaler(upscaler Upscaler) {
+	s.upscaler = upscaler
+}
+
+// MARK: RGB24の画像を拡大 (出力の幅と高さも返す)
+func (s *Upscale) Apply(src []byte, width int, height int) ([]byte, int, int) {
+	scale := s.upscaler.Scale()
+	if scale == 1 {
+		return src, width, height
+	}
+
+	for i := range width * height {
+		s.pixels[i] = uint32(src[i*3])<<16 | uint32(src[i*3+1])<<8 | uint32(src[i*3+2])
+		s.yuv[i] = rgbToYUV(s.pixels[i])
+	}
+
+	switch s.upscaler {
+	case UPSCALER_SCALE2X:
+		scale2x(s.pixels, width, height, s.result)
+	case UPSCALER_SCALE3X:
+		scale3x(s.pixels, width, height, s.result)
+	case UPSCALER_SCALE4X:
+		scale2x(s.pixels, width, height, s.scratch)
+		scale2x(s.scratch, width*2, height*2, s.result)
+	case UPSCALER_HQ2X, UPSCALER_HQ3X, UPSCALER_HQ4X:
+		s.hqx(width, height, scale)
+	case UPSCALER_XBR2X, UPSCALER_XBR3X, UPSCALER_XBR4X:
+		s.xbr(width, height, scale)
+	}
+
+	outWidth, outHeight := width*scale, height*scale
+	for i, c := range s.result[:outWidth*outHeight] {
+		s.output[i*3] = uint8(c >> 16)
+		s.output[i*3+1] = uint8(c >> 8)
+		s.output[i*3+2] = uint8(c)
+	}
+	return s.output[:outWidth*outHeight*3], outWidth, outHeight
+}
+
+// MARK: 画面外を端のピクセルで補って取得
+func pixelAt(pixels []uint32, width int, height int, x int, y int) uint32 {
+	x = min(max(x, 0), width-1)
+	y = min(max(y, 0), height-1)
+	return pixels[y*width+x]
+}
+
+/*
+	EPX / Scale2x
+
+	  A B C      E0 E1
+	  D E F  ->  E2 E3
+	  G H I
+*/
+// MARK: Scale2x (EPX)
+func scale2x(src []uint32, width int, height int, dst []uint32) {
+	for y := range height {
+		for x := range width {
+			b := pixelAt(src, width, height, x, y-1)
+			d := pixelAt(src, width, height, x-1, y)
+			e := src[y*width+x]
+			f := pixelAt(src, width, height, x+1, y)
+			h := pixelAt(src, width, height, x, y+1)
+
+			e0, e1, e2, e3 := e, e, e, e
+			if b != h && d != f {
+				if d == b {
+					e0 = d
+				}
+				if b == f {
+					e1 = f
+				}
+				if d == h {
+					e2 = d
+				}
+				if h == f {
+					e3 = f
+				}
+			}
+
+			top := (y*2)*width*2 + x*2
+			bottom := top + width*2
+			dst[top], dst[top+1] = e0, e1
+			dst[bottom], dst[bottom+1] = e2, e3
+		}
+	}
+}
+
+// MARK: Scale3x (AdvMAME3x)
+func scale3x(src []uint32, width int, height int, dst []uint32) {
+	for y := range height {
+		for x := range width {
+			a := pixelAt(src, width, height, x-1, y-1)
+			b := pixelAt(src, width, height, x, y-1)
+			c := pixelAt(src, width, height, x+1, y-1)
+			d := pixelAt(src, width, height, x-1, y)
+			e := src[y*width+x]
+			f := pixelAt(src, width, height, x+1, y)
+			g := pixelAt(src, width, height, x-1, y+1)
+			h := pixelAt(src, width, height, x, y+1)
+			i := pixelAt(src, width, height, x+1, y+1)
+
+			out := [9]uint32{e, e, e, e, e, e, e, e, e}
+			if b != h && d != f {
+				if d == b {
+					out[0] = d
+				}
+				if (d == b && e != c) || (b == f && e != a) {
+					out[1] = b
+				}
+				if b == f {
+					out[2] = f
+				}
+				if (d == b && e != g) || (d == h && e != a) {
+					out[3] = d
+				}
+				if (b == f && e != i) || (h == f && e != c) {
+					out[5] = f
+				}
+				if d == h {
+					out[6] = d
+				}
+				if (d == h && e != i) || (h == f && e != g) {
+					out[7] = h
+				}
+				if h == f {
+					out[8] = f
+				}
+			}
+
+			for row := range 3 {
+				copy(dst[(y*3+row)*width*3+x*3:], out[row*3:row*3+3])
+			}
+		}
+	}
+}
+
+/*
+	xBR は右下の角を基準に処理し，他の角は近傍を回転させて同じ処理を使う
+
+	      A1 B1 C1
+	   A0 A  B  C  C4
+	   D0 D  E  F  F4
+	   G0 G  H  I  I4
+	      G5 H5 I5
+*/
+// MARK: 角ごとの近傍の回転 (右下 → 左下 → 左上 → 右上)
+func rotate(corner int, dx int, dy int) (int, int) {
+	for range corner {
+		dx, dy = -dy, dx
+	}
+	return dx, dy
+}
+
+// MARK: 出力のサブピクセルの定義
+type subpixel struct {
+	corner   int     // 属する角 (0: 右下, 1: 左下, 2: 左上, 3: 右上)
+	coverage float64 // 角を右下とした座標 u + v (0 ~ 2)
+}
+
+// MARK: 拡大率ごとのサブピクセルの一覧を取得
+func subpixels(scale int) []subpixel {
+	out := make([]subpixel, 0, scale*scale)
+	for sy := range scale {
+		for sx := range scale {
+			u := (float64(sx) + 0.5) / float64(scale)
+			v := (float64(sy) + 0.5) / float64(scale)
+			switch {
+			case u >= 0.5 && v >= 0.5:
+				out = append(out, subpixel{0, u + v})
+			case u < 0.5 && v >= 0.5:
+				out = append(out, subpixel{1, v + 1 - u})
+			case u < 0.5 && v < 0.5:
+				out = append(out, subpixel{2, 2 - u - v})
+			default:
+				out = append(out, subpixel{3, 1 - v + u})
+			}
+		}
+	}
+	return out
+}
+
+// MARK: 5x5の近傍の定義
+type neighborhood struct {
+	rgb [5][5]uint32
+	yuv [5][5]uint32
+}
+
+// MARK: 5x5の近傍の取得 (画面外は端のピクセルで補う)
+func (s *Upscale) gather(width int, height int, x int, y int, n *neighborhood) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			nx := min(max(x+dx, 0), width-1)
+			ny := min(max(y+dy, 0), height-1)
+			n.rgb[dy+2][dx+2] = s.pixels[ny*width+nx]
+			n.yuv[dy+2][dx+2] = s.yuv[ny*width+nx]
+		}
+	}
+}
+
+// MARK: 角を右下とした座標で近傍を取得
+func (n *neighborhood) at(corner int, dx int, dy int) (uint32, uint32) {
+	dx, dy = rotate(corner, dx, dy)
+	return n.rgb[dy+2][dx+2], n.yuv[dy+2][dx+2]
+}
+
+// MARK: 上下左右が同じ色か (補間するエッジがないので拡大するだけでよい)
+func (s *Upscale) isFlat(width int, height int, x int, y int) bool {
+	e := s.pixels[y*width+x]
+	return pixelAt(s.pixels, width, height, x, y-1) == e && pixelAt(s.pixels, width, height, x-1, y) == e &&
+		pixelAt(s.pixels, width, height, x+1, y) == e && pixelAt(s.pixels, width, height, x, y+1) == e
+}
+
+// MARK: 拡大したピクセルを1色で埋める
+func (s *Upscale) fill(x int, y int, width int, scale int, color uint32) {
+	outWidth := width * scale
+	for sy := range scale {
+		row := (y*scale+sy)*outWidth + x*scale
+		for sx := range scale {
+			s.result[row+sx] = color
+		}
+	}
+}
+
+// MARK: xBR (レベル1)
+func (s *Upscale) xbr(width int, height int, scale int) {
+	sub := subpixels(scale)
+	outWidth := width * scale
+	var n neighborhood
+
+	for y := range height {
+		for x := range width {
+			if s.isFlat(width, height, x, y) {
+				s.fill(x, y, width, scale, s.pixels[y*width+x])
+				continue
+			}
+			s.gather(width, height, x, y, &n)
+			e, eYUV := n.at(0, 0, 0)
+
+			// 角ごとにエッジの判定をしておく
+			var edges [4]bool
+			var colors [4]uint32
+			for corner := range 4 {
+				at := func(dx int, dy int) uint32 {
+					_, yuv := n.at(corner, dx, dy)
+					return yuv
+				}
+				f, fYUV := n.at(corner, 1, 0)
+				h, hYUV := n.at(corner, 0, 1)
+				i, c, g := at(1, 1), at(1, -1), at(-1, 1)
+				b, d := at(0, -1), at(-1, 0)
+				f4, h5 := at(2, 0), at(0, 2)
+				i4, i5 := at(2, 1), at(1, 2)
+
+				// 右下の角を横切る (F-H 方向の) エッジの方が弱ければ補間する
+				wd1 := distance(eYUV, c) + distance(eYUV, g) + distance(i, f4) + distance(i, h5) + 4*distance(hYUV, fYUV)
+				wd2 := distance(hYUV, d) + distance(hYUV, i5) + distance(fYUV, i4) + distance(fYUV, b) + 4*distance(eYUV, i)
+				if wd1 < wd2 && eYUV != fYUV && eYUV != hYUV {
+					edges[corner] = true
+					colors[corner] = h
+					if distance(eYUV, fYUV) <= distance(eYUV, hYUV) {
+						colors[corner] = f
+					}
+				}
+			}
+
+			// 角の中点を結ぶ45度の線より外側を新しい色に，線上は半分混ぜる
+			for k, p := range sub {
+				color := e
+				if coverage := p.coverage - 1.5; edges[p.corner] && coverage > 0.1 {
+					color = colors[p.corner]
+				} else if edges[p.corner] && coverage > -0.1 {
+					color = blend(e, colors[p.corner], 1, 1)
+				}
+				s.result[(y*scale+k/scale)*outWidth+x*scale+k%scale] = color
+			}
+		}
+	}
+}
+
+// MARK: 0xRRGGBB を 0xYYUUVV へ変換
+func rgbToYUV(c uint32) uint32 {
+	r, g, b := int(c>>16&0xFF), int(c>>8&0xFF), int(c&0xFF)
+	y := (r*299 + g*587 + b*114) / 1000
+	u := (-r*169-g*331+b*500)/1000 + 128
+	v := (r*500-g*419-b*81)/1000 + 128
+	return uint32(y)<<16 | uint32(u)<<8 | uint32(v)
+}
+
+// MARK: YUVの差が閾値以内かどうか (hqx)
+func similar(a uint32, b uint32) bool {
+	return absDiff(a>>16&0xFF, b>>16&0xFF) <= YUV_THRESHOLD_Y &&
+		absDiff(a>>8&0xFF, b>>8&0xFF) <= YUV_THRESHOLD_U &&
+		absDiff(a&0xFF, b&0xFF) <= YUV_THRESHOLD_V
+}
+
+// MARK: YUVの重み付きの距離 (xBR)
+func distance(a uint32, b uint32) int {
+	return YUV_THRESHOLD_Y*absDiff(a>>16&0xFF, b>>16&0xFF) +
+		YUV_THRESHOLD_U*absDiff(a>>8&0xFF, b>>8&0xFF) +
+		YUV_THRESHOLD_V*absDiff(a&0xFF, b&0xFF)
+}
+
+// MARK: 差の絶対値
+func absDiff(a uint32, b uint32) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// MARK: 2色を重み付きで混ぜる
+func blend(a uint32, b uint32, wa uint32, wb uint32) uint32 {
+	var c uint32
+	for shift := 0; shift <= 16; shift += 8 {
+		channel := ((a>>shift&0xFF)*wa + (b>>shift&0xFF)*wb) / (wa + wb)
+		c |= channel << shift
+	}
+	return c
+}
@@ -0,0 +1,382 @@
+package filter
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// テスト用の色 (入力と期待値の文字との対応)
+var testColors = map[byte]uint32{
+	'.': 0x000000,
+	'#': 0xFFFFFF,
+	'r': 0xFF0000,
+	'a': 0x7F7F7F, // 黒と白の中間
+	'd': 0xFF7F7F, // 白と赤の中間
+	'e': 0x3F3F3F, // 黒 5 : 白 3
+	'f': 0xBFBFBF, // 黒 1 : 白 3
+	'g': 0xDFDFDF, // 黒 1 : 白 7
+	'h': 0x1F1F1F, // 黒 7 : 白 1
+	'i': 0xFFDFDF, // 白 14 : 赤 2
+}
+
+// 45度のエッジ
+var diagonal = []string{
+	"#...",
+	"##..",
+	"###.",
+	"####",
+}
+
+// 3色が接する角
+var threeColors = []string{
+	"...",
+	".#r",
+	".rr",
+}
+
+// テストヘルパー関数：文字の並びをRGB24の画像に変換
+func decodeImage(t *testing.T, rows []string) ([]byte, int, int) {
+	t.Helper()
+	var out []byte
+	for _, row := range rows {
+		for i := range len(row) {
+			c, ok := testColors[row[i]]
+			if !ok {
+				t.Fatalf("unknown color '%c'", row[i])
+			}
+			out = append(out, uint8(c>>16), uint8(c>>8), uint8(c))
+		}
+	}
+	return out, len(rows[0]), len(rows)
+}
+
+// テストヘルパー関数：RGB24の画像を文字の並びに変換 (対応する文字がない色は ?)
+func encodeImage(image []byte, width int, height int) string {
+	names := make(map[uint32]byte)
+	for name, c := range testColors {
+		names[c] = name
+	}
+	var out strings.Builder
+	for y := range height {
+		for x := range width {
+			i := (y*width + x) * 3
+			c := uint32(image[i])<<16 | uint32(image[i+1])<<8 | uint32(image[i+2])
+			name, ok := names[c]
+			if !ok {
+				name = '?'
+			}
+			out.WriteByte(name)
+		}
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+// TestUpscaleApply は各拡大フィルタの出力が期待値と一致することをテストします
+func TestUpscaleApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		upscaler Upscaler
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "scale2x diagonal",
+			upscaler: UPSCALER_SCALE2X,
+			input:    diagonal,
+			expected: []string{
+				"##......",
+				"###.....",
+				"###.....",
+				"#####...",
+				"#####...",
+				"#######.",
+				"########",
+				"########",
+			},
+		},
+		{
+			name:     "scale3x diagonal",
+			upscaler: UPSCALER_SCALE3X,
+			input:    diagonal,
+			expected: []string{
+				"###.........",
+				"####........",
+				"####........",
+				"#####.......",
+				"######......",
+				"#######.....",
+				"########....",
+				"#########...",
+				"###########.",
+				"############",
+				"############",
+				"############",
+			},
+		},
+		{
+			name:     "scale4x diagonal",
+			upscaler: UPSCALER_SCALE4X,
+			input:    diagonal,
+			expected: []string{
+				"####............",
+				"#####...........",
+				"#####...........",
+				"######..........",
+				"######..........",
+				"#######.........",
+				"#########.......",
+				"##########......",
+				"##########......",
+				"###########.....",
+				"#############...",
+				"###############.",
+				"################",
+				"################",
+				"################",
+				"################",
+			},
+		},
+		{
+			name:     "hq2x diagonal",
+			upscaler: UPSCALER_HQ2X,
+			input:    diagonal,
+			expected: []string{
+				"##e.....",
+				"##f.....",
+				"###a....",
+				"####a...",
+				"#####a..",
+				"######fe",
+				"########",
+				"########",
+			},
+		},
+		{
+			name:     "hq3x diagonal",
+			upscaler: UPSCALER_HQ3X,
+			input:    diagonal,
+			expected: []string{
+				"###e........",
+				"###f........",
+				"####e.......",
+				"####gh......",
+				"#####gh.....",
+				"######gh....",
+				"#######gh...",
+				"########ge..",
+				"##########fe",
+				"############",
+				"############",
+				"############",
+			},
+		},
+		{
+			name:     "hq4x diagonal",
+			upscaler: UPSCALER_HQ4X,
+			input:    diagonal,
+			expected: []string{
+				"####e...........",
+				"####f...........",
+				"#####e..........",
+				"#####f..........",
+				"######a.........",
+				"#######a........",
+				"########a.......",
+				"#########a......",
+				"##########a.....",
+				"###########a....",
+				"############fe..",
+				"##############fe",
+				"################",
+				"################",
+				"################",
+				"################",
+			},
+		},
+		{
+			name:     "xbr2x diagonal",
+			upscaler: UPSCALER_XBR2X,
+			input:    diagonal,
+			expected: []string{
+				"##......",
+				"##a.....",
+				"###a....",
+				"####a...",
+				"#####a..",
+				"######a.",
+				"########",
+				"########",
+			},
+		},
+		{
+			name:     "xbr3x diagonal",
+			upscaler: UPSCALER_XBR3X,
+			input:    diagonal,
+			expected: []string{
+				"###.........",
+				"###.........",
+				"####........",
+				"#####.......",
+				"######......",
+				"#######.....",
+				"########....",
+				"#########...",
+				"##########..",
+				"############",
+				"############",
+				"############",
+			},
+		},
+		{
+			name:     "xbr4x diagonal",
+			upscaler: UPSCALER_XBR4X,
+			input:    diagonal,
+			expected: []string{
+				"####............",
+				"####............",
+				"####a...........",
+				"#####a..........",
+				"######a.........",
+				"#######a........",
+				"########a.......",
+				"#########a......",
+				"##########a.....",
+				"###########a....",
+				"############a...",
+				"#############a..",
+				"################",
+				"################",
+				"################",
+				"################",
+			},
+		},
+		{
+			name:     "hq2x three colors",
+			upscaler: UPSCALER_HQ2X,
+			input:    threeColors,
+			expected: []string{
+				"......",
+				"......",
+				"..g#rr",
+				"..#irr",
+				"..rrrr",
+				"..rrrr",
+			},
+		},
+		{
+			name:     "hq3x three colors",
+			upscaler: UPSCALER_HQ3X,
+			input:    threeColors,
+			expected: []string{
+				".........",
+				".........",
+				".........",
+				"...a##rrr",
+				"...###rrr",
+				"...##drrr",
+				"...rrrrrr",
+				"...rrrrrr",
+				"...rrrrrr",
+			},
+		},
+		{
+			name:     "hq4x three colors",
+			upscaler: UPSCALER_HQ4X,
+			input:    threeColors,
+			expected: []string{
+				"............",
+				"............",
+				"............",
+				"............",
+				"....a###rrrr",
+				"....####rrrr",
+				"....####rrrr",
+				"....###drrrr",
+				"....rrrrrrrr",
+				"....rrrrrrrr",
+				"....rrrrrrrr",
+				"....rrrrrrrr",
+			},
+		},
+		{
+			name:     "xbr4x three colors",
+			upscaler: UPSCALER_XBR4X,
+			input:    threeColors,
+			expected: []string{
+				"............",
+				"............",
+				"............",
+				"............",
+				".....adrrrrr",
+				"....a##drrrr",
+				"....d##drrrr",
+				"....rddrrrrr",
+				"....rrrrrrrr",
+				"....rrrrrrrr",
+				"....rrrrrrrr",
+				"....rrrrrrrr",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, width, height := decodeImage(t, tt.input)
+			var upscale Upscale
+			upscale.Init(tt.upscaler)
+
+			out, outWidth, outHeight := upscale.Apply(src, width, height)
+			scale := tt.upscaler.Scale()
+			if outWidth != width*scale || outHeight != height*scale {
+				t.Fatalf("size = %dx%d, want %dx%d", outWidth, outHeight, width*scale, height*scale)
+			}
+
+			actual := encodeImage(out, outWidth, outHeight)
+			expected := strings.Join(tt.expected, "\n") + "\n"
+			if actual != expected {
+				t.Errorf("output =\n%s\nwant\n%s", actual, expected)
+			}
+
+			// 同じ入力には常に同じ出力を返す
+			again, _, _ := upscale.Apply(src, width, height)
+			if encodeImage(again, outWidth, outHeight) != actual {
+				t.Errorf("output changed on the second run")
+			}
+		})
+	}
+}
+
+// TestHqxSymmetry は hqx の表が転置と左右反転で対称になっていることをテストします
+// (左上の角の表を反転や回転で他の角にも使うため，表が対称でないと角ごとに出力がずれる)
+func TestHqxSymmetry(t *testing.T) {
+	palette := []uint32{0x000000, 0xFFFFFF, 0xFF0000, 0x808080, 0x0000FF, 0x00FF00, 0x202020}
+	transpose := [9]int{0, 3, 6, 1, 4, 7, 2, 5, 8}
+	rng := rand.New(rand.NewSource(1))
+
+	for n := range 200000 {
+		var w hqxWindow
+		for i := range w.rgb {
+			w.rgb[i] = palette[rng.Intn(2+n%(len(palette)-1))]
+			w.yuv[i] = rgbToYUV(w.rgb[i])
+		}
+		w.pattern = hqxPattern(&w.rgb, &w.yuv)
+		wt := w.permute(&transpose)
+		wx := w.permute(&hqxFlipX)
+
+		if hq2xCorner(&w) != hq2xCorner(&wt) {
+			t.Fatalf("hq2x: pattern %02X is not symmetric", w.pattern)
+		}
+		if hq3xCorner(&w) != hq3xCorner(&wt) {
+			t.Fatalf("hq3x corner: pattern %02X is not symmetric", w.pattern)
+		}
+		if hq3xEdge(&w) != hq3xEdge(&wx) {
+			t.Fatalf("hq3x edge: pattern %02X is not symmetric", w.pattern)
+		}
+		q, qt := hq4xQuadrant(&w), hq4xQuadrant(&wt)
+		if q[0] != qt[0] || q[1] != qt[2] || q[2] != qt[1] || q[3] != qt[3] {
+			t.Fatalf("hq4x: pattern %02X is not symmetric", w.pattern)
+		}
+	}
+}
@@ -51,14 +51,11 @@ func (j *JoyPad) Write(data uint8) {
 	}
 }
 
-// MARK: JoyPadの読み取り
+// MARK: JoyPadの読み取り (bit0のみ，上位ビットはバス側でオープンバスを重ねる)
 func (j *JoyPad) Read() uint8 {
-	// bit 6 を立てておく
-	const openBus uint8 = 0x40
-
 	// ストローブHigh: Aボタン(bit0)を返し続ける（インデックスは進めない）
 	if j.strobe {
-		return openBus | (j.State & 0x01)
+		return j.State & 0x01
 	}
 
 	// ストローブLow: ラッチした8bitを順番に返す
@@ -71,7 +68,7 @@ func (j *JoyPad) Read() uint8 {
 	}
 
 	j.ButtonIndex++
-	return openBus | bit
+	return bit
 }
 
 // MARK: ボタン押下状態のセット
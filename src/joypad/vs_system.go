@@ -0,0 +1,64 @@
+package joypad
+
+// MARK: 定数定義
+const (
+	VS_SERVICE_POSITION = 2 // $4016 bit2: サービスボタン
+	VS_DIP_LOWER_POS    = 3 // $4016 bit3-4: DIPスイッチ1-2
+	VS_COIN_1_POSITION  = 5 // $4016 bit5: コイン投入口1
+	VS_COIN_2_POSITION  = 6 // $4016 bit6: コイン投入口2
+	VS_DIP_UPPER_POS    = 2 // $4017 bit2-7: DIPスイッチ3-8
+)
+
+// MARK: Vs. システムの筐体入力の定義
+type VsSystem struct {
+	Coin1       bool  // コイン投入口1
+	Coin2       bool  // コイン投入口2
+	Service     bool  // サービスボタン (クレジット追加)
+	DipSwitches uint8 // DIPスイッチ (bit0 = スイッチ1)
+}
+
+// MARK: Vs. システムの筐体入力の初期化メソッド
+func (v *VsSystem) Init(dipSwitches uint8) {
+	v.Coin1 = false
+	v.Coin2 = false
+	v.Service = false
+	v.DipSwitches = dipSwitches
+}
+
+// MARK: $4016の読み取り値を生成
+func (v *VsSystem) Read4016(serial uint8) uint8 {
+	/*
+		76543210
+		0CCDDS0P
+		 ||||| +- コントローラのシリアルデータ
+		 ||||+--- サービスボタン
+		 ||++---- DIPスイッチ1-2
+		 ++------ コイン投入口1-2
+		(bit7はデュアルシステムのメイン側なので0)
+	*/
+	value := serial & 0b1
+	value |= boolBit(v.Service) << VS_SERVICE_POSITION
+	value |= (v.DipSwitches & 0b11) << VS_DIP_LOWER_POS
+	value |= boolBit(v.Coin1) << VS_COIN_1_POSITION
+	value |= boolBit(v.Coin2) << VS_COIN_2_POSITION
+	return value
+}
+
+// MARK: $4017の読み取り値を生成
+func (v *VsSystem) Read4017(serial uint8) uint8 {
+	/*
+		76543210
+		DDDDDD0P
+		||||||  +- コントローラのシリアルデータ
+		++++++---- DIPスイッチ3-8
+	*/
+	return serial&0b1 | (v.DipSwitches>>2)<<VS_DIP_UPPER_POS
+}
+
+// MARK: boolを1bitに変換
+func boolBit(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
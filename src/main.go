@@ -2,10 +2,22 @@ package main
 
 import (
 	"Famicom-emulator/config"
+	"Famicom-emulator/disasm"
+	"fmt"
+	"os"
 )
 
 // MARK: main関数
 func main() {
+	// サブコマンド: ROMの逆アセンブル
+	if len(os.Args) > 1 && os.Args[1] == "disasm" {
+		if err := disasm.Run(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	rom, config := config.ParseArguments()
 	famicom := Famicom{}
 	famicom.Init(rom, config)
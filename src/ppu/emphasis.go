@@ -0,0 +1,66 @@
+package ppu
+
+// MARK: 定数定義
+const (
+	EMPHASIS_PALETTE_SIZE = 512   // 6bitのパレット値 × 3bitの色強調
+	EMPHASIS_ATTENUATION  = 0.816 // 強調されていない色の減衰率 (2C02)
+)
+
+/*
+	色強調 ($2001 bit5-7)
+
+	2C02 (家庭用)
+		強調したビットごとに，それ以外のチャンネルを減衰させる (全て立てると画面全体が暗くなる)
+		$xE/$xF の黒は減衰しない
+	2C03/2C04/2C05 (Vs. システムのRGB PPU)
+		強調したチャンネルを最大の明るさにする
+*/
+// MARK: 64色のパレットから色強調を含む512色のパレットを生成
+func emphasisPalette(base [64][3]uint8, isRGB bool) [EMPHASIS_PALETTE_SIZE][3]uint8 {
+	var palette [EMPHASIS_PALETTE_SIZE][3]uint8
+	for color := range palette {
+		value := color & 0x3F
+		emphasis := color >> 6
+		rgb := base[value]
+
+		for channel := range rgb {
+			switch {
+			case isRGB && emphasis&(1<<channel) != 0:
+				rgb[channel] = 0xFF
+			case isRGB || value&0x0F >= 0x0E:
+				// RGB PPUで強調されていないチャンネルと，2C02の黒はそのまま
+			default:
+				level := float64(rgb[channel])
+				for other := range rgb {
+					if other != channel && emphasis&(1<<other) != 0 {
+						level *= EMPHASIS_ATTENUATION
+					}
+				}
+				rgb[channel] = uint8(level)
+			}
+		}
+		palette[color] = rgb
+	}
+	return palette
+}
+
+// MARK: パレットの値から9bitのカラー番号を取得 (グレースケールと色強調を適用)
+func (p *PPU) colorIndex(value uint8) uint16 {
+	value = p.applyGrayscale(value)
+
+	emphasis := p.mask.Emphasis()
+	if p.config.System.REGION.SwapsRedGreenEmphasis() {
+		// 2C07 (PAL) では赤と緑の強調ビットが入れ替わっている
+		emphasis = emphasis&0b100 | (emphasis&0b001)<<1 | (emphasis&0b010)>>1
+	}
+	return uint16(emphasis)<<6 | uint16(value)
+}
+
+// MARK: グレースケールの適用 (輝度のbit4-5だけを残す)
+func (p *PPU) applyGrayscale(value uint8) uint8 {
+	value &= 0x3F
+	if p.mask.grayscale {
+		value &= 0x30
+	}
+	return value
+}
@@ -0,0 +1,126 @@
+package ppu
+
+import (
+	"Famicom-emulator/config"
+	"math"
+)
+
+// MARK: 定数定義
+const (
+	NTSC_BLACK       = 0.518 // 黒の電圧 (同期信号基準)
+	NTSC_WHITE       = 1.962 // 白の電圧
+	NTSC_ATTENUATION = 0.746 // 色強調による減衰率
+	NTSC_PHASES      = 12    // 色副搬送波1周期あたりの位相数
+	NTSC_HUE_OFFSET  = 116.0 // カラーバーストに対する位相0の色相 [度]
+
+	DEFAULT_PALETTE_GAMMA = 2.2
+)
+
+// 輝度ごとの矩形波の電圧 (Low / High)
+var ntscLevels = [2][4]float64{
+	{0.350, 0.518, 0.962, 1.550},
+	{1.094, 1.506, 1.962, 1.962},
+}
+
+/*
+	NTSCパレットの生成
+
+	PPUは色ごとに位相のずれた矩形波を出力するため，12位相分の信号を YIQ に復調して RGB へ変換する
+	色強調は，強調した色以外の位相で信号を減衰させる
+	https://www.nesdev.org/wiki/NTSC_video
+*/
+// MARK: NTSCの信号から512色のパレットを生成
+func GenerateNTSCPalette(c config.PaletteConfig) [EMPHASIS_PALETTE_SIZE][3]uint8 {
+	saturation, contrast, gamma := c.SATURATION, c.CONTRAST, c.GAMMA
+	if saturation == 0 {
+		saturation = 1.0
+	}
+	if contrast == 0 {
+		contrast = 1.0
+	}
+	if gamma == 0 {
+		gamma = DEFAULT_PALETTE_GAMMA
+	}
+	hue := (c.HUE + NTSC_HUE_OFFSET) * math.Pi / 180
+
+	var palette [EMPHASIS_PALETTE_SIZE][3]uint8
+	for color := range palette {
+		var y, i, q float64
+		for phase := range NTSC_PHASES {
+			level := NTSCLevel(uint16(color), phase)
+			angle := 2*math.Pi*float64(phase)/NTSC_PHASES + hue
+			y += level
+			i += level * math.Cos(angle)
+			q += level * math.Sin(angle)
+		}
+		// 復調すると色差信号の振幅は半分になるため2倍する
+		y = y/NTSC_PHASES*contrast + c.BRIGHTNESS
+		i = i / NTSC_PHASES * 2 * saturation
+		q = q / NTSC_PHASES * 2 * saturation
+
+		palette[color] = YIQToRGB(y, i, q, gamma)
+	}
+	return palette
+}
+
+// MARK: 1位相分のNTSC信号を黒=0, 白=1 に正規化して取得
+func NTSCLevel(color uint16, phase int) float64 {
+	return (ntscSignal(color, phase) - NTSC_BLACK) / (NTSC_WHITE - NTSC_BLACK)
+}
+
+// MARK: YIQからRGBへの変換 (FCC)
+func YIQToRGB(y float64, i float64, q float64, gamma float64) [3]uint8 {
+	return [3]uint8{
+		gammaCorrect(y+0.956*i+0.621*q, gamma),
+		gammaCorrect(y-0.272*i-0.647*q, gamma),
+		gammaCorrect(y-1.106*i+1.703*q, gamma),
+	}
+}
+
+// MARK: 1位相分のNTSC信号の電圧を取得
+func ntscSignal(color uint16, phase int) float64 {
+	hue := int(color & 0x0F)
+	luma := (color >> 4) & 0x03
+	emphasis := color >> 6
+
+	// $xE/$xF は輝度1の黒
+	if hue > 0x0D {
+		luma = 1
+	}
+	low := ntscLevels[0][luma]
+	high := ntscLevels[1][luma]
+	if hue == 0x00 {
+		low = high
+	}
+	if hue > 0x0C {
+		high = low
+	}
+
+	inPhase := func(hue int) bool {
+		return (hue+phase)%NTSC_PHASES < NTSC_PHASES/2
+	}
+
+	signal := low
+	if inPhase(hue) {
+		signal = high
+	}
+
+	// 赤 (色相0) / 緑 (色相4) / 青 (色相8) の位相で減衰させる
+	if (emphasis&0b001 != 0 && inPhase(0)) ||
+		(emphasis&0b010 != 0 && inPhase(4)) ||
+		(emphasis&0b100 != 0 && inPhase(8)) {
+		signal *= NTSC_ATTENUATION
+	}
+	return signal
+}
+
+// MARK: ガンマ補正して8bitへ変換
+func gammaCorrect(value float64, gamma float64) uint8 {
+	if value <= 0 {
+		return 0
+	}
+	if gamma != DEFAULT_PALETTE_GAMMA {
+		value = math.Pow(value, DEFAULT_PALETTE_GAMMA/gamma)
+	}
+	return uint8(min(value, 1) * 255)
+}
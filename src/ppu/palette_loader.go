@@ -0,0 +1,155 @@
+package ppu
+
+import (
+	"Famicom-emulator/config"
+	"fmt"
+	"os"
+)
+
+// MARK: NTSCパレットの調整項目の定義
+type PaletteControl uint8
+
+const (
+	PALETTE_CONTROL_HUE PaletteControl = iota
+	PALETTE_CONTROL_SATURATION
+	PALETTE_CONTROL_CONTRAST
+	PALETTE_CONTROL_BRIGHTNESS
+	PALETTE_CONTROL_GAMMA
+	PALETTE_CONTROL_COUNT
+)
+
+// MARK: 調整項目ごとの設定
+type paletteControlSetup struct {
+	name    string
+	step    float64                                // 1回の調整幅
+	min     float64                                // 下限 (hasMinのときだけ)
+	hasMin  bool                                   // 0が既定値を意味する項目は正の値に留める
+	initial float64                                // 設定が0のときの実際の値
+	field   func(c *config.PaletteConfig) *float64 // 設定の項目
+}
+
+var paletteControlSetups = [PALETTE_CONTROL_COUNT]paletteControlSetup{
+	PALETTE_CONTROL_HUE: {
+		name: "hue", step: 5.0,
+		field: func(c *config.PaletteConfig) *float64 { return &c.HUE },
+	},
+	PALETTE_CONTROL_SATURATION: {
+		name: "saturation", step: 0.1, min: 0.05, hasMin: true, initial: 1.0,
+		field: func(c *config.PaletteConfig) *float64 { return &c.SATURATION },
+	},
+	PALETTE_CONTROL_CONTRAST: {
+		name: "contrast", step: 0.1, min: 0.05, hasMin: true, initial: 1.0,
+		field: func(c *config.PaletteConfig) *float64 { return &c.CONTRAST },
+	},
+	PALETTE_CONTROL_BRIGHTNESS: {
+		name: "brightness", step: 0.05,
+		field: func(c *config.PaletteConfig) *float64 { return &c.BRIGHTNESS },
+	},
+	PALETTE_CONTROL_GAMMA: {
+		name: "gamma", step: 0.1, min: 0.1, hasMin: true, initial: DEFAULT_PALETTE_GAMMA,
+		field: func(c *config.PaletteConfig) *float64 { return &c.GAMMA },
+	},
+}
+
+// MARK: 調整項目の名前の取得
+func (c PaletteControl) String() string {
+	if c < PALETTE_CONTROL_COUNT {
+		return paletteControlSetups[c].name
+	}
+	return "unknown"
+}
+
+// MARK: .palファイル (RGB×64色 or RGB×512色) を512色のパレットとして読み込み
+func LoadEmphasisPaletteFile(path string) ([EMPHASIS_PALETTE_SIZE][3]uint8, error) {
+	var palette [EMPHASIS_PALETTE_SIZE][3]uint8
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return palette, err
+	}
+	if len(data) < len(palette)*3 {
+		// 64色のファイルは色強調を計算で補う
+		base, err := LoadPaletteFile(path)
+		if err != nil {
+			return palette, err
+		}
+		return emphasisPalette(base, false), nil
+	}
+	for i := range palette {
+		copy(palette[i][:], data[i*3:i*3+3])
+	}
+	return palette, nil
+}
+
+// MARK: パレットの選択 (Vs. システムのRGB PPU / NTSCの生成 / .palファイル / 内蔵)
+func (p *PPU) applyPalette() {
+	if p.config.System.VS_PPU.IsRGB() {
+		p.applyVsPalette()
+		return
+	}
+
+	c := p.config.Ppu.PALETTE
+	switch {
+	case c.GENERATE:
+		p.emphasisPalette = GenerateNTSCPalette(c)
+	case c.FILE != "":
+		palette, err := LoadEmphasisPaletteFile(c.FILE)
+		if err != nil {
+			fmt.Printf("[Warning] PPU: failed to load palette file (%v), using the built-in palette.\n", err)
+			palette = emphasisPalette(PALETTE, false)
+		}
+		p.emphasisPalette = palette
+	default:
+		p.emphasisPalette = emphasisPalette(PALETTE, false)
+	}
+
+	// デバッグウィンドウは色強調なしの64色を使う
+	copy(p.palette[:], p.emphasisPalette[:len(p.palette)])
+}
+
+// MARK: 表示に使う64色のパレットの取得メソッド (色強調なし，デバッグウィンドウ用)
+func (p *PPU) Palette() *[64][3]uint8 {
+	return &p.palette
+}
+
+// MARK: NTSCパレットの生成の有効/無効の切り替えメソッド
+func (p *PPU) TogglePaletteGenerator() {
+	c := &p.config.Ppu.PALETTE
+	c.GENERATE = !c.GENERATE
+	if c.GENERATE {
+		fmt.Println("[PPU] Palette generator: ON")
+	} else {
+		fmt.Println("[PPU] Palette generator: OFF")
+	}
+	p.applyPalette()
+}
+
+// MARK: 調整するNTSCパレットの項目の切り替えメソッド
+func (p *PPU) SelectPaletteControl(steps int) {
+	count := int(PALETTE_CONTROL_COUNT)
+	p.paletteControl = PaletteControl(((int(p.paletteControl)+steps)%count + count) % count)
+	setup := paletteControlSetups[p.paletteControl]
+	fmt.Printf("[PPU] Palette control: %s (%.2f)\n", p.paletteControl, setup.value(&p.config.Ppu.PALETTE))
+}
+
+// MARK: 選択中のNTSCパレットの項目の調整メソッド
+func (p *PPU) AdjustPalette(steps int) {
+	c := &p.config.Ppu.PALETTE
+	c.GENERATE = true
+	setup := paletteControlSetups[p.paletteControl]
+	value := setup.value(c) + float64(steps)*setup.step
+	if setup.hasMin {
+		value = max(value, setup.min)
+	}
+	*setup.field(c) = value
+	fmt.Printf("[PPU] Palette %s: %.2f\n", p.paletteControl, value)
+	p.applyPalette()
+}
+
+// MARK: 調整項目の現在の値の取得 (0が既定値を意味する項目は既定値に読み替える)
+func (s paletteControlSetup) value(c *config.PaletteConfig) float64 {
+	value := *s.field(c)
+	if value == 0 && s.hasMin {
+		return s.initial
+	}
+	return value
+}
@@ -0,0 +1,433 @@
+package ppu
+
+import "math/bits"
+
+// MARK: 定数定義
+const (
+	SECONDARY_OAM_SIZE uint = 32 // 8スプライト × 4byte
+	OAM_SPRITE_COUNT   uint = 64
+
+	DOT_VISIBLE_END        = 256 // 可視ドットの最後 (1 ~ 256)
+	DOT_SECONDARY_OAM_END  = 64  // secondary OAM のクリアの最後 (1 ~ 64)
+	DOT_SPRITE_EVAL_START  = 65  // スプライト評価の開始
+	DOT_SPRITE_FETCH_START = 257 // スプライトのフェッチの開始
+	DOT_SPRITE_FETCH_END   = 320 // スプライトのフェッチの最後
+	DOT_PREFETCH_START     = 321 // 次のラインの先頭2タイルのフェッチの開始
+	DOT_PREFETCH_END       = 336 // 次のラインの先頭2タイルのフェッチの最後
+)
+
+// MARK: スプライトの出力ユニットの定義 (1ライン分の1スプライト)
+type spriteUnit struct {
+	patternLow  uint8 // 水平反転を適用済みのパターン (bit7 が左端)
+	patternHigh uint8
+	attribute   uint8
+	x           uint8
+}
+
+/*
+	1ドットの処理 (可視ライン・プリレンダーライン)
+
+	背景
+		1 ~ 256, 321 ~ 336 : 8ドットごとに NT → AT → パターン下位 → パターン上位 をフェッチし，
+		                     8ドット目で coarse X をインクリメント，次の8ドットの始めでシフトレジスタに読み込む
+		256                : fine Y をインクリメント
+		257                : t の水平ビットを v にコピー
+		280 ~ 304          : t の垂直ビットを v にコピー (プリレンダーラインのみ)
+		338, 340           : 未使用の NT フェッチ
+
+	スプライト
+		1 ~ 64    : secondary OAM を $FF でクリア
+		65 ~ 256  : 奇数ドットで OAM を読み，偶数ドットで secondary OAM に書く (スプライト評価)
+		257 ~ 320 : secondary OAM の8スプライトのパターンをフェッチ (次のラインに表示)
+*/
+// MARK: レンダリング中の1ドットの処理
+func (p *PPU) renderDot(canvas *Canvas, dot uint, isRenderLine bool, isPreRenderLine bool) {
+	isRenderingEnabled := p.mask.backgroundEnable || p.mask.spriteEnable
+
+	if isRenderingEnabled {
+		p.backgroundDot(dot, isPreRenderLine)
+		if isRenderLine {
+			p.spriteEvaluationDot(dot)
+		}
+		p.spriteFetchDot(dot, isPreRenderLine)
+	}
+
+	// 1 ~ 256ドットでピクセルを出力
+	if isRenderLine && 1 <= dot && dot <= DOT_VISIBLE_END {
+		p.outputPixel(canvas, dot-1, isRenderingEnabled)
+	}
+}
+
+// MARK: 背景のフェッチとシフトレジスタの処理
+func (p *PPU) backgroundDot(dot uint, isPreRenderLine bool) {
+	isFetchDot := (1 <= dot && dot <= DOT_VISIBLE_END) || (DOT_PREFETCH_START <= dot && dot <= DOT_PREFETCH_END)
+
+	// シフトレジスタは2 ~ 257, 322 ~ 337 ドットでシフトする
+	if (2 <= dot && dot <= DOT_SPRITE_FETCH_START) || (DOT_PREFETCH_START+1 <= dot && dot <= DOT_PREFETCH_END+1) {
+		p.shiftBackground()
+		// 8ドットごとに次のタイルを読み込む
+		if dot%TILE_SIZE == 1 {
+			p.reloadBackground()
+		}
+	}
+
+	if isFetchDot {
+		switch dot % TILE_SIZE {
+		case 1: // ネームテーブル
+			p.bgNameTableByte = p.fetchNameTable(tileAddress(p.v))
+		case 3: // 属性テーブル (2bitのパレット番号を取り出しておく)
+			attribute := p.fetchNameTable(attributeAddress(p.v))
+			shift := (p.v.coarseY&0x02)<<1 | p.v.coarseX&0x02
+			p.bgAttribute = (attribute >> shift) & 0b11
+		case 5: // パターン (下位)
+			address := p.control.BackgroundPatternTableAddress() + uint16(p.bgNameTableByte)*uint16(TILE_SIZE*2) + uint16(p.v.fineY)
+			p.bgPatternLow = p.readPattern(address)
+		case 7: // パターン (上位)
+			address := p.control.BackgroundPatternTableAddress() + uint16(p.bgNameTableByte)*uint16(TILE_SIZE*2) + uint16(p.v.fineY)
+			p.bgPatternHigh = p.readPattern(address + uint16(TILE_SIZE))
+		case 0: // 水平アドレスをインクリメント
+			p.v.incrementCoarseX()
+		}
+	}
+
+	switch {
+	case dot == DOT_VISIBLE_END:
+		// 垂直アドレスをインクリメント
+		p.v.incrementY()
+	case dot == DOT_SPRITE_FETCH_START:
+		// 水平ビットのコピー (t -> v)
+		p.t.copyHorizontalBitsTo(&p.v)
+	case dot == 338 || dot == 340:
+		// 未使用のネームテーブルのフェッチ
+		p.bgNameTableByte = p.fetchNameTable(tileAddress(p.v))
+	}
+
+	// プリレンダーラインでのみ垂直ビットをコピー (t -> v)
+	if isPreRenderLine && 280 <= dot && dot <= 304 {
+		p.t.copyVerticalBitsTo(&p.v)
+	}
+}
+
+// MARK: 背景のシフトレジスタを1ドット分シフト
+func (p *PPU) shiftBackground() {
+	p.bgShiftPatternLow <<= 1
+	p.bgShiftPatternHigh <<= 1
+	p.bgShiftAttributeLow <<= 1
+	p.bgShiftAttributeHigh <<= 1
+}
+
+// MARK: フェッチしたタイルを背景のシフトレジスタの下位8bitに読み込む
+func (p *PPU) reloadBackground() {
+	p.bgShiftPatternLow = p.bgShiftPatternLow&0xFF00 | uint16(p.bgPatternLow)
+	p.bgShiftPatternHigh = p.bgShiftPatternHigh&0xFF00 | uint16(p.bgPatternHigh)
+
+	// 属性はタイル内で同じなので8bit分に広げる
+	var low, high uint16
+	if p.bgAttribute&0b01 != 0 {
+		low = 0x00FF
+	}
+	if p.bgAttribute&0b10 != 0 {
+		high = 0x00FF
+	}
+	p.bgShiftAttributeLow = p.bgShiftAttributeLow&0xFF00 | low
+	p.bgShiftAttributeHigh = p.bgShiftAttributeHigh&0xFF00 | high
+}
+
+// MARK: スプライト評価の1ドットの処理 (可視ラインのみ)
+func (p *PPU) spriteEvaluationDot(dot uint) {
+	// スプライト評価でOAMの全体が読まれるため，DRAMがリフレッシュされる
+	if dot == DOT_SPRITE_EVAL_START && p.oamDecay {
+		p.refreshOAM()
+	}
+
+	switch {
+	case dot == 0:
+		return
+	case dot <= DOT_SECONDARY_OAM_END:
+		// secondary OAM のクリア (偶数ドットで書き込む)
+		if dot%2 == 0 {
+			p.secondaryOAM[(dot-1)/2] = 0xFF
+		}
+		if dot == DOT_SECONDARY_OAM_END {
+			p.evalN = 0
+			p.evalM = 0
+			p.evalIndex = 0
+			p.evalDone = false
+			p.spriteZeroNext = false
+		}
+	case DOT_SPRITE_EVAL_START <= dot && dot <= DOT_VISIBLE_END:
+		if dot%2 == 1 {
+			// 奇数ドット: OAM の読み取り
+			p.evalLatch = p.oam[uint(p.evalN)*OAM_SPRITE_SIZE+uint(p.evalM)]
+			return
+		}
+		p.evaluateSprite()
+	}
+}
+
+// MARK: スプライト評価の書き込み側のドット (偶数ドット)
+func (p *PPU) evaluateSprite() {
+	if p.evalDone {
+		return
+	}
+
+	height := uint16(p.control.SpriteSize())
+	row := p.scanline - uint16(p.evalLatch)
+	inRange := uint16(p.evalLatch) <= p.scanline && row < height
+
+	if p.evalIndex < SECONDARY_OAM_SIZE {
+		// secondary OAM に空きがある間は範囲外でもY座標は書き込まれる
+		p.secondaryOAM[p.evalIndex] = p.evalLatch
+
+		if p.evalM == 0 && !inRange {
+			p.nextSprite()
+			return
+		}
+		if p.evalM == 0 && p.evalN == 0 {
+			p.spriteZeroNext = true
+		}
+
+		// 範囲内のスプライトの残りの3byteをコピー
+		p.evalIndex++
+		p.evalM++
+		if p.evalM == uint8(OAM_SPRITE_SIZE) {
+			p.evalM = 0
+			p.nextSprite()
+		}
+		return
+	}
+
+	/*
+		secondary OAM が埋まった後は9個目のスプライトを探してオーバーフローを判定
+
+		@NOTE
+		ハードウェアのバグで，範囲外のときに n と一緒に m もインクリメントされる
+		Y座標ではなくタイル番号・属性・X座標を斜めに読んで判定するため，誤検出や見逃しが起きる
+		https://www.nesdev.org/wiki/PPU_sprite_evaluation
+	*/
+	if inRange {
+		p.status.SetSpriteOverflow(true)
+		p.evalDone = true
+		return
+	}
+	p.evalM = (p.evalM + 1) % uint8(OAM_SPRITE_SIZE)
+	p.nextSprite()
+}
+
+// MARK: スプライト評価で次のスプライトへ進める
+func (p *PPU) nextSprite() {
+	p.evalN++
+	if p.evalN == uint8(OAM_SPRITE_COUNT) {
+		p.evalN = 0
+		p.evalDone = true
+	}
+}
+
+// MARK: スプライトのパターンのフェッチの1ドットの処理
+func (p *PPU) spriteFetchDot(dot uint, isPreRenderLine bool) {
+	if dot < DOT_SPRITE_FETCH_START || DOT_SPRITE_FETCH_END < dot {
+		return
+	}
+
+	// スプライトのフェッチ中はOAMアドレスが0にリセットされる
+	p.oamAddress = 0
+
+	slot := (dot - DOT_SPRITE_FETCH_START) / TILE_SIZE
+	if dot == DOT_SPRITE_FETCH_START {
+		// プリレンダーラインでは評価をしないので，次のライン (0) にはスプライトが表示されない
+		p.spriteCount = uint8(p.evalIndex / OAM_SPRITE_SIZE)
+		if isPreRenderLine {
+			p.spriteCount = 0
+		}
+		p.spriteZeroInLine = p.spriteZeroNext && !isPreRenderLine
+	}
+
+	switch (dot - DOT_SPRITE_FETCH_START) % TILE_SIZE {
+	case 0, 2:
+		// 未使用のネームテーブル・属性テーブルのフェッチ
+		p.fetchNameTable(tileAddress(p.v))
+	case 4, 6:
+		base := slot * OAM_SPRITE_SIZE
+		y := p.secondaryOAM[base+OAM_SPRITE_Y]
+		tile := p.secondaryOAM[base+OAM_SPRITE_TILE]
+		attribute := p.secondaryOAM[base+OAM_SPRITE_ATTR]
+
+		// 空きのスロットもタイル$FFのパターンをフェッチする (値は使わない)
+		address := p.spriteRowAddress(y, tile, attribute)
+		if (dot-DOT_SPRITE_FETCH_START)%TILE_SIZE == 4 {
+			p.spritePatternLow = p.readPattern(address)
+			return
+		}
+		patternHigh := p.readPattern(address + uint16(TILE_SIZE))
+
+		if slot >= uint(p.spriteCount) {
+			p.sprites[slot] = spriteUnit{}
+			return
+		}
+		p.sprites[slot] = newSpriteUnit(p.spritePatternLow, patternHigh, attribute, p.secondaryOAM[base+OAM_SPRITE_X])
+	}
+
+	// 8スプライトの制限を外す場合は，9個目以降のスプライトを表示用に追加する
+	if dot == DOT_SPRITE_FETCH_END && p.config.Ppu.UNLIMITED_SPRITES && !isPreRenderLine {
+		p.fetchExtraSprites()
+	}
+}
+
+// MARK: 9個目以降のスプライトのフェッチ (表示のみ，オーバーフローフラグやマッパーには影響しない)
+func (p *PPU) fetchExtraSprites() {
+	height := uint16(p.control.SpriteSize())
+	found := uint(0)
+	for n := range OAM_SPRITE_COUNT {
+		base := n * OAM_SPRITE_SIZE
+		y := p.oam[base+OAM_SPRITE_Y]
+		if uint16(y) > p.scanline || p.scanline-uint16(y) >= height {
+			continue
+		}
+		// 先頭の8個はハードウェアの評価で secondary OAM に入っている
+		found++
+		if found <= SPRITE_MAX {
+			continue
+		}
+
+		tile := p.oam[base+OAM_SPRITE_TILE]
+		attribute := p.oam[base+OAM_SPRITE_ATTR]
+		address := p.spriteRowAddress(y, tile, attribute)
+
+		// アドレスバスに出さずに直接読み取る (MMC3のIRQカウンタを進めないため)
+		patternLow := p.mapper.ReadCharacterRom(address)
+		patternHigh := p.mapper.ReadCharacterRom(address + uint16(TILE_SIZE))
+		p.sprites[p.spriteCount] = newSpriteUnit(patternLow, patternHigh, attribute, p.oam[base+OAM_SPRITE_X])
+		p.spriteCount++
+	}
+}
+
+// MARK: スプライトの出力ユニットの生成 (水平反転を適用)
+func newSpriteUnit(patternLow uint8, patternHigh uint8, attribute uint8, x uint8) spriteUnit {
+	if attribute&0x40 != 0 {
+		// 水平反転
+		patternLow = bits.Reverse8(patternLow)
+		patternHigh = bits.Reverse8(patternHigh)
+	}
+	return spriteUnit{
+		patternLow:  patternLow,
+		patternHigh: patternHigh,
+		attribute:   attribute,
+		x:           x,
+	}
+}
+
+// MARK: 現在のラインに表示するスプライトの行のパターン (下位) のアドレスを取得
+func (p *PPU) spriteRowAddress(y uint8, tile uint8, attribute uint8) uint16 {
+	height := p.control.SpriteSize()
+	row := (p.scanline - uint16(y)) & uint16(height-1)
+	if attribute&0x80 != 0 {
+		// 垂直反転
+		row = uint16(height-1) - row
+	}
+	return p.spritePatternAddress(uint16(tile), height, row)
+}
+
+// MARK: スプライトの1行分のパターン (下位) のアドレスを取得
+func (p *PPU) spritePatternAddress(tileIndex uint16, spriteHeight uint8, row uint16) uint16 {
+	bank := p.control.SpritePatternTableAddress()
+	if spriteHeight != uint8(TILE_SIZE) {
+		// 8x16モード: tileIndex bit0 がパターンテーブル選択、bit1-7 が上側タイル番号(偶数)
+		bank = (tileIndex & 0x01) * 0x1000
+		tileIndex &= 0xFE
+		if row >= uint16(TILE_SIZE) {
+			tileIndex++
+			row -= uint16(TILE_SIZE)
+		}
+	}
+	// 1タイルは16bytes (= 8bytes plane0 + 8bytes plane1)
+	return bank + tileIndex*uint16(TILE_SIZE*2) + row
+}
+
+// MARK: 1ピクセルの出力
+func (p *PPU) outputPixel(canvas *Canvas, x uint, isRenderingEnabled bool) {
+	if !isRenderingEnabled {
+		// レンダリング無効時は背景色 (vがパレットを指していればその色) を出力
+		index := uint8(0)
+		if address := p.v.ToByte() & 0x3FFF; address >= 0x3F00 {
+			index = uint8(address & 0x1F)
+		}
+		canvas.SetColorAt(x, uint(p.scanline), p.colorIndex(p.readPalette(index)), &p.emphasisPalette)
+		return
+	}
+
+	// 背景のピクセル
+	var bgValue, bgPalette uint8
+	if p.mask.backgroundEnable && (p.mask.leftmostBackgroundEnable || x >= TILE_SIZE) {
+		bit := 15 - uint16(p.x.fineX)
+		bgValue = uint8((p.bgShiftPatternHigh>>bit)&1)<<1 | uint8((p.bgShiftPatternLow>>bit)&1)
+		bgPalette = uint8((p.bgShiftAttributeHigh>>bit)&1)<<1 | uint8((p.bgShiftAttributeLow>>bit)&1)
+	}
+
+	// スプライトのピクセル (OAMの順番が若いものが優先)
+	var spriteValue, spriteAttribute uint8
+	spriteZero := false
+	if p.mask.spriteEnable && (p.mask.leftmostSpriteEnable || x >= TILE_SIZE) {
+		for i := range p.spriteCount {
+			unit := &p.sprites[i]
+			offset := int(x) - int(unit.x)
+			if offset < 0 || offset >= int(TILE_SIZE) {
+				continue
+			}
+			value := Decode2bppPixel(unit.patternLow, unit.patternHigh, uint8(7-offset))
+			if value == 0 {
+				continue
+			}
+			spriteValue = value
+			spriteAttribute = unit.attribute
+			spriteZero = i == 0 && p.spriteZeroInLine
+			break
+		}
+	}
+
+	// スプライト0ヒット (X = 255 では起きない)
+	if spriteZero && bgValue != 0 && x != SCREEN_WIDTH-1 {
+		p.status.SetSpriteZeroHit(true)
+	}
+
+	// デバッグ用の描画の切り替え
+	if !p.config.Ppu.BACKGROUND_ENABLED {
+		bgValue = 0
+	}
+	if !p.config.Ppu.SPRITE_ENABLED {
+		spriteValue = 0
+	}
+
+	// 優先順位の判定 (属性のbit5が立っていればBGの背面)
+	var index uint8
+	switch {
+	case spriteValue != 0 && (bgValue == 0 || spriteAttribute&0x20 == 0):
+		index = 0x10 | (spriteAttribute&0b11)<<2 | spriteValue
+	case bgValue != 0:
+		index = bgPalette<<2 | bgValue
+	}
+	canvas.SetColorAt(x, uint(p.scanline), p.colorIndex(p.readPalette(index)), &p.emphasisPalette)
+}
+
+// MARK: パレットの読み取り ($3F10/$3F14/$3F18/$3F1C は $3F00/$3F04/$3F08/$3F0C のミラーリング)
+func (p *PPU) readPalette(index uint8) uint8 {
+	index &= 0x1F
+	if index&0x13 == 0x10 {
+		index &^= 0x10
+	}
+	return p.paletteTable[index]
+}
+
+// MARK: ネームテーブル・属性テーブルのフェッチ (レンダリング用)
+func (p *PPU) fetchNameTable(address uint16) uint8 {
+	p.driveAddressBus(address)
+	return p.readNameTable(address)
+}
+
+// MARK: パターンテーブルの読み取り (レンダリング用)
+func (p *PPU) readPattern(address uint16) uint8 {
+	p.driveAddressBus(address)
+	if p.cdl != nil {
+		p.cdl.LogRendered(address)
+	}
+	return p.mapper.ReadCharacterRom(address)
+}
@@ -3,15 +3,20 @@ package ppu
 import (
 	"Famicom-emulator/cartridge/mappers"
 	"Famicom-emulator/config"
+	"Famicom-emulator/debugger"
 	"fmt"
 )
 
 // MARK: 定数定義
 const (
-	VRAM_SIZE             uint16 = 2 * 1024 // 2kB
-	PALETTE_TABLE_SIZE    uint8  = 32
-	OAM_DATA_SIZE         uint16 = 64 * 4
-	OPEN_BUS_DECAY_CYCLES        = 3_000_000
+	VRAM_SIZE          uint16 = 2 * 1024 // 2kB
+	PALETTE_TABLE_SIZE uint8  = 32
+	OAM_DATA_SIZE      uint16 = 64 * 4
+	OPEN_BUS_DECAY_MS         = 600 // OpenBusの減衰時間の既定値 [ms]
+
+	OAM_ROW_SIZE     uint  = 8    // OAMのDRAMのリフレッシュ単位 [byte]
+	OAM_DECAY_CYCLES uint  = 9000 // リフレッシュされないOAMの行が崩れるまでのPPUサイクル数 (約3000 CPUサイクル)
+	OAM_DECAY_VALUE  uint8 = 0x10 // 崩れた後の値 (実機では不定)
 )
 
 const (
@@ -29,19 +34,8 @@ const (
 	SPRITE_MAX      uint = 8
 
 	TILE_SIZE uint = 8
-
-	SPRITE_ZERO_HIT_NOT_FOUND uint16 = 0xFFFF
 )
 
-// MARK: OAM Sprite の定義
-type OAMSprite struct {
-	y         uint8 // OAM byte0
-	tile      uint8 // OAM byte1
-	attribute uint8 // OAM byte2
-	x         uint8 // OAM byte3
-	oamIndex  uint8 // primary OAM上のスプライト番号 (0 ~ 63)
-}
-
 // MARK: PPUの定義
 type PPU struct {
 	mapper       mappers.Mapper
@@ -49,11 +43,32 @@ type PPU struct {
 	vram         [VRAM_SIZE]uint8
 
 	// Object Attribute Memory
-	oam               [OAM_DATA_SIZE]uint8
-	secondaryOAM      [SPRITE_MAX]OAMSprite
-	secondaryOAMCount uint8
-	spriteZeroInLine  bool
-	spriteZeroHitX    uint16
+	oam          [OAM_DATA_SIZE]uint8
+	secondaryOAM [SECONDARY_OAM_SIZE]uint8
+
+	// スプライト評価 (65 ~ 256ドット)
+	evalN          uint8 // 評価中のスプライト番号 (0 ~ 63)
+	evalM          uint8 // 評価中のバイト (0 ~ 3)
+	evalIndex      uint  // secondary OAM の書き込み位置
+	evalLatch      uint8 // 奇数ドットで読んだOAMの値
+	evalDone       bool
+	spriteZeroNext bool // 次のラインに0番スプライトがある
+
+	// スプライトの出力ユニット (257 ~ 320ドットでフェッチし，次のラインに表示)
+	sprites          [OAM_SPRITE_COUNT]spriteUnit // 先頭の8個がハードウェアのスロット
+	spriteCount      uint8
+	spriteZeroInLine bool // 現在のラインの先頭のスプライトが0番スプライト
+	spritePatternLow uint8
+
+	// 背景のフェッチとシフトレジスタ
+	bgNameTableByte      uint8
+	bgAttribute          uint8 // 2bitのパレット番号
+	bgPatternLow         uint8
+	bgPatternHigh        uint8
+	bgShiftPatternLow    uint16
+	bgShiftPatternHigh   uint16
+	bgShiftAttributeLow  uint16
+	bgShiftAttributeHigh uint16
 
 	// IOレジスタ
 	control ControlRegister // $2000
@@ -66,6 +81,11 @@ type PPU struct {
 	x InternalXRegister        // x スクロール
 	w InternalWRegister        // 書き込みラッチ
 
+	// PPUアドレスバス (マッパーが A12 などを監視する)
+	addressBus  uint16
+	busObserver mappers.PPUBusObserver // nilならマッパーは監視しない
+	dots        uint                   // 電源投入からのPPUサイクル数
+
 	scanline           uint16 // 現在描画中のスキャンライン
 	cycles             uint   // PPUサイクル
 	internalDataBuffer uint8  // PPU内部バッファ
@@ -73,16 +93,33 @@ type PPU struct {
 
 	nmi bool
 
-	lineBuffer        [SCREEN_WIDTH]Pixel // 次のスキャンラインのバッファ
-	openBus           uint8
-	openBusDecayTimer int // OpenBus減衰のタイマー
+	// OpenBus (ビットごとに最後に駆動されてから一定時間で0に戻る)
+	openBus          uint8
+	openBusRefreshed [8]uint // 各ビットを最後に駆動したPPUサイクル (dots)
+	openBusDecayDots uint    // 0に戻るまでのPPUサイクル数 (0なら減衰しない)
+
+	// OAMの減衰 (DRAMのため，レンダリングでリフレッシュされないと崩れる)
+	oamDecay        bool
+	oamRowRefreshed [uint(OAM_DATA_SIZE) / OAM_ROW_SIZE]uint // 各行を最後にアクセスしたPPUサイクル (dots)
 
 	frameOdd bool // 奇数フレームフラグ
+	frames   uint // 描画し終えたフレーム数
+
+	// 地域ごとのタイミング
+	vblankLine      uint16 // VBlankが始まるスキャンライン
+	preRenderLine   uint16 // プリレンダーライン (フレームの最終ライン)
+	skipOddFrameDot bool   // 奇数フレームでドットを1つ飛ばすかどうか (NTSCのみ)
+
+	// 表示に使うパレット (Vs. システムのRGBパレット / .palファイル / NTSCの生成から選ぶ)
+	palette         [64][3]uint8                    // 色強調なしの64色 (デバッグウィンドウ用)
+	emphasisPalette [EMPHASIS_PALETTE_SIZE][3]uint8 // 9bitのカラー番号 (色強調 << 6 | パレット値) に対応するRGB
+	paletteControl  PaletteControl                  // ホットキーで調整するNTSCパレットの項目
 
 	// デバッグウィンドウ用のスナップショット
-	vLineStart     InternalAddressRegiseter
 	mapperSnapshot mappers.Mapper
 
+	cdl *debugger.CodeDataLogger // Code/Data Logger (nilなら無効)
+
 	config config.Config
 }
 
@@ -90,6 +127,18 @@ type PPU struct {
 func (p *PPU) Init(mapper mappers.Mapper, config config.Config) {
 	p.mapper = mapper
 	p.config = config
+	p.busObserver, _ = mapper.(mappers.PPUBusObserver)
+
+	// 地域ごとのタイミングの設定
+	region := p.config.System.REGION
+	p.vblankLine = region.VBlankScanline()
+	p.preRenderLine = region.Scanlines() - 1
+	p.skipOddFrameDot = region.SkipsOddFrameDot()
+	p.openBusDecayDots = openBusDecayDots(p.config.Ppu.OPEN_BUS_DECAY, region)
+	p.oamDecay = p.config.Ppu.OAM_DECAY
+
+	// パレットの設定 (Vs. システムのRGBパレット / .palファイル / NTSCの生成)
+	p.applyPalette()
 
 	// VRAM/OAM/パレットの初期化
 	for addr := range p.vram {
@@ -98,18 +147,34 @@ func (p *PPU) Init(mapper mappers.Mapper, config config.Config) {
 	for addr := range p.oam {
 		p.oam[addr] = 0x00
 	}
-	for i := range p.secondaryOAM {
-		p.secondaryOAM[i] = OAMSprite{
-			y:         0xFF,
-			tile:      0xFF,
-			attribute: 0xFF,
-			x:         0xFF,
-			oamIndex:  0xFF,
-		}
+	for row := range p.oamRowRefreshed {
+		p.oamRowRefreshed[row] = 0
+	}
+	for addr := range p.secondaryOAM {
+		p.secondaryOAM[addr] = 0xFF
 	}
-	p.secondaryOAMCount = 0
+	p.evalN = 0
+	p.evalM = 0
+	p.evalIndex = 0
+	p.evalLatch = 0xFF
+	p.evalDone = true
+	p.spriteZeroNext = false
+	for i := range p.sprites {
+		p.sprites[i] = spriteUnit{}
+	}
+	p.spriteCount = 0
 	p.spriteZeroInLine = false
-	p.spriteZeroHitX = SPRITE_ZERO_HIT_NOT_FOUND
+	p.spritePatternLow = 0x00
+
+	// 背景のシフトレジスタの初期化
+	p.bgNameTableByte = 0x00
+	p.bgAttribute = 0x00
+	p.bgPatternLow = 0x00
+	p.bgPatternHigh = 0x00
+	p.bgShiftPatternLow = 0x0000
+	p.bgShiftPatternHigh = 0x0000
+	p.bgShiftAttributeLow = 0x0000
+	p.bgShiftAttributeHigh = 0x0000
 	for addr := range p.paletteTable {
 		p.paletteTable[addr] = 0x00
 	}
@@ -125,28 +190,20 @@ func (p *PPU) Init(mapper mappers.Mapper, config config.Config) {
 	p.x.Init()
 	p.w.Init()
 
-	// vLineStartの初期化
-	p.vLineStart.Init()
-
 	p.oamAddress = 0
 	p.scanline = 0
 	p.cycles = 0
 	p.internalDataBuffer = 0x00
 	p.openBus = 0x00
+	for bit := range p.openBusRefreshed {
+		p.openBusRefreshed[bit] = 0
+	}
+	p.addressBus = 0x0000
+	p.dots = 0
 
 	p.nmi = false
 	p.frameOdd = false
-
-	// ラインバッファの初期化
-	for i := range p.lineBuffer {
-		p.lineBuffer[i] = Pixel{
-			0x00,
-			PALETTE[p.paletteTable[0]],
-			PALETTE[p.paletteTable[0]],
-			true,
-			true,
-		}
-	}
+	p.frames = 0
 
 	p.mapperSnapshot = p.mapper
 }
@@ -199,6 +256,10 @@ func (p *PPU) WriteToPPUInternalRegister(address uint16, data uint8) {
 
 		if beforeLatch && !p.w.latch {
 			p.t.copyAllBitsTo(&p.v)
+			// レンダリング中でなければ v がそのままアドレスバスに出力される
+			if !p.isRendering() {
+				p.driveAddressBus(p.v.ToByte())
+			}
 		}
 	}
 	p.refreshOpenBus(data)
@@ -206,19 +267,12 @@ func (p *PPU) WriteToPPUInternalRegister(address uint16, data uint8) {
 
 // MARK: OAM DATA($4014) への書き込み
 func (p *PPU) WriteToOAMDataRegister(data uint8) {
+	p.refreshOAMRow(p.oamAddress)
 	p.oam[p.oamAddress] = data
 	p.oamAddress++
 	p.refreshOpenBus(data)
 }
 
-// MARK: DMA転送
-func (p *PPU) DMATransfer(bytes *[256]uint8) {
-	for _, byte := range *bytes {
-		p.oam[p.oamAddress] = byte
-		p.oamAddress++
-	}
-}
-
 // MARK: VRAMアドレスのインクリメント
 func (p *PPU) incrementVRAMAddress() {
 	step := uint16(p.control.VRAMAddressIncrement())
@@ -226,6 +280,17 @@ func (p *PPU) incrementVRAMAddress() {
 	p.v.SetFromWord(newAddr)
 }
 
+// MARK: $2007 のアクセス後のVRAMアドレスの更新
+func (p *PPU) advanceVRAMAddress() {
+	if p.isRendering() {
+		// レンダリング中は通常のインクリメントではなく，coarse X と Y が同時にインクリメントされる (グリッチ)
+		p.v.incrementCoarseX()
+		p.v.incrementY()
+		return
+	}
+	p.incrementVRAMAddress()
+}
+
 // MARK: VRAMへの書き込み
 func (p *PPU) WriteVRAM(value uint8) {
 	/*
@@ -238,13 +303,14 @@ func (p *PPU) WriteVRAM(value uint8) {
 	*/
 
 	address := p.v.ToByte()
-	p.incrementVRAMAddress()
+	p.advanceVRAMAddress()
 	p.refreshOpenBus(value)
 
 	// $0000-$3FFF のミラーリング
 	if address > 0x3FFF {
 		address -= 0x4000
 	}
+	p.driveAddressBus(address)
 
 	switch {
 	case address <= 0x1FFF: // キャラクタROM
@@ -252,9 +318,9 @@ func (p *PPU) WriteVRAM(value uint8) {
 			p.mapper.WriteToCharacterRom(address, value)
 		}
 	case 0x2000 <= address && address <= 0x2FFF: // VRAM
-		p.vram[p.mirrorVRAMAddress(address)] = value
-	case 0x3000 <= address && address <= 0x3EFF: // ネームテーブル
-		return
+		p.writeNameTable(address, value)
+	case 0x3000 <= address && address <= 0x3EFF: // ネームテーブル ($2000-$2EFF のミラーリング)
+		p.writeNameTable(address-0x1000, value)
 	case 0x3F00 <= address && address <= 0x3F1F: // パレット
 		// アドレスのミラーリング
 		if address == 0x3F10 ||
@@ -286,14 +352,29 @@ func (p *PPU) ReadPPUStatus() uint8 {
 	status := p.status.ToByte()
 	p.status.ClearVBlankStatus()
 	p.w.reset()
-	value := status | (p.openBus)&0x1F
-	p.openBus = value
+	// 上位3bitだけが駆動され，下位5bitはOpenBusの値
+	value := status | p.decayOpenBus()&0x1F
+	driven := uint8(0xE0)
+	if id := p.config.System.VS_PPU.StatusID(); id != 0 {
+		// RC2C05は下位5bitにPPUのIDを返す
+		value = status | id&0x1F
+		driven = 0xFF
+	}
+	p.driveOpenBus(value, driven)
 	// @FIXME PPU STATUS を読み込んだ次のフレームはNMIを発生させない
 	return value
 }
 
 // MARK: OAM DATAの読み取り
 func (p *PPU) ReadOAMData() uint8 {
+	// レンダリング中はOAMアドレスではなく，スプライト評価のバスの値が読み出される
+	if p.isRendering() {
+		value := p.oamEvaluationBus()
+		p.refreshOpenBus(value)
+		return value
+	}
+
+	p.refreshOAMRow(p.oamAddress)
 	value := p.oam[p.oamAddress]
 
 	// 属性バイト (Byte 2) の bit 2-4 は未実装のため 0 として読み出される
@@ -305,15 +386,92 @@ func (p *PPU) ReadOAMData() uint8 {
 	return value
 }
 
+/*
+	レンダリング中の $2004 の読み取り (スプライト評価のバスの値)
+
+	1 ~ 64    : secondary OAM のクリア中は $FF
+	65 ~ 256  : スプライト評価で OAM から読んだ値
+	257 ~ 320 : フェッチ中のスプライトの secondary OAM の値 (Y, タイル, 属性, X, X, X, X, X の順)
+	それ以外  : secondary OAM の先頭
+*/
+// MARK: スプライト評価のバスの値の取得
+func (p *PPU) oamEvaluationBus() uint8 {
+	dot := p.cycles
+	switch {
+	case 1 <= dot && dot <= DOT_SECONDARY_OAM_END:
+		return 0xFF
+	case DOT_SPRITE_EVAL_START <= dot && dot <= DOT_VISIBLE_END:
+		return p.evalLatch
+	case DOT_SPRITE_FETCH_START <= dot && dot <= DOT_SPRITE_FETCH_END:
+		slot := (dot - DOT_SPRITE_FETCH_START) / TILE_SIZE
+		offset := min((dot-DOT_SPRITE_FETCH_START)%TILE_SIZE, OAM_SPRITE_X)
+		return p.secondaryOAM[slot*OAM_SPRITE_SIZE+offset]
+	}
+	return p.secondaryOAM[0]
+}
+
 // MARK: Open Busの読み取り
 func (p *PPU) ReadOpenBus() uint8 {
-	return p.openBus
+	return p.decayOpenBus()
 }
 
-// MARK: Open Busのリフレッシュ
+// MARK: Open Busのリフレッシュ (8bitすべてを駆動)
 func (p *PPU) refreshOpenBus(value uint8) {
-	p.openBus = value
-	p.openBusDecayTimer = OPEN_BUS_DECAY_CYCLES
+	p.driveOpenBus(value, 0xFF)
+}
+
+// MARK: Open Busの駆動 (driven のビットだけを更新し，減衰のタイマーを戻す)
+func (p *PPU) driveOpenBus(value uint8, driven uint8) {
+	p.openBus = p.openBus&^driven | value&driven
+	for bit := range p.openBusRefreshed {
+		if driven&(1<<bit) != 0 {
+			p.openBusRefreshed[bit] = p.dots
+		}
+	}
+}
+
+// MARK: 減衰を反映したOpen Busの値の取得
+func (p *PPU) decayOpenBus() uint8 {
+	if p.openBusDecayDots == 0 {
+		return p.openBus
+	}
+	for bit := range p.openBusRefreshed {
+		if p.dots-p.openBusRefreshed[bit] >= p.openBusDecayDots {
+			p.openBus &^= 1 << bit
+		}
+	}
+	return p.openBus
+}
+
+// MARK: Open Busの減衰時間 [ms] をPPUサイクル数に変換 (0なら減衰しない)
+func openBusDecayDots(ms int, region config.Region) uint {
+	if ms < 0 {
+		return 0
+	}
+	if ms == 0 {
+		ms = OPEN_BUS_DECAY_MS
+	}
+	numerator, denominator := region.PPUClockRatio()
+	return uint(region.CPUClock() * float64(numerator) / float64(denominator) * float64(ms) / 1000)
+}
+
+// MARK: OAMの行のリフレッシュ (長くアクセスされていなければ先に崩す)
+func (p *PPU) refreshOAMRow(address uint8) {
+	row := uint(address) / OAM_ROW_SIZE
+	if p.oamDecay && p.dots-p.oamRowRefreshed[row] >= OAM_DECAY_CYCLES {
+		base := row * OAM_ROW_SIZE
+		for i := range OAM_ROW_SIZE {
+			p.oam[base+i] = OAM_DECAY_VALUE
+		}
+	}
+	p.oamRowRefreshed[row] = p.dots
+}
+
+// MARK: OAM全体のリフレッシュ (スプライト評価でOAMの全体が読まれる)
+func (p *PPU) refreshOAM() {
+	for row := range uint(len(p.oamRowRefreshed)) {
+		p.refreshOAMRow(uint8(row * OAM_ROW_SIZE))
+	}
 }
 
 // MARK: VRAMの読み取り
@@ -328,28 +486,40 @@ func (p *PPU) ReadVRAM() uint8 {
 	*/
 
 	address := p.v.ToByte()
-	p.incrementVRAMAddress()
+	p.advanceVRAMAddress()
 
 	// $0000-$3FFF のミラーリング
 	if address > 0x3FFF {
 		address -= 0x4000
 	}
+	p.driveAddressBus(address)
 
 	switch {
 	case address <= 0x1FFF: // キャラクタROM
 		value := p.internalDataBuffer
 		p.internalDataBuffer = p.mapper.ReadCharacterRom(address)
+		if p.cdl != nil {
+			p.cdl.LogCharacterRead(address)
+		}
 		p.refreshOpenBus(value)
 		return value
 	case 0x2000 <= address && address <= 0x2FFF: // VRAM
 		// 一回遅れで値は反映されるため，内部バッファを更新し，元のバッファ値を返す
 		value := p.internalDataBuffer
-		p.internalDataBuffer = p.vram[p.mirrorVRAMAddress(address)]
+		p.internalDataBuffer = p.readNameTable(address)
+		p.refreshOpenBus(value)
+		return value
+	case 0x3000 <= address && address <= 0x3EFF: // ネームテーブル ($2000-$2EFF のミラーリング)
+		value := p.internalDataBuffer
+		p.internalDataBuffer = p.readNameTable(address - 0x1000)
 		p.refreshOpenBus(value)
 		return value
-	case 0x3000 <= address && address <= 0x3EFF: // ネームテーブル
-		panic(fmt.Sprintf("Error: address space 0x3000..0x3eff is not expected to read, requested: %04X", address))
 	case 0x3F00 <= address && address <= 0x3F1F: // パレット
+		// パレット読み込み時は内部バッファを更新する (ミラーリングされたVRAMの値)
+		// $3F00-$3FFF は $2F00-$2FFF (VRAM) にミラーリングされる
+		// (パレットのミラーリングより前のアドレスで読む)
+		p.internalDataBuffer = p.readNameTable(address - 0x1000)
+
 		// アドレスのミラーリング
 		if address == 0x3F10 ||
 			address == 0x3F14 ||
@@ -357,491 +527,106 @@ func (p *PPU) ReadVRAM() uint8 {
 			address == 0x3F1C {
 			address -= 0x10
 		}
-		// パレット読み込み時は内部バッファを更新する (ミラーリングされたVRAMの値)
-		// $3F00-$3FFF は $2F00-$2FFF (VRAM) にミラーリングされる
-		p.internalDataBuffer = p.vram[p.mirrorVRAMAddress(address)]
 
-		// パレットデータの下位6bitとOpenBusの上位2bitを結合して返す
-		value := (p.openBus & 0xC0) | (p.paletteTable[address-0x3F00] & 0x3F)
-		p.refreshOpenBus(value)
+		// パレットデータの下位6bitとOpenBusの上位2bitを結合して返す (グレースケールも適用される)
+		// 上位2bitは駆動されないため，減衰のタイマーは戻らない
+		value := (p.decayOpenBus() & 0xC0) | p.applyGrayscale(p.paletteTable[address-0x3F00])
+		p.driveOpenBus(value, 0x3F)
 		return value
 	case 0x3F20 <= address && address <= 0x3FFF: // パレット (ミラーリング)
 		// パレット読み込み時は内部バッファを更新する
-		p.internalDataBuffer = p.vram[p.mirrorVRAMAddress(address)]
+		p.internalDataBuffer = p.readNameTable(address - 0x1000)
 
-		value := (p.openBus & 0xC0) | (p.paletteTable[(address-0x3F00)%32] & 0x3F)
-		p.refreshOpenBus(value)
+		value := (p.decayOpenBus() & 0xC0) | p.applyGrayscale(p.paletteTable[(address-0x3F00)%32])
+		p.driveOpenBus(value, 0x3F)
 		return value
 	default:
 		panic(fmt.Sprintf("Error: unexpected read to vram space: %04X", address))
 	}
 }
 
-// MARK: VRAMアドレスのミラーリング
-func (p *PPU) mirrorVRAMAddress(addr uint16) uint16 {
-	// 0x3000-0x3eff から 0x2000 - 0x2eff へミラーリング
-	mirroredVRAMAddr := addr & PPU_VRAM_MIRROR_MASK
-
-	// メモリアドレスをVRAMの配列用に補正 (VRAMの先頭アドレスを引く)
-	vramIndex := mirroredVRAMAddr - 0x2000
-
-	// ネームテーブルのインデックスを求める
-	nameTable := vramIndex / 0x400
-
-	mirroring := p.mapper.Mirroring()
-
-	// ネームテーブルのミラーリングがVerticalの場合
-	// [ A ] [ B ] (一つのテーブルが 0x400 × 0x400，そのテーブルが 2 × 2)
-	// [ a ] [ b ]
-	if mirroring == mappers.MIRRORING_VERTICAL {
-		if nameTable == 2 || nameTable == 3 {
-			return vramIndex - 0x800
-		}
+// MARK: PPUアドレスバスへの出力 (マッパーに観測させる)
+func (p *PPU) driveAddressBus(address uint16) {
+	p.addressBus = address & 0x3FFF
+	if p.busObserver != nil {
+		p.busObserver.ObservePPUAddress(p.addressBus, p.dots)
 	}
-
-	// ネームテーブルのミラーリングがHorizontalの場合
-	// [ A ] [ a ]
-	// [ B ] [ b ]
-	if mirroring == mappers.MIRRORING_HORIZONTAL {
-		switch nameTable {
-		case 2, 1:
-			return vramIndex - 0x400
-		case 3:
-			return vramIndex - 0x800
-		}
-	}
-
-	return vramIndex
 }
 
-// MARK: 待機しているNMIを取得
-func (p *PPU) PollNmiStatus() bool {
-	if p.nmi {
-		p.nmi = false
-		return true
-	} else {
-		return false
-	}
+// MARK: レンダリング中かどうか (可視ライン・プリレンダーラインでレンダリングが有効)
+func (p *PPU) isRendering() bool {
+	isRenderingEnabled := p.mask.backgroundEnable || p.mask.spriteEnable
+	isRenderLine := p.scanline < SCANLINE_POSTRENDER || p.scanline == p.preRenderLine
+	return isRenderingEnabled && isRenderLine
 }
 
-// MARK: スプライト0ヒットの判定
-func (p *PPU) isSpriteZeroHit(cycles uint) bool {
-	if p.spriteZeroHitX == SPRITE_ZERO_HIT_NOT_FOUND {
-		return false
-	}
+// MARK: ネームテーブルの読み取り ($2000-$2FFF)
+func (p *PPU) readNameTable(address uint16) uint8 {
+	return p.readNameTableFrom(p.mapper, uint8((address>>10)&0x03), address&0x3FF)
+}
 
+// MARK: ネームテーブルへの書き込み ($2000-$2FFF)
+func (p *PPU) writeNameTable(address uint16, value uint8) {
 	/*
-		PPUの可視ドットは cycle = 1..256 が X = 0..255 に対応するため，
-		hit dot = hitX + 1
+		1kB毎の枠 ($2000 / $2400 / $2800 / $2C00) をどのメモリが担当するかはカートリッジが決める
+		  - CIRAM A / B: PPU内部の2kB VRAM
+		  - それ以外: カートリッジ上の追加VRAMやキャラクタROM
 	*/
-	hitDot := uint(p.spriteZeroHitX) + 1
-	return cycles == hitDot
-}
-
-// MARK: セカンダリOAMの中で0番スプライトを検索
-func (p *PPU) findSpriteZeroInSecondaryOam() (OAMSprite, bool) {
-	if !p.spriteZeroInLine {
-		return OAMSprite{}, false
-	}
-	for i := uint8(0); i < p.secondaryOAMCount && i < uint8(SPRITE_MAX); i++ {
-		if p.secondaryOAM[i].oamIndex == 0 {
-			return p.secondaryOAM[i], true
-		}
-	}
-	return OAMSprite{}, false
-}
-
-// MARK: 現在のスキャンラインかつ指定したX座標のBGの値を取得
-func (p *PPU) backgroundPixelValueAt(x uint16) uint8 {
-	if !p.mask.backgroundEnable || !p.mask.leftmostBackgroundEnable && x < uint16(TILE_SIZE) {
-		return 0
-	}
-	// X = 255 は Sprite 0 hit を起こさない
-	if x >= uint16(SCREEN_WIDTH-1) {
-		return 0
-	}
-
-	// ライン開始時点のVレジスタ状態から、xに相当するタイルまで進めてピクセル値だけ取る。
-	v := p.vLineStart
-	totalFineX := uint(p.x.fineX) + uint(x)
-	tileSteps := totalFineX / TILE_SIZE
-	fineXInTile := totalFineX % TILE_SIZE
-	for range tileSteps {
-		v.incrementCoarseX()
-	}
-
-	tileX := uint(v.coarseX)
-	tileY := uint(v.coarseY)
-	fineY := uint16(v.fineY)
-
-	nameTable := *p.nameTable(v)
-	tileIndex := uint16(nameTable[tileY*32+tileX])
-	bank := p.control.BackgroundPatternTableAddress()
-	plane0, plane1 := p.fetchTileRowBytes(bank, tileIndex, fineY)
-	bit := uint8(7 - fineXInTile)
-
-	return Decode2bppPixel(plane0, plane1, bit)
-}
-
-// MARK: 指定スキャンラインで Sprite 0 hit が発生する最初のXを計算
-func (p *PPU) calculateSpriteZeroHitX(scanline uint16) uint16 {
-	if !p.mask.backgroundEnable || !p.mask.spriteEnable {
-		return SPRITE_ZERO_HIT_NOT_FOUND
-	}
-
-	// 0番スプライトを検索
-	sprite, found := p.findSpriteZeroInSecondaryOam()
-	if !found {
-		return SPRITE_ZERO_HIT_NOT_FOUND
-	}
-
-	spriteHeight := p.control.SpriteSize()
-	spriteY := uint16(sprite.y) + 1
-	spriteX := uint16(sprite.x)
-	tileIndex := uint16(sprite.tile)
-	attributes := sprite.attribute
-
-	flipV := (attributes>>7)&1 == 1
-	flipH := (attributes>>6)&1 == 1
-
-	var tileY uint16
-	if flipV {
-		tileY = (spriteY + uint16(spriteHeight-1)) - scanline
-	} else {
-		tileY = scanline - spriteY
-	}
-
-	// タイルのデータをフェッチ
-	plane0, plane1 := p.fetchSpriteRowBytes(tileIndex, spriteHeight, tileY)
-
-	for dx := range uint16(TILE_SIZE) {
-		actualX := spriteX + dx
-		// dot 255 は常に無視
-		if actualX >= uint16(SCREEN_WIDTH-1) {
-			continue
-		}
-
-		// 左端8pxのどちらかが無効ならこの領域ではヒットしない
-		if actualX < uint16(TILE_SIZE) {
-			if !p.mask.leftmostBackgroundEnable || !p.mask.leftmostSpriteEnable {
-				continue
-			}
-		}
-
-		var bit uint8
-		if flipH {
-			bit = uint8(dx) // LSB
-		} else {
-			bit = uint8(7 - dx) // MSB
-		}
-		spriteValue := Decode2bppPixel(plane0, plane1, bit)
-		if spriteValue == 0 {
-			continue
-		}
-
-		bgValue := p.backgroundPixelValueAt(actualX)
-		if bgValue == 0 {
-			continue
-		}
-
-		return actualX
-	}
-
-	return SPRITE_ZERO_HIT_NOT_FOUND
-}
-
-// MARK: 指定したスキャンラインに表示されるスプライトを secondary OAM へ評価
-func (p *PPU) evaluateSecondaryOam(scanline uint16) {
-	p.clearSecondaryOam()
-
-	// スプライトが無効なら評価しない
-	if !p.mask.spriteEnable {
-		return
-	}
-
-	// 1ライン毎にオーバーフローフラグはリセットして評価し直す
-	p.status.SetSpriteOverflow(false)
-
-	spriteHeight := uint16(p.control.SpriteSize())
-	var count uint8 = 0
-
-	for i := range uint(len(p.oam)) / OAM_SPRITE_SIZE {
-		base := i * OAM_SPRITE_SIZE
-		spriteY := uint16(p.oam[base+OAM_SPRITE_Y]) + 1
-
-		if scanline < spriteY || scanline >= spriteY+spriteHeight {
-			continue
-		}
-
-		if count < uint8(SPRITE_MAX) {
-			p.secondaryOAM[count] = OAMSprite{
-				y:         p.oam[base+OAM_SPRITE_Y],
-				tile:      p.oam[base+OAM_SPRITE_TILE],
-				attribute: p.oam[base+OAM_SPRITE_ATTR],
-				x:         p.oam[base+OAM_SPRITE_X],
-				oamIndex:  uint8(i),
-			}
-
-			if i == 0 {
-				p.spriteZeroInLine = true
-			}
-
-			count++
-		} else {
-			// 9個目以降がヒットしたら overflow を立てる
-			p.status.SetSpriteOverflow(true)
-			break
-		}
-	}
-
-	p.secondaryOAMCount = count
-}
-
-// MARK: secondary OAM のクリア
-func (p *PPU) clearSecondaryOam() {
-	for i := range p.secondaryOAM {
-		p.secondaryOAM[i] = OAMSprite{
-			y:         0xFF,
-			tile:      0xFF,
-			attribute: 0xFF,
-			x:         0xFF,
-			oamIndex:  0xFF,
-		}
-	}
-	p.secondaryOAMCount = 0
-	p.spriteZeroInLine = false
-	p.spriteZeroHitX = SPRITE_ZERO_HIT_NOT_FOUND
-}
-
-// MARK: ラインバッファをクリア
-func (p *PPU) ClearLineBuffer() {
-	for x := range p.lineBuffer {
-		p.lineBuffer[x].backgroundValue = PALETTE[p.paletteTable[0]]
-		p.lineBuffer[x].spriteValue = PALETTE[p.paletteTable[0]]
-		p.lineBuffer[x].priority = 0x00
-		p.lineBuffer[x].isBgTransparent = true
-		p.lineBuffer[x].isSpriteTransparent = true
+	slot := uint8((address >> 10) & 0x03)
+	offset := address & 0x3FF
+
+	bank := p.mapper.NameTable(slot)
+	switch bank.Source {
+	case mappers.NAMETABLE_CIRAM_A:
+		p.vram[offset] = value
+	case mappers.NAMETABLE_CIRAM_B:
+		p.vram[0x400+offset] = value
+	default:
+		p.mapper.WriteToNameTable(bank, offset, value)
 	}
 }
 
-// MARK: スキャンライン開始時点のVレジスタからネームテーブルを取得
-func (p *PPU) nameTable(v InternalAddressRegiseter) *[]uint8 {
-	nameTableIndex := v.nameTable
-	var nameTable []uint8
-
-	primaryNameTable := p.vram[0x000:0x400]
-	secondaryNameTable := p.vram[0x400:0x800]
-
-	mirroring := p.mapper.Mirroring()
-	switch mirroring {
-	case mappers.MIRRORING_VERTICAL:
-		if nameTableIndex == 0 || nameTableIndex == 2 {
-			nameTable = primaryNameTable
-		} else {
-			nameTable = secondaryNameTable
-		}
-	case mappers.MIRRORING_HORIZONTAL:
-		if nameTableIndex == 0 || nameTableIndex == 1 {
-			nameTable = primaryNameTable
-		} else {
-			nameTable = secondaryNameTable
-		}
+// MARK: 指定したマッパーの割り当てでネームテーブルを読み取り
+func (p *PPU) readNameTableFrom(mapper mappers.Mapper, slot uint8, offset uint16) uint8 {
+	bank := mapper.NameTable(slot)
+	switch bank.Source {
+	case mappers.NAMETABLE_CIRAM_A:
+		return p.vram[offset]
+	case mappers.NAMETABLE_CIRAM_B:
+		return p.vram[0x400+offset]
 	default:
-		nameTable = primaryNameTable
+		return mapper.ReadNameTable(bank, offset)
 	}
-	return &nameTable
 }
 
-// キャラクタROMからタイル1行分(plane0 / plane1)を取得
-func (p *PPU) fetchTileRowBytes(bank uint16, tileIndex uint16, row uint16) (plane0 uint8, plane1 uint8) {
-	// 1タイルは16bytes (= 8bytes plane0 + 8bytes plane1)
-	base := bank + tileIndex*uint16(TILE_SIZE*2)
-	plane0 = p.mapper.ReadCharacterRom(base + row)
-	plane1 = p.mapper.ReadCharacterRom(base + row + uint16(TILE_SIZE))
-	return plane0, plane1
+// MARK: Vレジスタが指すタイルのネームテーブルのアドレスを取得
+func tileAddress(v InternalAddressRegiseter) uint16 {
+	return 0x2000 | uint16(v.nameTable)<<10 | uint16(v.coarseY)<<5 | uint16(v.coarseX)
 }
 
-// MARK: スプライトの1行分のピクセルを取得
-func (p *PPU) fetchSpriteRowBytes(tileIndex uint16, spriteHeight uint8, tileY uint16) (plane0 uint8, plane1 uint8) {
-	if spriteHeight == uint8(TILE_SIZE) {
-		bank := p.control.SpritePatternTableAddress()
-		return p.fetchTileRowBytes(bank, tileIndex, tileY)
-	}
-
-	// 8x16モード: tileIndex bit0 がパターンテーブル選択、bit1-7 が上側タイル番号(偶数)
-	bank := (tileIndex & 0x01) * 0x1000
-	tileIndex &= 0xFE
-	if tileY >= uint16(TILE_SIZE) {
-		tileIndex++
-		tileY -= uint16(TILE_SIZE)
-	}
-	return p.fetchTileRowBytes(bank, tileIndex, tileY)
+// MARK: Vレジスタが指すタイルの属性テーブルのアドレスを取得
+func attributeAddress(v InternalAddressRegiseter) uint16 {
+	return 0x23C0 | uint16(v.nameTable)<<10 | uint16(v.coarseY>>2)<<3 | uint16(v.coarseX>>2)
 }
 
-// MARK: 指定したスキャンラインのBG面を計算
-func (p *PPU) CalculateScanlineBackground(canvas *Canvas, scanline uint16) {
-	// BGが無効であれば描画をしない
-	if !p.mask.backgroundEnable || !p.config.Ppu.BACKGROUND_ENABLED {
-		return
-	}
-
-	// 現在のVレジスタの状態をバックアップ（ライン開始時点の値を使う）
-	v := p.vLineStart
-
-	// 画面の左端から右端まで
-	bank := p.control.BackgroundPatternTableAddress()
-
-	fineX := uint(p.x.fineX) // ここからはローカルで進める。p.xは書き換えない
-	var x uint = 0
-	for x < SCREEN_WIDTH {
-		// 今のfineXからタイル境界までの残りピクセル数(
-		span := min(SCREEN_WIDTH-x, TILE_SIZE-(fineX%TILE_SIZE))
-
-		// 左端8pxの描画有無を判定
-		if !p.mask.leftmostBackgroundEnable && x < TILE_SIZE {
-			skip := min(TILE_SIZE-x, span)
-
-			x += skip
-			fineX += skip
-			if fineX%TILE_SIZE == 0 {
-				v.incrementCoarseX()
-			}
-			continue
-		}
-
-		// 現在のピクセル位置でのタイル座標を計算
-		tileX := uint(v.coarseX)
-		tileY := uint(v.coarseY)
-		fineY := uint16(v.fineY)
-
-		// ネームテーブルの選択
-		nameTable := *p.nameTable(v)
-
-		// タイルのインデックスを取得
-		tileIndex := uint16(nameTable[tileY*32+tileX])
-
-		// 属性テーブルからパレット情報を取得
-		attributeTable := nameTable[0x3C0:0x400]
-		palette := p.BackgroundColorPalette(&attributeTable, tileX, tileY)
-
-		// パターンテーブルからタイルのピクセルデータを取得
-		plane0, plane1 := p.fetchTileRowBytes(bank, tileIndex, fineY)
-
-		// タイル内の開始ビット位置（7..0）
-		startBit := uint8(7 - (fineX % TILE_SIZE))
-		for i := range span {
-			pixelIndex := uint8(startBit - uint8(i))
-			value := Decode2bppPixel(plane0, plane1, pixelIndex)
-			color := PALETTE[palette[value]]
-
-			p.lineBuffer[x+i].backgroundValue = color
-			p.lineBuffer[x+i].priority = 0x00
-			p.lineBuffer[x+i].isBgTransparent = (value == 0)
-		}
-
-		x += span
-		fineX += span
-		if fineX%TILE_SIZE == 0 {
-			// タイル境界を越えたらタイルを進める
-			v.incrementCoarseX()
-		}
-	}
-}
-
-// MARK: 指定したスキャンラインのスプライトを計算
-func (p *PPU) CalculateScanlineSprite(canvas *Canvas, scanline uint16) {
-	// スプライトが無効であれば描画しない
-	if !p.mask.spriteEnable || !p.config.Ppu.SPRITE_ENABLED {
-		return
-	}
-
-	// スプライトサイズの取得 (8 / 16)
-	spriteHeight := p.control.SpriteSize()
-	spriteCount := uint(p.secondaryOAMCount)
-
-	// スプライトの描画
-	for i := range spriteCount {
-		// 逆順に評価する (重なり順のため)
-		index := (spriteCount - 1) - i
-
-		/*
-			タイル属性
-			bit 76543210
-					VHP...CC
-
-			V: 垂直反転
-			H: 水平反転
-			P: 優先度 (0:前面, 1:背面)
-			C: パレット
-		*/
-
-		// 描画するスプライトを secondary OAM から取得
-		s := p.secondaryOAM[index]
-		spriteY := uint16(s.y) + 1
-		spriteX := uint16(s.x)
-		tileIndex := uint16(s.tile)
-		attributes := s.attribute
-		priority := (attributes >> 5) & 1
-
-		flipV := (attributes>>7)&1 == 1
-		flipH := (attributes>>6)&1 == 1
-		paletteIndex := attributes & 0b11
-		palette := p.spritePalette(paletteIndex)
-
-		// スプライトの何行目を描画するかを判定
-		var tileY uint16
-		if flipV {
-			tileY = (spriteY + uint16(spriteHeight-1)) - scanline
-		} else {
-			tileY = scanline - spriteY
-		}
-
-		// キャラクタROMからタイルデータを取得
-		plane0, plane1 := p.fetchSpriteRowBytes(tileIndex, spriteHeight, tileY)
-
-		// タイルデータを描画
-		for x := range TILE_SIZE {
-			// 現在のxに対応するビットを取り出す
-			var bit uint8
-			if flipH {
-				// 左右反転: x=0 が LSB(bit0)
-				bit = uint8(x)
-			} else {
-				// 反転なし: x=0 が MSB(bit7)
-				bit = uint8(7 - x)
-			}
-			value := Decode2bppPixel(plane0, plane1, bit)
-
-			// 透明ピクセルは描画しない
-			if value == 0 {
-				// @FIXME 飛ばすとOAMでの順番が若い透明なピクセルで上書きできない
-				continue
-			}
-
-			actualX := uint(spriteX) + uint(x)
-
-			// 画面外のピクセルは描画しない
-			if actualX >= SCREEN_WIDTH {
-				continue
-			}
-
-			// 左端のスプライト描画フラグが無効であれば描画しない
-			if !p.mask.leftmostSpriteEnable && actualX < TILE_SIZE {
-				continue
-			}
-
-			p.lineBuffer[actualX].spriteValue = PALETTE[palette[value]]
-			p.lineBuffer[actualX].priority = priority
-			p.lineBuffer[actualX].isSpriteTransparent = false
-		}
+// MARK: 待機しているNMIを取得
+func (p *PPU) PollNmiStatus() bool {
+	if p.nmi {
+		p.nmi = false
+		return true
+	} else {
+		return false
 	}
 }
 
 // MARK: BG面のカラーパレットを取得
 func (p *PPU) BackgroundColorPalette(attrributeTable *[]uint8, tileColumn uint, tileRow uint) [4]uint8 {
 	attrTableIdx := tileRow/4*TILE_SIZE + tileColumn/4
-	attrByte := (*attrributeTable)[attrTableIdx]
+	return p.backgroundPalette((*attrributeTable)[attrTableIdx], tileColumn, tileRow)
+}
 
+// MARK: 属性テーブルの1バイトからBG面のカラーパレットを取得
+func (p *PPU) backgroundPalette(attrByte uint8, tileColumn uint, tileRow uint) [4]uint8 {
 	var paletteIdx uint8
 	if tileColumn%4/2 == 0 && tileRow%4/2 == 0 {
 		paletteIdx = (attrByte) & 0b11
@@ -866,17 +651,6 @@ func (p *PPU) BackgroundColorPalette(attrributeTable *[]uint8, tileColumn uint,
 	return color
 }
 
-// MARK: スプライトのカラーパレットを取得
-func (p *PPU) spritePalette(paletteIndex uint8) [4]uint8 {
-	var start uint = 0x11 + uint(paletteIndex*4)
-	return [4]uint8{
-		0,
-		p.paletteTable[start+0],
-		p.paletteTable[start+1],
-		p.paletteTable[start+2],
-	}
-}
-
 // MARK: 2bpp(plane0 / plane1) の指定bit(0 ~ 8)からピクセルの値 (0 ~ 3) を取得
 func Decode2bppPixel(plane0 uint8, plane1 uint8, bit uint8) uint8 {
 	return ((plane1>>bit)&1)<<1 | ((plane0 >> bit) & 1)
@@ -886,22 +660,13 @@ func Decode2bppPixel(plane0 uint8, plane1 uint8, bit uint8) uint8 {
 func (p *PPU) Tick(canvas *Canvas, cycles uint) bool {
 	for range cycles {
 		pixel := p.cycles // 0 ~ 340
+		p.dots++
 
 		// 描画設定
 		isRenderingEnabled := p.mask.backgroundEnable || p.mask.spriteEnable
 		isRenderLine := (SCANLINE_START <= p.scanline && p.scanline < SCANLINE_POSTRENDER)
-		isPreRenderLine := p.scanline == SCANLINE_PRERENDER
-		isVBlankLine := p.scanline == SCANLINE_VBLANK
-
-		// ライン開始時点のVレジスタのスナップショットを取る
-		if pixel == 0 {
-			/*
-				Vレジスタは直前の321 ~ 336pixelで2タイル進んでいるため，
-				描画用スナップショットはTレジスタの水平ビットで補正して使用する
-			*/
-			p.vLineStart = p.v
-			p.t.copyHorizontalBitsTo(&p.vLineStart)
-		}
+		isPreRenderLine := p.scanline == p.preRenderLine
+		isVBlankLine := p.scanline == p.vblankLine
 
 		// プリレンダーラインのpixel 1で各種フラグをクリア
 		if isPreRenderLine && pixel == 1 {
@@ -918,72 +683,14 @@ func (p *PPU) Tick(canvas *Canvas, cycles uint) bool {
 			}
 		}
 
-		// 各スキャンライン開始(pixel 0)に secondary OAM を評価
-		if pixel == 0 {
-			// Overflow はライン単位でクリアされる
-			if isRenderLine || isPreRenderLine {
-				p.status.SetSpriteOverflow(false)
-			}
-
-			if isRenderLine {
-				p.evaluateSecondaryOam(p.scanline)
-				// スキャンライン開始時点で Sprite 0 hitのX座標を計算しておく
-				p.spriteZeroHitX = p.calculateSpriteZeroHitX(p.scanline)
-			} else {
-				p.clearSecondaryOam()
-				p.spriteZeroHitX = SPRITE_ZERO_HIT_NOT_FOUND
-			}
-		}
-
-		// スプライト0ヒットの判定
-		if isRenderLine && p.isSpriteZeroHit(pixel) {
-			p.status.SetSpriteZeroHit(true)
-		}
-
-		// Open Busの減衰
-		if p.openBusDecayTimer > 0 {
-			p.openBusDecayTimer--
-		} else {
-			p.openBus = 0x00
-		}
-
-		if isRenderingEnabled {
-			// レンダリング中のpixel処理
-			if isRenderLine || isPreRenderLine {
-				// 1 ~ 256pixel: 各タイルをフェッチする間に水平アドレスをインクリメント
-				if 1 <= pixel && pixel <= 256 {
-					if pixel%TILE_SIZE == 0 {
-						p.v.incrementCoarseX()
-					}
-				}
-
-				// 256pixel: 垂直アドレスをインクリメント
-				if pixel == SCREEN_WIDTH {
-					p.v.incrementY()
-				}
-
-				// 257pixel: 水平ビットのコピー (t -> v)
-				if pixel == SCREEN_WIDTH+1 {
-					p.t.copyHorizontalBitsTo(&p.v)
-				}
-
-				// 321 ~ 336pixel: 次のスキャンライン準備のため水平アドレスをインクリメント
-				if 321 <= pixel && pixel <= 336 {
-					if pixel%TILE_SIZE == 0 {
-						p.v.incrementCoarseX()
-					}
-				}
-			}
-
-			// プリレンダーラインでのみ垂直ビットをコピー (T -> V)
-			if isPreRenderLine && 280 <= pixel && pixel <= 304 {
-				p.t.copyVerticalBitsTo(&p.v)
-			}
+		// 背景・スプライトのフェッチとピクセルの出力
+		if isRenderLine || isPreRenderLine {
+			p.renderDot(canvas, pixel, isRenderLine, isPreRenderLine)
 		}
 
 		// NTSC: レンダリング有効な奇数フレームはプリレンダーラインが1pixel短い
 		var endDot uint
-		if isPreRenderLine && isRenderingEnabled && p.frameOdd {
+		if isPreRenderLine && isRenderingEnabled && p.frameOdd && p.skipOddFrameDot {
 			endDot = 339
 		} else {
 			endDot = 340
@@ -993,29 +700,22 @@ func (p *PPU) Tick(canvas *Canvas, cycles uint) bool {
 			// スキャンライン終端
 			p.cycles = 0
 
-			// マッパーによるIRQの判定
-			p.mapper.GenerateScanlineIRQ(p.scanline, isRenderingEnabled)
-
-			// 可視領域のスキャンラインを描画
-			if SCANLINE_START <= p.scanline && p.scanline < SCANLINE_POSTRENDER {
-				RenderScanlineToCanvas(p, canvas, p.scanline)
-
-				if p.scanline == SCANLINE_START {
-					// デバッグウィンドウ用のマッパースナップショットを保存
-					p.mapperSnapshot = p.mapper.Clone()
-				}
+			if p.scanline == SCANLINE_START {
+				// デバッグウィンドウ用のマッパースナップショットを保存
+				p.mapperSnapshot = p.mapper.Clone()
 			}
 
 			// スキャンラインを進める
 			p.scanline++
 
 			// プリレンダーラインに到達した時（フレーム終了）
-			if p.scanline > SCANLINE_PRERENDER {
+			if p.scanline > p.preRenderLine {
 				p.scanline = 0
 				p.nmi = false
 				p.status.SetSpriteZeroHit(false)
 				p.status.ClearVBlankStatus()
 				p.frameOdd = !p.frameOdd
+				p.frames++
 				return true
 			}
 			continue
@@ -1037,6 +737,11 @@ func (p *PPU) PaletteTable() *[PALETTE_TABLE_SIZE]uint8 {
 	return &p.paletteTable
 }
 
+// MARK: フレーム開始時点のマッパーの割り当てでネームテーブルを読み取るメソッド (デバッグ用)
+func (p *PPU) NameTableSnapshot(slot uint8, offset uint16) uint8 {
+	return p.readNameTableFrom(p.mapperSnapshot, slot&0x03, offset&0x3FF)
+}
+
 // MARK: マッパー を取得するメソッド
 func (p *PPU) MapperSnapshot() mappers.Mapper {
 	return p.mapperSnapshot
@@ -1062,6 +767,26 @@ func (p *PPU) SpriteSize() uint8 {
 	return p.control.SpriteSize()
 }
 
+// MARK: ドット (スキャンライン内のPPUサイクル) の取得
+func (p *PPU) Dot() uint {
+	return p.cycles
+}
+
+// MARK: 描画し終えたフレーム数の取得
+func (p *PPU) Frame() uint {
+	return p.frames
+}
+
+// MARK: Code/Data Logger の接続 (nilで切り離し)
+func (p *PPU) AttachCodeDataLogger(l *debugger.CodeDataLogger) {
+	p.cdl = l
+}
+
+// MARK: $2007でアクセスするVRAMアドレスの取得
+func (p *PPU) VRAMAddress() uint16 {
+	return p.v.ToByte() & 0x3FFF
+}
+
 // MARK: Scanline の取得メソッド
 func (p *PPU) Scanline() uint16 {
 	return p.scanline
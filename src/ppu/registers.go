@@ -253,6 +253,11 @@ func (mr *MaskRegister) ToByte() uint8 {
 	return value
 }
 
+// MARK: 色強調のビットの取得 (bit0: 赤, bit1: 緑, bit2: 青)
+func (mr *MaskRegister) Emphasis() uint8 {
+	return mr.ToByte() >> MASK_REG_EMPHASIZE_RED_POS
+}
+
 // uint8の値をマスクレジスタオブジェクトへ反映するメソッド
 func (mr *MaskRegister) update(value uint8) {
 	mr.grayscale = (value & (1 << MASK_REG_GRAYSCALE)) != 0
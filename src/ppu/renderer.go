@@ -12,47 +12,14 @@ const (
 	CANVAS_HEIGHT uint = SCREEN_HEIGHT
 )
 
-const (
-	PIXEL_TYPE_BACKGROUND PixelType = 0
-	PIXEL_TYPE_SPRITE     PixelType = 1
-)
-
-// MARK: PixelTypeの定義
-type PixelType byte
-
-// MARK: Pixelの定義
-type Pixel struct {
-	priority            uint8
-	backgroundValue     [3]uint8
-	spriteValue         [3]uint8
-	isBgTransparent     bool
-	isSpriteTransparent bool
-}
-
-func (p *Pixel) Value(ppu *PPU) [3]uint8 {
-	var color [3]uint8
-	if p.isBgTransparent && p.isSpriteTransparent {
-		color = PALETTE[ppu.paletteTable[0]]
-	} else if p.isBgTransparent && !p.isSpriteTransparent {
-		color = p.spriteValue
-	} else if !p.isBgTransparent && p.isSpriteTransparent {
-		color = p.backgroundValue
-	} else {
-		if p.priority == 0 {
-			color = p.spriteValue
-		} else {
-			color = p.backgroundValue
-		}
-	}
-	return color
-}
-
 // MARK: Canvasの定義
 type Canvas struct {
 	Width   uint
 	Height  uint
 	Buffers [2][uint(SCREEN_WIDTH) * uint(SCREEN_HEIGHT) * 3]byte // ダブルバッファリング
+	Colors  [2][uint(SCREEN_WIDTH) * uint(SCREEN_HEIGHT)]uint16   // PPUが出力した9bitのカラー番号 (NTSCフィルタ用)
 	index   int                                                   // 現在表示されていバッファのインデックス
+	isRaw   [2]bool                                               // 最後にPPUのカラー番号で描かれたか (RGBを直接描くと解除)
 
 	doubleBuffering bool
 }
@@ -70,6 +37,13 @@ func (c *Canvas) SetPixelAt(x uint, y uint, palette [3]uint8) {
 	if x >= c.Width || y >= c.Height {
 		return
 	}
+	// RGBを直接描いたバッファはカラー番号を持たない
+	c.isRaw[c.backIndex()] = false
+	c.setRGB(x, y, palette)
+}
+
+// MARK: キャンバスの指定した座標にRGBを書き込む
+func (c *Canvas) setRGB(x uint, y uint, palette [3]uint8) {
 
 	basePtr := int((y*c.Width + x) * 3)
 
@@ -87,6 +61,26 @@ func (c *Canvas) SetPixelAt(x uint, y uint, palette [3]uint8) {
 	}
 }
 
+// MARK: キャンバスの指定した座標に9bitのカラー番号の色をセット
+func (c *Canvas) SetColorAt(x uint, y uint, color uint16, palette *[EMPHASIS_PALETTE_SIZE][3]uint8) {
+	if x >= c.Width || y >= c.Height {
+		return
+	}
+	color %= EMPHASIS_PALETTE_SIZE
+	back := c.backIndex()
+	c.isRaw[back] = true
+	c.Colors[back][y*c.Width+x] = color
+	c.setRGB(x, y, palette[color])
+}
+
+// MARK: 描画先のバッファのインデックスを取得
+func (c *Canvas) backIndex() int {
+	if c.doubleBuffering {
+		return 1 - c.index
+	}
+	return 0
+}
+
 // 現在描画しているバッファと入れ替える
 func (c *Canvas) Swap() {
 	if c.doubleBuffering {
@@ -94,6 +88,18 @@ func (c *Canvas) Swap() {
 	}
 }
 
+// MARK: 表示中のバッファのカラー番号を取得 (RGBを直接描いたフレームなら nil)
+func (c *Canvas) FrontColors() *[uint(SCREEN_WIDTH) * uint(SCREEN_HEIGHT)]uint16 {
+	index := 0
+	if c.doubleBuffering {
+		index = c.index
+	}
+	if !c.isRaw[index] {
+		return nil
+	}
+	return &c.Colors[index]
+}
+
 // 現在描画しているバッファの先頭のポインタを返す
 func (c *Canvas) FrontBuffer() *[uint(SCREEN_WIDTH) * uint(SCREEN_HEIGHT) * 3]byte {
 	if c.doubleBuffering {
@@ -102,14 +108,3 @@ func (c *Canvas) FrontBuffer() *[uint(SCREEN_WIDTH) * uint(SCREEN_HEIGHT) * 3]by
 		return &c.Buffers[0]
 	}
 }
-
-// MARK: 指定したスキャンラインをキャンバスに描画
-func RenderScanlineToCanvas(ppu *PPU, canvas *Canvas, scanline uint16) {
-	ppu.ClearLineBuffer()
-	ppu.CalculateScanlineBackground(canvas, scanline)
-	ppu.CalculateScanlineSprite(canvas, scanline)
-
-	for x := range SCREEN_WIDTH {
-		canvas.SetPixelAt(x, uint(scanline), ppu.lineBuffer[x].Value(ppu))
-	}
-}
@@ -0,0 +1,118 @@
+package ppu
+
+import (
+	"Famicom-emulator/cartridge"
+	"fmt"
+	"os"
+)
+
+// MARK: 変数定義
+var (
+	// Vs. システム (2C03/2C05) のRGBパレット (RGB各3bit)
+	RGB_PALETTE_2C03 = rgb333Palette([64]uint16{
+		0333, 0014, 0006, 0326, 0403, 0503, 0510, 0420, 0320, 0120, 0031, 0040, 0022, 0000, 0000, 0000,
+		0555, 0036, 0027, 0407, 0507, 0704, 0700, 0630, 0430, 0140, 0040, 0053, 0044, 0000, 0000, 0000,
+		0777, 0357, 0447, 0637, 0707, 0737, 0740, 0750, 0660, 0360, 0070, 0276, 0077, 0000, 0000, 0000,
+		0777, 0567, 0657, 0757, 0747, 0755, 0764, 0772, 0773, 0572, 0473, 0276, 0467, 0000, 0000, 0000,
+	})
+
+	/*
+		Vs. システム (2C04) のRGBパレット
+
+		4種類とも同じ64色 (2C03の色と2C04にだけある数色) を並べ替えたもので，
+		どの並びかは基板ごとに異なる (並びを知らないと正しい色にならない)
+	*/
+	RGB_PALETTE_2C04_0001 = rgb333Palette([64]uint16{
+		0755, 0637, 0700, 0447, 0044, 0120, 0222, 0704, 0777, 0333, 0750, 0503, 0403, 0660, 0320, 0777,
+		0357, 0653, 0310, 0360, 0467, 0657, 0764, 0027, 0760, 0276, 0000, 0200, 0666, 0444, 0707, 0014,
+		0003, 0567, 0757, 0070, 0077, 0022, 0053, 0507, 0000, 0420, 0747, 0510, 0407, 0006, 0740, 0000,
+		0000, 0140, 0555, 0031, 0572, 0326, 0770, 0630, 0020, 0036, 0040, 0111, 0773, 0737, 0430, 0473,
+	})
+	RGB_PALETTE_2C04_0002 = rgb333Palette([64]uint16{
+		0000, 0750, 0430, 0572, 0473, 0737, 0044, 0567, 0700, 0407, 0773, 0747, 0777, 0637, 0467, 0040,
+		0020, 0357, 0510, 0666, 0053, 0360, 0200, 0447, 0222, 0707, 0003, 0276, 0657, 0320, 0000, 0326,
+		0403, 0764, 0740, 0757, 0036, 0310, 0555, 0006, 0507, 0760, 0333, 0120, 0027, 0000, 0660, 0777,
+		0653, 0111, 0070, 0630, 0022, 0014, 0704, 0140, 0000, 0077, 0420, 0770, 0755, 0503, 0031, 0444,
+	})
+	RGB_PALETTE_2C04_0003 = rgb333Palette([64]uint16{
+		0507, 0737, 0473, 0555, 0040, 0777, 0567, 0120, 0014, 0000, 0764, 0320, 0704, 0666, 0653, 0467,
+		0447, 0044, 0503, 0027, 0140, 0430, 0630, 0053, 0333, 0326, 0000, 0006, 0700, 0510, 0747, 0755,
+		0637, 0020, 0003, 0770, 0111, 0750, 0740, 0777, 0360, 0403, 0357, 0707, 0036, 0444, 0000, 0310,
+		0077, 0200, 0572, 0757, 0420, 0070, 0660, 0222, 0031, 0000, 0657, 0773, 0407, 0276, 0760, 0022,
+	})
+	RGB_PALETTE_2C04_0004 = rgb333Palette([64]uint16{
+		0430, 0326, 0044, 0660, 0000, 0755, 0014, 0630, 0555, 0310, 0070, 0003, 0764, 0770, 0040, 0572,
+		0737, 0200, 0027, 0747, 0000, 0222, 0510, 0740, 0653, 0053, 0447, 0140, 0403, 0000, 0473, 0357,
+		0503, 0031, 0420, 0006, 0407, 0507, 0333, 0704, 0022, 0666, 0036, 0020, 0111, 0773, 0444, 0707,
+		0757, 0777, 0320, 0700, 0760, 0276, 0777, 0467, 0000, 0750, 0637, 0567, 0360, 0657, 0077, 0120,
+	})
+)
+
+// MARK: RGB333 (8進数3桁) のパレットを24bitカラーへ変換
+func rgb333Palette(table [64]uint16) [64][3]uint8 {
+	var palette [64][3]uint8
+	for i, c := range table {
+		palette[i] = [3]uint8{
+			uint8((c >> 6 & 0b111) * 255 / 7), // R
+			uint8((c >> 3 & 0b111) * 255 / 7), // G
+			uint8((c & 0b111) * 255 / 7),      // B
+		}
+	}
+	return palette
+}
+
+// MARK: .palファイル (RGB×64色) の読み込み
+func LoadPaletteFile(path string) ([64][3]uint8, error) {
+	var palette [64][3]uint8
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return palette, err
+	}
+	if len(data) < len(palette)*3 {
+		return palette, fmt.Errorf("palette file %s is too short (%d bytes)", path, len(data))
+	}
+	for i := range palette {
+		copy(palette[i][:], data[i*3:i*3+3])
+	}
+	return palette, nil
+}
+
+// MARK: Vs. システムのRGB PPUのパレットの選択
+func (p *PPU) applyVsPalette() {
+	vsPPU := p.config.System.VS_PPU
+	switch {
+	case vsPPU.IsScrambledPalette():
+		// 2C04は色の並びが基板ごとに異なるため，内蔵の並びを使う (ダンプしたパレットがあればそちらを優先)
+		p.palette = scrambledPalette(vsPPU)
+		if path := p.config.System.VS_PALETTE; path != "" {
+			palette, err := LoadPaletteFile(path)
+			if err != nil {
+				fmt.Printf("[Warning] PPU: couldn't load Vs. palette (%v), using the built-in %s palette.\n", err, vsPPU)
+			} else {
+				p.palette = palette
+			}
+		}
+	default:
+		p.palette = RGB_PALETTE_2C03
+	}
+	p.emphasisPalette = emphasisPalette(p.palette, vsPPU.IsRGB())
+}
+
+// MARK: 2C04の内蔵パレットの取得
+func scrambledPalette(vsPPU cartridge.VsPPUType) [64][3]uint8 {
+	switch vsPPU {
+	case cartridge.VS_PPU_RP2C04_0001:
+		return RGB_PALETTE_2C04_0001
+	case cartridge.VS_PPU_RP2C04_0002:
+		return RGB_PALETTE_2C04_0002
+	case cartridge.VS_PPU_RP2C04_0003:
+		return RGB_PALETTE_2C04_0003
+	default:
+		return RGB_PALETTE_2C04_0004
+	}
+}
+
+// MARK: $2000と$2001が入れ替わっているかどうか (RC2C05)
+func (p *PPU) SwapsControlAndMask() bool {
+	return p.config.System.VS_PPU.SwapsControlAndMask()
+}
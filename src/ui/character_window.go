@@ -120,6 +120,7 @@ func (c *CharacterWindow) Update() {
 
 	// パレットはグレースケールで用意
 	paletteTable := c.ppu.PaletteTable()
+	colors := c.ppu.Palette()
 	bgPalette := [4]uint8{
 		(*paletteTable)[0],
 		(*paletteTable)[1],
@@ -147,7 +148,7 @@ func (c *CharacterWindow) Update() {
 					for col := range int(ppu.TILE_SIZE) {
 						bit := (((b1 >> (7 - uint(col))) & 1) << 1) | ((b0 >> (7 - uint(col))) & 1)
 						index := bgPalette[bit]
-						color := ppu.PALETTE[index]
+						color := colors[index]
 
 						px := basePx + col
 						py := basePy + row
@@ -168,7 +169,7 @@ func (c *CharacterWindow) Update() {
 		py0 := (i/PALETTE_COLUMNS)*SWATCH + paletteTop
 
 		index := (*paletteTable)[i]
-		color := ppu.PALETTE[index]
+		color := colors[index]
 
 		for dy := range SWATCH {
 			py := py0 + dy
@@ -0,0 +1,270 @@
+package ui
+
+import (
+	"Famicom-emulator/cpu"
+	"Famicom-emulator/debugger"
+	"Famicom-emulator/ppu"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// MARK: 定数定義
+const (
+	DEBUGGER_COLUMNS        = int(ppu.SCREEN_WIDTH / ppu.TILE_SIZE) // 1行の文字数
+	DEBUGGER_DISASM_ROW     = 5                                     // 逆アセンブルの開始行
+	DEBUGGER_DISASM_LINES   = 17                                    // 逆アセンブルの行数
+	DEBUGGER_DISASM_BEFORE  = 5                                     // PCより前に表示する命令数
+	DEBUGGER_STACK_ROW      = 23                                    // スタックの開始行
+	DEBUGGER_STACK_ROWS     = 4                                     // スタックの行数
+	DEBUGGER_STACK_COLUMNS  = 8                                     // スタックの1行のバイト数
+	DEBUGGER_HELP_ROW       = 29                                    // キー操作の説明の行
+	DEBUGGER_MAX_INST_BYTES = 3                                     // 命令の最大バイト数
+)
+
+// MARK: DebuggerWindow の定義
+type DebuggerWindow struct {
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	texture  *sdl.Texture
+	debugger *debugger.Debugger
+	canvas   *ppu.Canvas
+	onClose  func(id uint32)
+	baseW    int
+	baseH    int
+	scale    int
+}
+
+// MARK: DebuggerWindow の作成メソッド
+func NewDebuggerWindow(d *debugger.Debugger, scale int, onClose func(id uint32)) (*DebuggerWindow, error) {
+	width := int(ppu.SCREEN_WIDTH)
+	height := int(ppu.SCREEN_HEIGHT)
+
+	win, err := sdl.CreateWindow(
+		"CPU Debugger",
+		sdl.WINDOWPOS_CENTERED,
+		sdl.WINDOWPOS_CENTERED,
+		int32(width*scale),
+		int32(height*scale),
+		sdl.WINDOW_SHOWN,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := sdl.CreateRenderer(win, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		win.Destroy()
+		return nil, err
+	}
+
+	t, err := r.CreateTexture(sdl.PIXELFORMAT_RGB24, sdl.TEXTUREACCESS_STREAMING, int32(width), int32(height))
+	if err != nil {
+		r.Destroy()
+		win.Destroy()
+		return nil, err
+	}
+
+	// 文字の描画用キャンバス (ダブルバッファリングは使わない)
+	canvas := &ppu.Canvas{Width: ppu.SCREEN_WIDTH, Height: ppu.SCREEN_HEIGHT}
+
+	return &DebuggerWindow{window: win, renderer: r, texture: t, debugger: d, canvas: canvas, onClose: onClose, baseW: width, baseH: height, scale: scale}, nil
+}
+
+// MARK: ウィンドウのID取得メソッド
+func (dw *DebuggerWindow) ID() uint32 {
+	id, _ := dw.window.GetID()
+	return id
+}
+
+// MARK: イベント処理メソッド
+func (dw *DebuggerWindow) HandleEvent(event sdl.Event) {
+	switch e := event.(type) {
+	case *sdl.WindowEvent:
+		if e.Event == sdl.WINDOWEVENT_CLOSE {
+			dw.requestClose()
+		}
+	case *sdl.KeyboardEvent:
+		if e.State == sdl.PRESSED {
+			switch e.Keysym.Sym {
+			case sdl.K_ESCAPE:
+				dw.requestClose()
+			case sdl.K_PLUS, sdl.K_SEMICOLON:
+				dw.setScale(dw.scale + 1)
+			case sdl.K_MINUS:
+				dw.setScale(dw.scale - 1)
+			case sdl.K_SPACE:
+				if dw.debugger.Paused() {
+					dw.debugger.Continue()
+				} else {
+					dw.debugger.Pause()
+				}
+			case sdl.K_i:
+				if dw.debugger.Paused() {
+					dw.debugger.StepInto()
+				}
+			case sdl.K_o:
+				if dw.debugger.Paused() {
+					dw.debugger.StepOver()
+				}
+			case sdl.K_u:
+				if dw.debugger.Paused() {
+					dw.debugger.StepOut()
+				}
+			}
+		}
+	}
+}
+
+// MARK: スケール設定メソッド
+func (dw *DebuggerWindow) setScale(s int) {
+	// 1 ~ 8 の間に設定
+	s = min(max(s, 1), 8)
+	if s == dw.scale {
+		return
+	}
+	dw.scale = s
+	if dw.window != nil {
+		dw.window.SetSize(int32(dw.baseW*dw.scale), int32(dw.baseH*dw.scale))
+	}
+}
+
+// MARK: ウィンドウの更新メソッド
+func (dw *DebuggerWindow) Update() {
+	ClearScreen(dw.canvas, fontPalette[0])
+
+	state := dw.debugger.State()
+	dw.drawLine(0, fmt.Sprintf("PC:%04X A:%02X X:%02X Y:%02X SP:%02X", state.PC, state.A, state.X, state.Y, state.SP))
+	dw.drawLine(1, fmt.Sprintf("P:%s SL:%d DOT:%d", flagString(state.P), state.Scanline, state.Dot))
+	if dw.debugger.Paused() {
+		dw.drawLine(2, fmt.Sprintf("CYC:%d PAUSED", state.Cycles))
+		dw.drawLine(3, dw.debugger.Reason())
+	} else {
+		dw.drawLine(2, fmt.Sprintf("CYC:%d RUNNING", state.Cycles))
+	}
+
+	// PC周辺の逆アセンブル ('>' は現在の命令，'*' はブレークポイント)
+	breakpoints := make(map[uint16]bool)
+	for _, bp := range dw.debugger.Breakpoints() {
+		if bp.Enabled {
+			breakpoints[bp.Address] = true
+		}
+	}
+	lines := disassembleAround(dw.debugger.Peek, dw.debugger.Symbols().Lookup, state.PC, DEBUGGER_DISASM_BEFORE, DEBUGGER_DISASM_LINES)
+	for i, inst := range lines {
+		marker := " "
+		if inst.Address == state.PC {
+			marker = ">"
+		}
+		bp := " "
+		if breakpoints[inst.Address] {
+			bp = "*"
+		}
+		dw.drawLine(DEBUGGER_DISASM_ROW+i, fmt.Sprintf("%s%s%04X %s", marker, bp, inst.Address, inst))
+	}
+
+	// スタック ($0100-$01FF) のSP周辺
+	dw.drawLine(DEBUGGER_STACK_ROW-1, "STACK")
+	stackBytes := DEBUGGER_STACK_ROWS * DEBUGGER_STACK_COLUMNS
+	start := min(int(state.SP+1)&^(DEBUGGER_STACK_COLUMNS-1), 0x100-stackBytes)
+	for row := range DEBUGGER_STACK_ROWS {
+		address := 0x0100 + start + row*DEBUGGER_STACK_COLUMNS
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%04X", address)
+		for column := range DEBUGGER_STACK_COLUMNS {
+			// SPが指している位置 (次にプッシュされる位置) の直後に ':' を付ける
+			separator := " "
+			if address+column == 0x0100+int(state.SP)+1 {
+				separator = ":"
+			}
+			fmt.Fprintf(&sb, "%s%02X", separator, dw.debugger.Peek(uint16(address+column)))
+		}
+		dw.drawLine(DEBUGGER_STACK_ROW+row, sb.String())
+	}
+
+	dw.drawLine(DEBUGGER_HELP_ROW, "SPC:RUN I:INTO O:OVER U:OUT")
+
+	buffer := dw.canvas.FrontBuffer()
+	dw.texture.Update(nil, unsafe.Pointer(&buffer[0]), dw.baseW*3)
+}
+
+// MARK: 1行分の文字列を描画するメソッド (はみ出す部分は切り捨てる)
+func (dw *DebuggerWindow) drawLine(row int, text string) {
+	text = strings.ToUpper(text)
+	if len(text) > DEBUGGER_COLUMNS {
+		text = text[:DEBUGGER_COLUMNS]
+	}
+	DrawText(dw.canvas, 0, row*int(ppu.TILE_SIZE), text)
+}
+
+// MARK: 描画メソッド
+func (dw *DebuggerWindow) Render() {
+	dw.renderer.Clear()
+	dw.renderer.Copy(dw.texture, nil, nil)
+	dw.renderer.Present()
+}
+
+// MARK: SDLリソースの解放メソッド
+func (dw *DebuggerWindow) Close() {
+	if dw.texture != nil {
+		dw.texture.Destroy()
+	}
+	if dw.renderer != nil {
+		dw.renderer.Destroy()
+	}
+	if dw.window != nil {
+		dw.window.Destroy()
+	}
+	dw.texture = nil
+	dw.renderer = nil
+	dw.window = nil
+}
+
+// MARK: ウィンドウを閉じるメソッド
+func (dw *DebuggerWindow) requestClose() {
+	if dw.onClose != nil {
+		dw.onClose(dw.ID())
+	}
+}
+
+// MARK: ステータスフラグの文字列 (クリアされているフラグは '.')
+func flagString(p uint8) string {
+	const names = "NV-BDIZC"
+	flags := []byte(names)
+	for i := range flags {
+		if p&(0x80>>i) == 0 {
+			flags[i] = '.'
+		}
+	}
+	return string(flags)
+}
+
+// MARK: PC周辺の命令を逆アセンブルする関数
+func disassembleAround(read func(uint16) uint8, labeler cpu.Labeler, pc uint16, before int, total int) []cpu.DisassembledInstruction {
+	// PCより前の命令は可変長で一意に決まらないため，
+	// PCから遠い位置から順に逆アセンブルを試し，ちょうどPCに着地する開始位置を採用する
+	var lines []cpu.DisassembledInstruction
+	for back := before * DEBUGGER_MAX_INST_BYTES; back > 0; back-- {
+		var candidate []cpu.DisassembledInstruction
+		address := pc - uint16(back)
+		for address != pc && pc-address <= uint16(back) {
+			inst := cpu.Disassemble(read, address, labeler)
+			candidate = append(candidate, inst)
+			address += inst.Length()
+		}
+		if address == pc {
+			lines = candidate[max(len(candidate)-before, 0):]
+			break
+		}
+	}
+
+	address := pc
+	for len(lines) < total {
+		inst := cpu.Disassemble(read, address, labeler)
+		lines = append(lines, inst)
+		address += inst.Length()
+	}
+	return lines
+}
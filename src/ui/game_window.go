@@ -1,12 +1,22 @@
 package ui
 
 import (
+	"Famicom-emulator/config"
+	"Famicom-emulator/filter"
 	"Famicom-emulator/ppu"
+	"fmt"
+	"math"
 	"unsafe"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+// MARK: 定数定義
+const (
+	PIXEL_ASPECT_RATIO = 8.0 / 7.0 // NTSCのファミコンの1ピクセルの横幅 (縦を1として)
+	OVERSCAN_MAX       = 32        // 端から切り取れる最大のピクセル数
+)
+
 // MARK: GameWindow の定義
 type GameWindow struct {
 	window       *sdl.Window
@@ -16,10 +26,37 @@ type GameWindow struct {
 	isFullscreen bool
 	scale        int
 	onClose      func()
+
+	// NTSCフィルタ (出力は横2倍のテクスチャに描く)
+	ntsc        filter.NTSC
+	ntscTexture *sdl.Texture
+	isFiltered  bool // 直前のフレームをNTSCフィルタで描いたか
+
+	// 拡大フィルタ (出力サイズが変わったらテクスチャを作り直す)
+	upscale        filter.Upscale
+	upscaleTexture *sdl.Texture
+	upscaleWidth   int
+	upscaleHeight  int
+	isUpscaled     bool // 直前のフレームを拡大フィルタで描いたか
+
+	// 表示の設定
+	aspectCorrection bool
+	integerScaling   bool
+	overscan         config.OverscanConfig
+
+	// CRTマスク (表示サイズかマスクが変わったら作り直す)
+	crt        filter.CRT
+	crtTexture *sdl.Texture
+	crtWidth   int32
+	crtHeight  int32
+	crtMask    filter.CRTMask
 }
 
 // MARK: GameWindow の作成メソッド
-func NewGameWindow(scale int, isFullscreen bool, canvas *ppu.Canvas, onClose func()) (*GameWindow, error) {
+func NewGameWindow(config config.RenderConfig, canvas *ppu.Canvas, onClose func()) (*GameWindow, error) {
+	scale := config.SCALE_FACTOR
+	isFullscreen := config.FULLSCREEN
+
 	w, err := sdl.CreateWindow(
 		"Famicom emu",
 		sdl.WINDOWPOS_UNDEFINED,
@@ -38,20 +75,26 @@ func NewGameWindow(scale int, isFullscreen bool, canvas *ppu.Canvas, onClose fun
 		return nil, err
 	}
 
-	// フルスクリーン時にアスペクト比を保つため，論理サイズを指定
-	if err := r.SetLogicalSize(int32(ppu.SCREEN_WIDTH), int32(ppu.SCREEN_HEIGHT)); err != nil {
+	t, err := r.CreateTexture(
+		sdl.PIXELFORMAT_RGB24,
+		sdl.TEXTUREACCESS_STREAMING,
+		int32(ppu.SCREEN_WIDTH),
+		int32(ppu.SCREEN_HEIGHT),
+	)
+	if err != nil {
 		r.Destroy()
 		w.Destroy()
 		return nil, err
 	}
 
-	t, err := r.CreateTexture(
+	nt, err := r.CreateTexture(
 		sdl.PIXELFORMAT_RGB24,
 		sdl.TEXTUREACCESS_STREAMING,
-		int32(ppu.SCREEN_WIDTH),
-		int32(ppu.SCREEN_HEIGHT),
+		int32(filter.NTSC_OUTPUT_WIDTH),
+		int32(filter.NTSC_OUTPUT_HEIGHT),
 	)
 	if err != nil {
+		t.Destroy()
 		r.Destroy()
 		w.Destroy()
 		return nil, err
@@ -61,7 +104,36 @@ func NewGameWindow(scale int, isFullscreen bool, canvas *ppu.Canvas, onClose fun
 		w.SetFullscreen(sdl.WINDOW_FULLSCREEN_DESKTOP)
 	}
 
-	return &GameWindow{window: w, renderer: r, texture: t, canvas: canvas, isFullscreen: isFullscreen, scale: scale, onClose: onClose}, nil
+	preset, ok := filter.ParseNTSCPreset(config.NTSC_FILTER)
+	if !ok {
+		fmt.Printf("[Warning] Render: unknown NTSC filter '%s', using none.\n", config.NTSC_FILTER)
+	}
+
+	upscaler, ok := filter.ParseUpscaler(config.UPSCALER)
+	if !ok {
+		fmt.Printf("[Warning] Render: unknown upscaler '%s', using nearest.\n", config.UPSCALER)
+	}
+
+	mask, ok := filter.ParseCRTMask(config.CRT_MASK)
+	if !ok {
+		fmt.Printf("[Warning] Render: unknown CRT mask '%s', using none.\n", config.CRT_MASK)
+	}
+
+	g := &GameWindow{window: w, renderer: r, texture: t, ntscTexture: nt, canvas: canvas, isFullscreen: isFullscreen, scale: scale, onClose: onClose}
+	g.ntsc.Init(preset)
+	g.upscale.Init(upscaler)
+	g.crt.Init(mask, config.CRT_MASK_STRENGTH)
+	g.aspectCorrection = config.ASPECT_CORRECTION
+	g.integerScaling = config.INTEGER_SCALING
+	g.overscan = config.OVERSCAN
+	g.overscan.TOP = min(max(g.overscan.TOP, 0), OVERSCAN_MAX)
+	g.overscan.BOTTOM = min(max(g.overscan.BOTTOM, 0), OVERSCAN_MAX)
+	g.overscan.LEFT = min(max(g.overscan.LEFT, 0), OVERSCAN_MAX)
+	g.overscan.RIGHT = min(max(g.overscan.RIGHT, 0), OVERSCAN_MAX)
+
+	// 切り取りとピクセル比を反映したウィンドウサイズにする
+	g.setScale(scale)
+	return g, nil
 }
 
 // MARK: ウィンドウのID取得メソッド
@@ -92,6 +164,15 @@ func (g *GameWindow) HandleEvent(event sdl.Event) {
 					g.window.SetFullscreen(sdl.WINDOW_FULLSCREEN_DESKTOP)
 				}
 				g.isFullscreen = !g.isFullscreen
+			case sdl.K_t:
+				g.ntsc.SetPreset(g.ntsc.Preset().Next())
+				fmt.Printf("[Render] NTSC filter: %s\n", g.ntsc.Preset())
+			case sdl.K_TAB:
+				g.upscale.SetUpscaler(g.upscale.Upscaler().Next())
+				fmt.Printf("[Render] Upscaler: %s\n", g.upscale.Upscaler())
+			case sdl.K_m:
+				g.crt.SetMask(g.crt.Mask().Next())
+				fmt.Printf("[Render] CRT mask: %s\n", g.crt.Mask())
 			}
 		}
 	}
@@ -104,27 +185,187 @@ func (g *GameWindow) setScale(s int) {
 	g.scale = s
 
 	if g.window != nil {
-		g.window.SetSize(int32(ppu.SCREEN_WIDTH)*int32(s),
-			int32(ppu.SCREEN_HEIGHT)*int32(s))
+		_, _, width, height := g.visibleArea()
+		g.window.SetSize(int32(math.Round(g.displayWidth(width)*float64(s))),
+			int32(height)*int32(s))
+	}
+}
+
+// MARK: オーバースキャンを除いた範囲の取得 (ファミコンの画面上の座標)
+func (g *GameWindow) visibleArea() (int, int, int, int) {
+	left, top := g.overscan.LEFT, g.overscan.TOP
+	width := int(ppu.SCREEN_WIDTH) - left - g.overscan.RIGHT
+	height := int(ppu.SCREEN_HEIGHT) - top - g.overscan.BOTTOM
+	return left, top, width, height
+}
+
+// MARK: ピクセル比を反映した表示上の横幅の取得
+func (g *GameWindow) displayWidth(width int) float64 {
+	if g.aspectCorrection {
+		return float64(width) * PIXEL_ASPECT_RATIO
+	}
+	return float64(width)
+}
+
+/*
+	表示位置の計算
+
+	1. オーバースキャンを除いた範囲をテクスチャから切り出す (NTSCフィルタや拡大フィルタの出力は倍率を掛ける)
+	2. 8:7のピクセル比なら横を 8/7 倍にした大きさを基準にする
+	3. 出力先に収まる最大の倍率で拡大し (整数倍のみなら切り捨て)，中央に置いて余りを黒帯にする
+*/
+// MARK: テクスチャの切り出し範囲と表示位置の計算
+func (g *GameWindow) layout(textureWidth int, textureHeight int) (sdl.Rect, sdl.Rect) {
+	left, top, width, height := g.visibleArea()
+	scaleX := textureWidth / int(ppu.SCREEN_WIDTH)
+	scaleY := textureHeight / int(ppu.SCREEN_HEIGHT)
+	src := sdl.Rect{X: int32(left * scaleX), Y: int32(top * scaleY), W: int32(width * scaleX), H: int32(height * scaleY)}
+
+	outputWidth, outputHeight, err := g.renderer.GetOutputSize()
+	if err != nil || outputWidth <= 0 || outputHeight <= 0 {
+		return src, sdl.Rect{W: int32(g.displayWidth(width)), H: int32(height)}
+	}
+
+	displayWidth := g.displayWidth(width)
+	scale := min(float64(outputWidth)/displayWidth, float64(outputHeight)/float64(height))
+	if g.integerScaling && scale >= 1 {
+		scale = math.Floor(scale)
 	}
+
+	w := int32(math.Round(displayWidth * scale))
+	h := int32(math.Round(float64(height) * scale))
+	dst := sdl.Rect{X: (outputWidth - w) / 2, Y: (outputHeight - h) / 2, W: w, H: h}
+	return src, dst
 }
 
 // MARK: ウィンドウの更新メソッド
 func (g *GameWindow) Update() {
+	// NTSCフィルタはPPUのカラー番号で描かれたフレームにだけかける
+	if g.ntsc.Preset() != filter.NTSC_PRESET_NONE {
+		if colors := g.canvas.FrontColors(); colors != nil {
+			out := g.ntsc.Apply(colors, g.canvas.FrontBuffer())
+			g.ntscTexture.Update(nil, unsafe.Pointer(&out[0]), filter.NTSC_OUTPUT_WIDTH*3)
+			g.isFiltered = true
+			g.isUpscaled = false
+			return
+		}
+	}
+	g.isFiltered = false
+
 	// 現在描画中のバッファを元に画面を更新
 	buf := g.canvas.FrontBuffer()
-	g.texture.Update(nil, unsafe.Pointer(&(*buf)[0]), int(g.canvas.Width*3))
+	width, height := int(g.canvas.Width), int(g.canvas.Height)
+	if g.upscale.Upscaler() == filter.UPSCALER_NEAREST {
+		g.texture.Update(nil, unsafe.Pointer(&(*buf)[0]), width*3)
+		g.isUpscaled = false
+		return
+	}
+
+	out, outWidth, outHeight := g.upscale.Apply((*buf)[:], width, height)
+	if err := g.resizeUpscaleTexture(outWidth, outHeight); err != nil {
+		fmt.Printf("[Warning] Render: failed to create upscale texture: %v\n", err)
+		g.upscale.SetUpscaler(filter.UPSCALER_NEAREST)
+		g.texture.Update(nil, unsafe.Pointer(&(*buf)[0]), width*3)
+		g.isUpscaled = false
+		return
+	}
+	g.upscaleTexture.Update(nil, unsafe.Pointer(&out[0]), outWidth*3)
+	g.isUpscaled = true
+}
+
+// MARK: 拡大フィルタの出力サイズに合わせてテクスチャを作り直す
+func (g *GameWindow) resizeUpscaleTexture(width int, height int) error {
+	if g.upscaleTexture != nil && g.upscaleWidth == width && g.upscaleHeight == height {
+		return nil
+	}
+
+	t, err := g.renderer.CreateTexture(
+		sdl.PIXELFORMAT_RGB24,
+		sdl.TEXTUREACCESS_STREAMING,
+		int32(width),
+		int32(height),
+	)
+	if err != nil {
+		return err
+	}
+	if g.upscaleTexture != nil {
+		g.upscaleTexture.Destroy()
+	}
+	g.upscaleTexture = t
+	g.upscaleWidth, g.upscaleHeight = width, height
+	return nil
+}
+
+// MARK: CRTマスクのテクスチャを表示サイズに合わせて作り直す
+func (g *GameWindow) updateCRTTexture(dst sdl.Rect) error {
+	if g.crtTexture != nil && g.crtWidth == dst.W && g.crtHeight == dst.H && g.crtMask == g.crt.Mask() {
+		return nil
+	}
+
+	t, err := g.renderer.CreateTexture(
+		sdl.PIXELFORMAT_RGB24,
+		sdl.TEXTUREACCESS_STREAMING,
+		dst.W,
+		dst.H,
+	)
+	if err != nil {
+		return err
+	}
+	// 画面に乗算で重ねる
+	t.SetBlendMode(sdl.BLENDMODE_MOD)
+
+	_, _, _, height := g.visibleArea()
+	overlay := g.crt.Overlay(int(dst.W), int(dst.H), float64(dst.H)/float64(height))
+	t.Update(nil, unsafe.Pointer(&overlay[0]), int(dst.W)*3)
+
+	if g.crtTexture != nil {
+		g.crtTexture.Destroy()
+	}
+	g.crtTexture = t
+	g.crtWidth, g.crtHeight = dst.W, dst.H
+	g.crtMask = g.crt.Mask()
+	return nil
 }
 
 // MARK: 描画メソッド
 func (g *GameWindow) Render() {
+	texture := g.texture
+	textureWidth, textureHeight := int(ppu.SCREEN_WIDTH), int(ppu.SCREEN_HEIGHT)
+	if g.isFiltered {
+		texture = g.ntscTexture
+		textureWidth, textureHeight = filter.NTSC_OUTPUT_WIDTH, filter.NTSC_OUTPUT_HEIGHT
+	} else if g.isUpscaled {
+		texture = g.upscaleTexture
+		textureWidth, textureHeight = g.upscaleWidth, g.upscaleHeight
+	}
+	src, dst := g.layout(textureWidth, textureHeight)
+
+	// 余白は黒帯にする
+	g.renderer.SetDrawColor(0, 0, 0, 255)
 	g.renderer.Clear()
-	g.renderer.Copy(g.texture, nil, nil)
+	g.renderer.Copy(texture, &src, &dst)
+	if g.crt.Mask() != filter.CRT_MASK_NONE && dst.W > 0 && dst.H > 0 {
+		if err := g.updateCRTTexture(dst); err != nil {
+			fmt.Printf("[Warning] Render: failed to create CRT mask texture: %v\n", err)
+			g.crt.SetMask(filter.CRT_MASK_NONE)
+		} else {
+			g.renderer.Copy(g.crtTexture, nil, &dst)
+		}
+	}
 	g.renderer.Present()
 }
 
 // MARK: SDLリソースの解放メソッド
 func (g *GameWindow) Close() {
+	if g.crtTexture != nil {
+		g.crtTexture.Destroy()
+	}
+	if g.upscaleTexture != nil {
+		g.upscaleTexture.Destroy()
+	}
+	if g.ntscTexture != nil {
+		g.ntscTexture.Destroy()
+	}
 	if g.texture != nil {
 		g.texture.Destroy()
 	}
@@ -1,7 +1,6 @@
 package ui
 
 import (
-	"Famicom-emulator/cartridge/mappers"
 	"Famicom-emulator/ppu"
 	"unsafe"
 
@@ -103,41 +102,29 @@ func (n *NameTableWindow) setScale(s int) {
 
 // MARK: 更新メソッド
 func (n *NameTableWindow) Update() {
-	vramPtr := n.ppu.Vram()
-
 	const cols uint = 32
 	const rows uint = 30
-	const ntSize uint = 1024
 	width := cols * 2 * ppu.TILE_SIZE
 
-	// フレーム最初のマッパーでミラーリングを判別
+	// フレーム最初のマッパーでネームテーブルの割り当てを判別
 	mapper := n.ppu.MapperSnapshot()
-	mirroring := mapper.Mirroring()
 	bankBase := n.ppu.BackgroundPatternTableAddress()
+	colors := n.ppu.Palette()
 
 	// 4つのネームテーブルを描画
 	for nt := range 4 {
 		xOffset := uint(nt%2) * cols * ppu.TILE_SIZE
 		yOffset := uint(nt/2) * rows * ppu.TILE_SIZE
 
-		var physicalPage uint
-		switch mirroring {
-		case mappers.MIRRORING_VERTICAL:
-			physicalPage = uint(nt % 2) // 0,1,0,1
-		case mappers.MIRRORING_HORIZONTAL:
-			physicalPage = uint(nt / 2) // 0,0,1,1
-		default:
-			physicalPage = uint(nt % 2)
+		attributeTable := make([]uint8, 0x40)
+		for i := range attributeTable {
+			attributeTable[i] = n.ppu.NameTableSnapshot(uint8(nt), uint16(0x3C0+i))
 		}
 
-		base := physicalPage * ntSize
-		attrStart := base + 0x3C0
-		attributeTable := (*vramPtr)[int(attrStart):int(attrStart+0x40)]
-
 		for ty := range rows {
 			for tx := range cols {
-				idx := base + ty*cols + tx
-				tileIndex := (*vramPtr)[idx]
+				idx := ty*cols + tx
+				tileIndex := n.ppu.NameTableSnapshot(uint8(nt), uint16(idx))
 				palette := n.ppu.BackgroundColorPalette(&attributeTable, tx, ty)
 				tileBase := bankBase + uint16(tileIndex)*uint16(ppu.TILE_SIZE*2)
 
@@ -149,7 +136,7 @@ func (n *NameTableWindow) Update() {
 					for col := range ppu.TILE_SIZE {
 						bit := (((lower >> (7 - uint(col))) & 1) << 1) | ((upper >> (7 - uint(col))) & 1)
 						colorIdx := palette[bit]
-						color := ppu.PALETTE[colorIdx]
+						color := colors[colorIdx]
 
 						px := xOffset + tx*ppu.TILE_SIZE + col
 						py := yOffset + ty*ppu.TILE_SIZE + row
@@ -117,7 +117,8 @@ func (o *OAMWindow) Update() {
 
 	// パレットはスプライトのパレット0固定 ($3F10..$3F13)
 	paletteTable := o.ppu.PaletteTable()
-	bgColor := ppu.PALETTE[(*paletteTable)[0]]
+	colors := o.ppu.Palette()
+	bgColor := colors[(*paletteTable)[0]]
 	palette0 := [4]uint8{(*paletteTable)[16], (*paletteTable)[17], (*paletteTable)[18], (*paletteTable)[19]}
 
 	// フレーム最初のマッパーを使用
@@ -189,7 +190,7 @@ func (o *OAMWindow) Update() {
 					continue
 				}
 				colorIdx := palette0[bit]
-				color := ppu.PALETTE[colorIdx]
+				color := colors[colorIdx]
 
 				px := basePx + col
 				py := basePy + row
@@ -109,6 +109,114 @@ var (
 			0x7E, 0x3F, 0x0F, 0x1E, 0x3C, 0x78, 0x7E, 0x3F,
 			0x7E, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x7E, 0x00,
 		},
+		'0': {
+			0x3C, 0x7E, 0x7F, 0x77, 0x7F, 0x77, 0x3F, 0x1E,
+			0x3C, 0x66, 0x6E, 0x76, 0x66, 0x66, 0x3C, 0x00,
+		},
+		'1': {
+			0x18, 0x3C, 0x1C, 0x1C, 0x1C, 0x1C, 0x7E, 0x3F,
+			0x18, 0x38, 0x18, 0x18, 0x18, 0x18, 0x7E, 0x00,
+		},
+		'2': {
+			0x3C, 0x7E, 0x37, 0x0F, 0x36, 0x78, 0x7E, 0x3F,
+			0x3C, 0x66, 0x06, 0x0C, 0x30, 0x60, 0x7E, 0x00,
+		},
+		'3': {
+			0x3C, 0x7E, 0x37, 0x1F, 0x0E, 0x67, 0x3F, 0x1E,
+			0x3C, 0x66, 0x06, 0x1C, 0x06, 0x66, 0x3C, 0x00,
+		},
+		'4': {
+			0x0C, 0x1E, 0x3E, 0x7E, 0x7E, 0x3F, 0x0E, 0x06,
+			0x0C, 0x1C, 0x3C, 0x6C, 0x7E, 0x0C, 0x0C, 0x00,
+		},
+		'5': {
+			0x7E, 0x7F, 0x7C, 0x3E, 0x07, 0x67, 0x3F, 0x1E,
+			0x7E, 0x60, 0x7C, 0x06, 0x06, 0x66, 0x3C, 0x00,
+		},
+		'6': {
+			0x3C, 0x7E, 0x70, 0x7C, 0x7E, 0x77, 0x3F, 0x1E,
+			0x3C, 0x60, 0x60, 0x7C, 0x66, 0x66, 0x3C, 0x00,
+		},
+		'7': {
+			0x7E, 0x3F, 0x0F, 0x1E, 0x3C, 0x38, 0x38, 0x18,
+			0x7E, 0x06, 0x0C, 0x18, 0x30, 0x30, 0x30, 0x00,
+		},
+		'8': {
+			0x3C, 0x7E, 0x77, 0x3F, 0x7E, 0x77, 0x3F, 0x1E,
+			0x3C, 0x66, 0x66, 0x3C, 0x66, 0x66, 0x3C, 0x00,
+		},
+		'9': {
+			0x3C, 0x7E, 0x77, 0x3F, 0x1F, 0x0F, 0x3E, 0x1C,
+			0x3C, 0x66, 0x66, 0x3E, 0x06, 0x0C, 0x38, 0x00,
+		},
+		'$': {
+			0x18, 0x3E, 0x7F, 0x3C, 0x1E, 0x7F, 0x3E, 0x0C,
+			0x18, 0x3E, 0x60, 0x3C, 0x06, 0x7C, 0x18, 0x00,
+		},
+		'#': {
+			0x6C, 0x7E, 0xFE, 0x7F, 0xFE, 0x7F, 0x7E, 0x36,
+			0x6C, 0x6C, 0xFE, 0x6C, 0xFE, 0x6C, 0x6C, 0x00,
+		},
+		'(': {
+			0x0C, 0x1E, 0x3C, 0x38, 0x38, 0x18, 0x0C, 0x06,
+			0x0C, 0x18, 0x30, 0x30, 0x30, 0x18, 0x0C, 0x00,
+		},
+		')': {
+			0x30, 0x18, 0x0C, 0x0E, 0x0E, 0x1E, 0x3C, 0x18,
+			0x30, 0x18, 0x0C, 0x0C, 0x0C, 0x18, 0x30, 0x00,
+		},
+		'[': {
+			0x3C, 0x3E, 0x38, 0x38, 0x38, 0x38, 0x3C, 0x1E,
+			0x3C, 0x30, 0x30, 0x30, 0x30, 0x30, 0x3C, 0x00,
+		},
+		']': {
+			0x3C, 0x1E, 0x0E, 0x0E, 0x0E, 0x0E, 0x3E, 0x1E,
+			0x3C, 0x0C, 0x0C, 0x0C, 0x0C, 0x0C, 0x3C, 0x00,
+		},
+		',': {
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x18, 0x1C, 0x3C,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x18, 0x18, 0x30,
+		},
+		'.': {
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x18, 0x1C, 0x0C,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x18, 0x18, 0x00,
+		},
+		':': {
+			0x00, 0x18, 0x1C, 0x0C, 0x18, 0x1C, 0x0C, 0x00,
+			0x00, 0x18, 0x18, 0x00, 0x18, 0x18, 0x00, 0x00,
+		},
+		'-': {
+			0x00, 0x00, 0x00, 0x7E, 0x3F, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x7E, 0x00, 0x00, 0x00, 0x00,
+		},
+		'+': {
+			0x00, 0x18, 0x1C, 0x7E, 0x3F, 0x1C, 0x0C, 0x00,
+			0x00, 0x18, 0x18, 0x7E, 0x18, 0x18, 0x00, 0x00,
+		},
+		'=': {
+			0x00, 0x00, 0x7E, 0x3F, 0x7E, 0x3F, 0x00, 0x00,
+			0x00, 0x00, 0x7E, 0x00, 0x7E, 0x00, 0x00, 0x00,
+		},
+		'>': {
+			0x30, 0x18, 0x0C, 0x06, 0x0F, 0x1E, 0x3C, 0x18,
+			0x30, 0x18, 0x0C, 0x06, 0x0C, 0x18, 0x30, 0x00,
+		},
+		'/': {
+			0x06, 0x0F, 0x1E, 0x3C, 0x78, 0xF0, 0xE0, 0x40,
+			0x06, 0x0C, 0x18, 0x30, 0x60, 0xC0, 0x80, 0x00,
+		},
+		'*': {
+			0x00, 0x66, 0x3F, 0xFF, 0x7F, 0x7E, 0x33, 0x00,
+			0x00, 0x66, 0x3C, 0xFF, 0x3C, 0x66, 0x00, 0x00,
+		},
+		'!': {
+			0x18, 0x1C, 0x1C, 0x1C, 0x1C, 0x0C, 0x18, 0x0C,
+			0x18, 0x18, 0x18, 0x18, 0x18, 0x00, 0x18, 0x00,
+		},
+		'_': {
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x7E, 0x3F,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x7E, 0x00,
+		},
 		' ': {
 			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"Famicom-emulator/apu"
+	"Famicom-emulator/debugger"
 	"Famicom-emulator/ppu"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -164,3 +165,20 @@ func (wm *WindowManager) ToggleOAMWindow(p *ppu.PPU, scale int) (uint32, error)
 	wm.Add(ow)
 	return ow.ID(), nil
 }
+
+// MARK: DebuggerWindow の表示/非表示切り替えメソッド
+func (wm *WindowManager) ToggleDebuggerWindow(d *debugger.Debugger, scale int) (uint32, error) {
+	for _, w := range wm.windows {
+		if dw, ok := w.(*DebuggerWindow); ok {
+			id := dw.ID()
+			wm.Remove(id)
+			return 0, nil
+		}
+	}
+	dw, err := NewDebuggerWindow(d, scale, func(id uint32) { wm.Remove(id) })
+	if err != nil {
+		return 0, err
+	}
+	wm.Add(dw)
+	return dw.ID(), nil
+}